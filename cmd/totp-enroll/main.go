@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// totp-enroll creates a totp secret for a user, stores it in the secret file
+// used by auth-http-proxy and prints the otpauth:// uri for authenticator apps.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bborbe/errors"
+	flag "github.com/bborbe/flagenv"
+	"github.com/golang/glog"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var (
+	userPtr           = flag.String("user", "", "username to enroll")
+	issuerPtr         = flag.String("issuer", "auth-http-proxy", "issuer shown in authenticator app")
+	totpSecretFilePtr = flag.String("totp-secret-file", "", "totp secrets (username:secret)")
+)
+
+func main() {
+	defer glog.Flush()
+	glog.CopyStandardLogTo("info")
+	flag.Parse()
+
+	ctx := context.Background()
+	if err := run(ctx); err != nil {
+		glog.Exit(err)
+	}
+}
+
+func run(ctx context.Context) error {
+	if len(*userPtr) == 0 {
+		return errors.Errorf(ctx, "parameter user missing")
+	}
+	secret, err := pkg.GenerateTotpSecret()
+	if err != nil {
+		return errors.Wrapf(ctx, err, "generate totp secret failed")
+	}
+	if len(*totpSecretFilePtr) > 0 {
+		if err := pkg.WriteTotpSecret(
+			ctx,
+			pkg.TotpSecretFile(*totpSecretFilePtr),
+			pkg.UserName(*userPtr),
+			secret,
+		); err != nil {
+			return errors.Wrapf(ctx, err, "write totp secret failed")
+		}
+		glog.V(1).Infof("stored totp secret of user %s in %s", *userPtr, *totpSecretFilePtr)
+	} else {
+		fmt.Fprintf(os.Stderr, "secret (store it in ldap or the totp secret file): %s\n", secret)
+	}
+	fmt.Println(pkg.TotpURI(*issuerPtr, pkg.UserName(*userPtr), secret))
+	return nil
+}
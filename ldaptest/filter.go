@@ -0,0 +1,160 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ldaptest
+
+import (
+	"strings"
+
+	ber "gopkg.in/asn1-ber.v1"
+)
+
+const (
+	filterAnd             = 0
+	filterOr              = 1
+	filterNot             = 2
+	filterEqualityMatch   = 3
+	filterSubstrings      = 4
+	filterPresent         = 7
+	filterApproxMatch     = 8
+	filterExtensibleMatch = 9
+
+	// matchingRuleInChain is the Active Directory rule LDAP_MATCHING_RULE_IN_CHAIN.
+	matchingRuleInChain = "1.2.840.113556.1.4.1941"
+)
+
+// matches evaluates the search filter. Ordering filters are not supported and never match.
+func (s *Server) matches(entry Entry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case filterAnd:
+		for _, child := range filter.Children {
+			if !s.matches(entry, child) {
+				return false
+			}
+		}
+		return true
+	case filterOr:
+		for _, child := range filter.Children {
+			if s.matches(entry, child) {
+				return true
+			}
+		}
+		return false
+	case filterNot:
+		return len(filter.Children) == 1 && !s.matches(entry, filter.Children[0])
+	case filterEqualityMatch, filterApproxMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		return hasValue(entry, stringValue(filter.Children[0]), stringValue(filter.Children[1]))
+	case filterSubstrings:
+		return matchesSubstrings(entry, filter)
+	case filterPresent:
+		name := stringValue(filter)
+		return strings.EqualFold(name, "objectClass") || len(entry.Values(name)) > 0
+	case filterExtensibleMatch:
+		return s.matchesExtensible(entry, filter)
+	default:
+		return false
+	}
+}
+
+func (s *Server) matchesExtensible(entry Entry, filter *ber.Packet) bool {
+	var rule, name, value string
+	for _, child := range filter.Children {
+		switch child.Tag {
+		case 1:
+			rule = stringValue(child)
+		case 2:
+			name = stringValue(child)
+		case 3:
+			value = stringValue(child)
+		}
+	}
+	if rule == matchingRuleInChain {
+		return s.inChain(entry, name, value, map[string]bool{})
+	}
+	return hasValue(entry, name, value)
+}
+
+// inChain returns true if the entry references the value directly or through
+// the same attribute of referenced entries.
+func (s *Server) inChain(entry Entry, name string, value string, seen map[string]bool) bool {
+	key := normalizeDN(entry.DN)
+	if seen[key] {
+		return false
+	}
+	seen[key] = true
+	for _, member := range entry.Values(name) {
+		if equalDN(member, value) {
+			return true
+		}
+		if child, ok := s.entry(member); ok && s.inChain(child, name, value, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesSubstrings(entry Entry, filter *ber.Packet) bool {
+	if len(filter.Children) != 2 {
+		return false
+	}
+	for _, value := range entry.Values(stringValue(filter.Children[0])) {
+		if matchesSubstring(strings.ToLower(value), filter.Children[1].Children) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesSubstring(value string, parts []*ber.Packet) bool {
+	for _, part := range parts {
+		substring := strings.ToLower(stringValue(part))
+		switch part.Tag {
+		case 0:
+			if !strings.HasPrefix(value, substring) {
+				return false
+			}
+			value = value[len(substring):]
+		case 1:
+			index := strings.Index(value, substring)
+			if index < 0 {
+				return false
+			}
+			value = value[index+len(substring):]
+		case 2:
+			if !strings.HasSuffix(value, substring) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// hasValue compares case insensitive, values containing = are compared as dn.
+func hasValue(entry Entry, name string, value string) bool {
+	for _, v := range entry.Values(name) {
+		if strings.EqualFold(v, value) || strings.Contains(v, "=") && equalDN(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, part := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(part))
+	}
+	return strings.Join(parts, ",")
+}
+
+func equalDN(a string, b string) bool {
+	return normalizeDN(a) == normalizeDN(b)
+}
+
+func hasSuffixDN(dn string, baseDN string) bool {
+	return strings.HasSuffix(normalizeDN(dn), ","+normalizeDN(baseDN))
+}
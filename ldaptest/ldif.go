@@ -0,0 +1,117 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ldaptest
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bborbe/errors"
+)
+
+// ReadLDIFFile reads all entries of the given LDIF file.
+func ReadLDIFFile(ctx context.Context, path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "open ldif file %s failed", path)
+	}
+	defer file.Close()
+	entries, err := ParseLDIF(ctx, file)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse ldif file %s failed", path)
+	}
+	return entries, nil
+}
+
+// ParseLDIF parses content records of RFC 2849 LDIF. Values can be base64 encoded
+// with "::" and continued on the next line starting with a space. Change records
+// are not supported.
+func ParseLDIF(ctx context.Context, reader io.Reader) ([]Entry, error) {
+	lines, err := unfoldLDIF(ctx, reader)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	var current *Entry
+	for _, line := range lines {
+		if len(line) == 0 {
+			if current != nil {
+				entries = append(entries, *current)
+				current = nil
+			}
+			continue
+		}
+		name, value, err := parseLDIFLine(ctx, line)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case current == nil && strings.EqualFold(name, "version"):
+		case current == nil && strings.EqualFold(name, "dn"):
+			current = &Entry{DN: value, Attributes: map[string][]string{}}
+		case current == nil:
+			return nil, errors.Errorf(ctx, "expected dn but got %s", name)
+		case strings.EqualFold(name, "changetype"):
+			return nil, errors.Errorf(ctx, "change records are not supported in %s", current.DN)
+		default:
+			current.Attributes[name] = append(current.Attributes[name], value)
+		}
+	}
+	if current != nil {
+		entries = append(entries, *current)
+	}
+	return entries, nil
+}
+
+// unfoldLDIF returns the lines without comments with continuation lines joined.
+func unfoldLDIF(ctx context.Context, reader io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(reader)
+	comment := false
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.HasPrefix(line, " ") {
+			if comment {
+				continue
+			}
+			if len(lines) == 0 || len(lines[len(lines)-1]) == 0 {
+				return nil, errors.Errorf(ctx, "continuation line without attribute")
+			}
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		comment = strings.HasPrefix(line, "#")
+		if comment {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(ctx, err, "read ldif failed")
+	}
+	return lines, nil
+}
+
+func parseLDIFLine(ctx context.Context, line string) (string, string, error) {
+	name, value, found := strings.Cut(line, ":")
+	if !found {
+		return "", "", errors.Errorf(ctx, "invalid ldif line %q", line)
+	}
+	if strings.HasPrefix(value, ":") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value[1:]))
+		if err != nil {
+			return "", "", errors.Wrapf(ctx, err, "decode base64 value of %s failed", name)
+		}
+		return name, string(decoded), nil
+	}
+	if strings.HasPrefix(value, "<") {
+		return "", "", errors.Errorf(ctx, "url value of %s is not supported", name)
+	}
+	return name, strings.TrimLeft(value, " "), nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ldaptest_test
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/ldaptest"
+)
+
+var _ = Describe("ParseLDIF", func() {
+	var ctx context.Context
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+	It("parses entries", func() {
+		entries, err := ldaptest.ParseLDIF(ctx, strings.NewReader(`version: 1
+
+# comment
+dn: uid=alice,ou=users,dc=example,dc=com
+uid: alice
+mail: alice@exa
+ mple.com
+objectClass: top
+objectClass: inetOrgPerson
+
+dn: uid=bob,ou=users,dc=example,dc=com
+userPassword:: Ym9iLXNlY3JldA==
+`))
+		Expect(err).To(BeNil())
+		Expect(entries).To(Equal([]ldaptest.Entry{
+			{
+				DN: "uid=alice,ou=users,dc=example,dc=com",
+				Attributes: map[string][]string{
+					"uid":         {"alice"},
+					"mail":        {"alice@example.com"},
+					"objectClass": {"top", "inetOrgPerson"},
+				},
+			},
+			{
+				DN:         "uid=bob,ou=users,dc=example,dc=com",
+				Attributes: map[string][]string{"userPassword": {"bob-secret"}},
+			},
+		}))
+	})
+	It("returns error for change records", func() {
+		_, err := ldaptest.ParseLDIF(ctx, strings.NewReader(`dn: uid=alice,dc=example,dc=com
+changetype: delete
+`))
+		Expect(err).NotTo(BeNil())
+	})
+	It("returns error for attributes without dn", func() {
+		_, err := ldaptest.ParseLDIF(ctx, strings.NewReader("uid: alice\n"))
+		Expect(err).NotTo(BeNil())
+	})
+	It("reads the sample", func() {
+		entries, err := ldaptest.ReadLDIFFile(ctx, "../sample/ldap.ldif")
+		Expect(err).To(BeNil())
+		Expect(entries).To(HaveLen(9))
+		Expect(entries[6].Values("userPassword")).To(Equal([]string{"carol-secret"}))
+		Expect(entries[6].Values("mail")).To(Equal([]string{"carol@example.com"}))
+	})
+})
@@ -0,0 +1,429 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ldaptest provides an in-process ldap server for tests.
+// It supports simple bind, search, StartTLS and ldaps.
+package ldaptest
+
+import (
+	"crypto/tls"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	ber "gopkg.in/asn1-ber.v1"
+)
+
+const (
+	applicationBindRequest      = 0
+	applicationBindResponse     = 1
+	applicationUnbindRequest    = 2
+	applicationSearchRequest    = 3
+	applicationSearchEntry      = 4
+	applicationSearchDone       = 5
+	applicationExtendedRequest  = 23
+	applicationExtendedResponse = 24
+
+	resultSuccess            = 0
+	resultOperationsError    = 1
+	resultProtocolError      = 2
+	resultNoSuchObject       = 32
+	resultInvalidCredentials = 49
+	resultUnwillingToPerform = 53
+
+	scopeBaseObject   = 0
+	scopeSingleLevel  = 1
+	scopeWholeSubtree = 2
+
+	startTLSOID = "1.3.6.1.4.1.1466.20037"
+)
+
+// Entry is a directory entry. Entries with userPassword can bind.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Values returns the values of the attribute, the name is case insensitive.
+func (e Entry) Values(name string) []string {
+	for key, values := range e.Attributes {
+		if strings.EqualFold(key, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+// Server is a ldap server listening on a local port.
+type Server struct {
+	// Listener is the plain tcp listener. Use StartTLS to serve ldaps.
+	Listener net.Listener
+	// TLSConfig is used for ldaps and enables the StartTLS extended operation.
+	TLSConfig *tls.Config
+
+	mux     sync.Mutex
+	entries []Entry
+	conns   map[net.Conn]bool
+	useSSL  bool
+	wg      sync.WaitGroup
+}
+
+// NewServer starts a plain server with the given entries.
+func NewServer(entries []Entry) *Server {
+	s := NewUnstartedServer(entries)
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a server that is listening but not accepting connections,
+// so TLSConfig can be set before Start or StartTLS.
+func NewUnstartedServer(entries []Entry) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("ldaptest: listen failed: " + err.Error())
+	}
+	return &Server{
+		Listener: listener,
+		entries:  entries,
+		conns:    map[net.Conn]bool{},
+	}
+}
+
+// Start serves plain ldap. StartTLS is supported if TLSConfig is set.
+func (s *Server) Start() {
+	s.wg.Add(1)
+	go s.serve()
+}
+
+// StartTLS serves ldaps with TLSConfig.
+func (s *Server) StartTLS() {
+	if s.TLSConfig == nil {
+		panic("ldaptest: StartTLS without TLSConfig")
+	}
+	s.useSSL = true
+	s.Listener = tls.NewListener(s.Listener, s.TLSConfig)
+	s.Start()
+}
+
+// Host returns the ip the server listens on.
+func (s *Server) Host() string {
+	return s.Listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the server listens on.
+func (s *Server) Port() int {
+	return s.Listener.Addr().(*net.TCPAddr).Port
+}
+
+// URL returns the ldap:// or ldaps:// url of the server.
+func (s *Server) URL() string {
+	scheme := "ldap"
+	if s.useSSL {
+		scheme = "ldaps"
+	}
+	return scheme + "://" + net.JoinHostPort(s.Host(), strconv.Itoa(s.Port()))
+}
+
+// AddEntry adds or replaces an entry.
+func (s *Server) AddEntry(entry Entry) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for i, e := range s.entries {
+		if strings.EqualFold(e.DN, entry.DN) {
+			s.entries[i] = entry
+			return
+		}
+	}
+	s.entries = append(s.entries, entry)
+}
+
+// Close stops the listener and closes all connections.
+func (s *Server) Close() {
+	s.Listener.Close()
+	s.mux.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mux.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.Listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mux.Lock()
+		s.conns[conn] = true
+		s.mux.Unlock()
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mux.Lock()
+		delete(s.conns, conn)
+		s.mux.Unlock()
+		conn.Close()
+	}()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		if len(packet.Children) < 2 {
+			return
+		}
+		messageID, ok := packet.Children[0].Value.(int64)
+		if !ok {
+			return
+		}
+		request := packet.Children[1]
+		switch request.Tag {
+		case applicationBindRequest:
+			s.writeResult(conn, messageID, applicationBindResponse, s.bind(request))
+		case applicationUnbindRequest:
+			return
+		case applicationSearchRequest:
+			s.search(conn, messageID, request)
+		case applicationExtendedRequest:
+			next, ok := s.extended(conn, messageID, request)
+			if !ok {
+				return
+			}
+			if next != nil {
+				s.mux.Lock()
+				delete(s.conns, conn)
+				s.conns[next] = true
+				s.mux.Unlock()
+				conn = next
+			}
+		default:
+			glog.V(2).Infof("ldaptest: unsupported request %d", request.Tag)
+			s.writeResult(conn, messageID, request.Tag+1, resultUnwillingToPerform)
+		}
+	}
+}
+
+func (s *Server) bind(request *ber.Packet) int {
+	if len(request.Children) < 3 {
+		return resultProtocolError
+	}
+	name := stringValue(request.Children[1])
+	password := stringValue(request.Children[2])
+	if len(name) == 0 && len(password) == 0 {
+		return resultSuccess
+	}
+	entry, ok := s.entry(name)
+	if !ok {
+		return resultInvalidCredentials
+	}
+	for _, userPassword := range entry.Values("userPassword") {
+		if userPassword == password {
+			return resultSuccess
+		}
+	}
+	return resultInvalidCredentials
+}
+
+// extended handles StartTLS and returns the tls connection.
+func (s *Server) extended(conn net.Conn, messageID int64, request *ber.Packet) (net.Conn, bool) {
+	if len(request.Children) < 1 || stringValue(request.Children[0]) != startTLSOID {
+		s.writeResult(conn, messageID, applicationExtendedResponse, resultProtocolError)
+		return nil, true
+	}
+	if s.TLSConfig == nil || s.useSSL {
+		s.writeResult(conn, messageID, applicationExtendedResponse, resultOperationsError)
+		return nil, true
+	}
+	if !s.writeResult(conn, messageID, applicationExtendedResponse, resultSuccess) {
+		return nil, false
+	}
+	tlsConn := tls.Server(conn, s.TLSConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		glog.V(2).Infof("ldaptest: tls handshake failed: %v", err)
+		return nil, false
+	}
+	return tlsConn, true
+}
+
+func (s *Server) search(conn net.Conn, messageID int64, request *ber.Packet) {
+	if len(request.Children) < 8 {
+		s.writeResult(conn, messageID, applicationSearchDone, resultProtocolError)
+		return
+	}
+	baseDN := stringValue(request.Children[0])
+	scope, _ := request.Children[1].Value.(int64)
+	filter := request.Children[6]
+	var attributes []string
+	for _, attribute := range request.Children[7].Children {
+		attributes = append(attributes, stringValue(attribute))
+	}
+
+	var candidates []Entry
+	if len(baseDN) == 0 && scope == scopeBaseObject {
+		candidates = []Entry{s.rootDSE()}
+	} else {
+		if _, ok := s.entry(baseDN); !ok && !s.hasChildren(baseDN) {
+			s.writeResult(conn, messageID, applicationSearchDone, resultNoSuchObject)
+			return
+		}
+		for _, entry := range s.snapshot() {
+			if inScope(entry.DN, baseDN, scope) {
+				candidates = append(candidates, entry)
+			}
+		}
+	}
+	for _, entry := range candidates {
+		if !s.matches(entry, filter) {
+			continue
+		}
+		if !s.write(conn, messageID, encodeEntry(entry, attributes)) {
+			return
+		}
+	}
+	s.writeResult(conn, messageID, applicationSearchDone, resultSuccess)
+}
+
+func (s *Server) rootDSE() Entry {
+	entry := Entry{
+		Attributes: map[string][]string{
+			"objectClass":          {"top"},
+			"supportedLDAPVersion": {"3"},
+		},
+	}
+	if s.TLSConfig != nil && !s.useSSL {
+		entry.Attributes["supportedExtension"] = []string{startTLSOID}
+	}
+	return entry
+}
+
+func (s *Server) snapshot() []Entry {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return append([]Entry{}, s.entries...)
+}
+
+func (s *Server) entry(dn string) (Entry, bool) {
+	for _, entry := range s.snapshot() {
+		if equalDN(entry.DN, dn) {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+func (s *Server) hasChildren(dn string) bool {
+	for _, entry := range s.snapshot() {
+		if inScope(entry.DN, dn, scopeWholeSubtree) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) writeResult(conn net.Conn, messageID int64, tag ber.Tag, resultCode int) bool {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	response.AppendChild(
+		ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, resultCode, "Code"),
+	)
+	response.AppendChild(
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "DN"),
+	)
+	response.AppendChild(
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Message"),
+	)
+	return s.write(conn, messageID, response)
+}
+
+func (s *Server) write(conn net.Conn, messageID int64, response *ber.Packet) bool {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP")
+	packet.AppendChild(
+		ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "ID"),
+	)
+	packet.AppendChild(response)
+	if _, err := conn.Write(packet.Bytes()); err != nil {
+		glog.V(2).Infof("ldaptest: write failed: %v", err)
+		return false
+	}
+	return true
+}
+
+func encodeEntry(entry Entry, attributes []string) *ber.Packet {
+	packet := ber.Encode(
+		ber.ClassApplication,
+		ber.TypeConstructed,
+		applicationSearchEntry,
+		nil,
+		"Entry",
+	)
+	packet.AppendChild(
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "DN"),
+	)
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attrs")
+	for name, values := range entry.Attributes {
+		if !selected(name, attributes) {
+			continue
+		}
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attribute.AppendChild(
+			ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"),
+		)
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			set.AppendChild(
+				ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""),
+			)
+		}
+		attribute.AppendChild(set)
+		list.AppendChild(attribute)
+	}
+	packet.AppendChild(list)
+	return packet
+}
+
+// selected returns true if the attribute was requested. No attributes or * selects all.
+func selected(name string, attributes []string) bool {
+	if len(attributes) == 0 {
+		return true
+	}
+	for _, attribute := range attributes {
+		if attribute == "*" || strings.EqualFold(attribute, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func inScope(dn string, baseDN string, scope int64) bool {
+	if len(baseDN) == 0 {
+		return scope != scopeBaseObject
+	}
+	switch scope {
+	case scopeBaseObject:
+		return equalDN(dn, baseDN)
+	case scopeSingleLevel:
+		parts := strings.SplitN(dn, ",", 2)
+		return len(parts) == 2 && equalDN(parts[1], baseDN)
+	default:
+		return equalDN(dn, baseDN) || hasSuffixDN(dn, baseDN)
+	}
+}
+
+func stringValue(packet *ber.Packet) string {
+	if value, ok := packet.Value.(string); ok {
+		return value
+	}
+	if packet.Data != nil {
+		return packet.Data.String()
+	}
+	return ""
+}
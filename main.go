@@ -6,10 +6,13 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"runtime"
 	"strings"
@@ -21,7 +24,10 @@ import (
 	"github.com/facebookgo/grace/gracehttp"
 	"github.com/golang/glog"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.jona.me/crowd"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 
 	"github.com/bborbe/auth-http-proxy/pkg"
 )
@@ -33,10 +39,13 @@ var (
 	targetHealthzUrlPtr = flag.String("target-healthz-url", "", "target healthz address")
 	verifierPtr         = flag.String("verifier", "", "verifier (file,ldap,crowd,auth)")
 	secretPtr           = flag.String("secret", "", "aes secret key (length: 32")
-	kindPtr             = flag.String("kind", "", "(basic,html)")
+	kindPtr             = flag.String("kind", "", "(basic,digest,html)")
 	configPtr           = flag.String("config", "", "config")
 	requiredGroupsPtr   = flag.String("required-groups", "", "required groups reperated by comma")
 	cacheTTLPtr         = flag.Duration("cache-ttl", 5*time.Minute, "cache ttl")
+	cacheNegativeTTLPtr = flag.Duration("cache-negative-ttl", 10*time.Second, "cache ttl of failures")
+	cacheMaxEntriesPtr  = flag.Int("cache-max-entries", 10000, "max cached credentials")
+	cacheStaleTTLPtr    = flag.Duration("cache-stale-ttl", 0, "accept cached on backend error")
 
 	// file params
 	fileUseresPtr = flag.String("file-users", "", "users")
@@ -44,6 +53,7 @@ var (
 	// ldap params
 	ldapBaseDnPtr       = flag.String("ldap-base-dn", "", "ldap-base-dn")
 	ldapHostPtr         = flag.String("ldap-host", "", "ldap-host")
+	ldapURLsPtr         = flag.String("ldap-urls", "", "ldap:// or ldaps:// urls separated by comma")
 	ldapServerNamePtr   = flag.String("ldap-servername", "", "ldap-servername")
 	ldapPortPtr         = flag.Int("ldap-port", 0, "ldap-port")
 	ldapUseSSLPtr       = flag.Bool("ldap-use-ssl", false, "ldap-use-ssl")
@@ -57,10 +67,120 @@ var (
 	ldapUserFieldPtr    = flag.String("ldap-user-field", "", "ldap-user-field")
 	ldapGroupFieldPtr   = flag.String("ldap-group-field", "", "ldap-group-field")
 
+	// ldap tls
+	ldapTlsModePtr     = flag.String("ldap-tls-mode", "", "starttls, ldaps or plain")
+	ldapTlsCaFilePtr   = flag.String("ldap-tls-ca-file", "", "ca of ldap servers")
+	ldapTlsCertFilePtr = flag.String("ldap-tls-cert-file", "", "ldap client certificate")
+	ldapTlsKeyFilePtr  = flag.String("ldap-tls-key-file", "", "ldap client certificate key")
+	ldapTlsMinPtr      = flag.String("ldap-tls-min-version", "1.2", "min tls version")
+	ldapTlsInsecurePtr = flag.Bool("ldap-tls-insecure-skip-verify", false, "skip verify")
+
+	// ldap nested groups
+	ldapGroupNestingPtr = flag.String("ldap-group-nesting", "none", "none, recursive or in-chain")
+	ldapGroupMemberPtr  = flag.String("ldap-group-member-attribute", "member", "member dn attr")
+	ldapGroupDepthPtr   = flag.Int("ldap-group-max-depth", 10, "max depth of recursive groups")
+
+	// ldap connection pool
+	ldapMaxConnectionsPtr = flag.Int("ldap-max-connections", 10, "max connections per server")
+	ldapTimeoutPtr        = flag.Duration("ldap-timeout", 10*time.Second, "ldap request timeout")
+	ldapIdleTimeoutPtr    = flag.Duration("ldap-idle-timeout", 5*time.Minute, "close idle after")
+	ldapHealthIntervalPtr = flag.Duration("ldap-health-interval", 30*time.Second, "0 disables")
+
+	// ldap user attributes
+	ldapAttributeHeadersPtr = flag.String("ldap-attribute-headers", "", "attribute=header,...")
+	ldapRequiredAttrsPtr    = flag.String("ldap-required-attributes", "", "employeeType=staff,...")
+
 	// crowd
 	crowdURLPtr     = flag.String("crowd-url", "", "crowd url")
 	crowdAppNamePtr = flag.String("crowd-app-name", "", "crowd app name")
 	crowdAppPassPtr = flag.String("crowd-app-password", "", "crowd app password")
+
+	// jwt
+	jwtPublicKeyFilesPtr = flag.String("jwt-public-key-files", "", "jwt public keys (pem) by comma")
+	jwtJwksURLPtr        = flag.String("jwt-jwks-url", "", "jwt jwks url")
+	jwtJwksRefreshPtr    = flag.Duration("jwt-jwks-refresh-interval", time.Hour, "jwks refresh")
+	jwtIssuerPtr         = flag.String("jwt-issuer", "", "jwt required issuer")
+	jwtAudiencePtr       = flag.String("jwt-audience", "", "jwt required audience")
+	jwtUsernameClaimPtr  = flag.String("jwt-username-claim", "sub", "jwt username claim")
+	jwtGroupsClaimPtr    = flag.String("jwt-groups-claim", "groups", "jwt groups claim")
+	jwtGroupMappingPtr   = flag.String("jwt-group-mapping", "", "jwt group mapping (value=group,...)")
+	jwtLeewayPtr         = flag.Duration("jwt-leeway", 0, "jwt leeway for time based claims")
+
+	// api keys
+	apiKeyFilePtr   = flag.String("api-key-file", "", "json file with hashed api keys")
+	apiKeyHeaderPtr = flag.String("api-key-header", "X-Api-Key", "header containing the api key")
+
+	// tls
+	tlsCertFilePtr       = flag.String("tls-cert-file", "", "tls server certificate (pem)")
+	tlsKeyFilePtr        = flag.String("tls-key-file", "", "tls server key (pem)")
+	tlsClientCaFilePtr   = flag.String("tls-client-ca-file", "", "ca to verify client certificates")
+	tlsClientCertModePtr = flag.String("tls-client-cert-mode", "alternative", "alternative,additional")
+	tlsClientUsernamePtr = flag.String(
+		"tls-client-username-template",
+		"{{.Subject.CommonName}}",
+		"client certificate username template",
+	)
+	tlsClientGroupFilePtr = flag.String("tls-client-group-file", "", "groups per username (json)")
+
+	// totp
+	totpSecretFilePtr    = flag.String("totp-secret-file", "", "totp secrets (username:secret)")
+	totpLdapAttributePtr = flag.String("totp-ldap-attribute", "", "ldap attribute with totp secret")
+	totpSkewPtr          = flag.Int("totp-skew", 1, "totp time steps accepted before and after now")
+
+	// digest
+	digestFilePtr     = flag.String("digest-file", "", "htdigest file (username:realm:ha1)")
+	digestNonceTTLPtr = flag.Duration("digest-nonce-ttl", 5*time.Minute, "digest nonce lifetime")
+
+	// authorization
+	authorizationFilePtr = flag.String("authorization-file", "", "json file with authorization rules")
+	celPolicyPtr         = flag.String("cel-policy", "", "cel expression that must return true")
+	ipAllowPtr           = flag.String("ip-allow", "", "allowed client cidrs separated by comma")
+	ipDenyPtr            = flag.String("ip-deny", "", "denied client cidrs separated by comma")
+	trustedNetworksPtr   = flag.String("trusted-networks", "", "client cidrs skipping auth")
+	trustedNetworkUserPt = flag.String("trusted-network-user", "", "user of trusted networks")
+	trustedProxiesPtr    = flag.String("trusted-proxies", "", "proxy cidrs setting X-Forwarded-For")
+	allowUnauthPtr       = flag.String(
+		"allow-unauthenticated",
+		"",
+		"requests without auth separated by semicolon (METHOD /path/* [CIDR,...])",
+	)
+
+	// brute force protection
+	loginMaxFailuresPtr      = flag.Int("login-max-failures", 5, "failed logins per user (0=off)")
+	loginMaxFailuresPerIPPtr = flag.Int("login-max-failures-per-ip", 20, "failed logins per ip")
+	loginLockoutPtr          = flag.Duration("login-lockout", time.Minute, "first lockout")
+	loginLockoutMaxPtr       = flag.Duration("login-lockout-max", time.Hour, "max lockout")
+	loginMaxEntriesPtr       = flag.Int("login-max-entries", 10000, "max tracked users and ips each")
+
+	// rate limit
+	rateLimitFilePtr = flag.String("rate-limit-file", "", "json file with rate limit rules")
+
+	// admin api
+	adminPortPtr     = flag.Int("admin-port", 0, "port of the admin api (0=off)")
+	adminApiTokenPtr = flag.String("admin-api-token", "", "bearer token of the admin api")
+	adminGroupPtr    = flag.String("admin-group", "", "group allowed to use the admin api")
+
+	// metrics
+	metricsPortPtr = flag.Int("metrics-port", 0, "port of /metrics (0=off)")
+
+	// access log
+	accessLogPtr           = flag.String("access-log", "", "stdout or access log file (empty=off)")
+	accessLogFormatPtr     = flag.String("access-log-format", "json", "json or combined")
+	accessLogMaxSizePtr    = flag.Int("access-log-max-size", 100, "rotate after megabytes")
+	accessLogMaxBackupsPtr = flag.Int("access-log-max-backups", 7, "rotated files kept")
+
+	// audit log
+	auditLogPtr           = flag.String("audit-log", "", "stdout or audit log file (empty=off)")
+	auditLogMaxSizePtr    = flag.Int("audit-log-max-size", 100, "rotate after megabytes")
+	auditLogMaxBackupsPtr = flag.Int("audit-log-max-backups", 30, "rotated files kept")
+	auditSyslogPtr        = flag.String("audit-syslog", "", "local, udp://host:514 or unix:///dev/log")
+
+	// tracing
+	otlpEndpointPtr     = flag.String("otlp-endpoint", "", "otlp http url like http://localhost:4318")
+	traceSampleRatioPtr = flag.Float64("trace-sample-ratio", 1, "fraction of new traces recorded")
+
+	// request id
+	requestIDHeaderPtr = flag.String("request-id-header", "", "trusted request id header")
 )
 
 func main() {
@@ -87,43 +207,117 @@ func main() {
 }
 
 type application struct {
-	Port             Port                 `json:"port"`
-	CacheTTL         pkg.CacheTTL         `json:"cache-ttl"`
-	TargetAddress    TargetAddress        `json:"target-address"`
-	TargetHealthzUrl TargetHealthzUrl     `json:"target-healthz-url"`
-	BasicAuthRealm   BasicAuthRealm       `json:"basic-auth-realm"`
-	Secret           Secret               `json:"secret"`
-	RequiredGroups   []pkg.GroupName      `json:"required-groups"`
-	VerifierType     VerifierType         `json:"verifier"`
-	UserFile         pkg.UserFile         `json:"file-users"`
-	Kind             Kind                 `json:"kind"`
-	LdapHost         pkg.LdapHost         `json:"ldap-host"`
-	LdapServerName   pkg.LdapServerName   `json:"ldap-servername"`
-	LdapPort         pkg.LdapPort         `json:"ldap-port"`
-	LdapUseSSL       pkg.LdapUseSSL       `json:"ldap-use-ssl"`
-	LdapSkipTls      pkg.LdapSkipTls      `json:"ldap-skip-tls"`
-	LdapBindDN       pkg.LdapBindDN       `json:"ldap-bind-dn"`
-	LdapBindPassword pkg.LdapBindPassword `json:"ldap-bind-password"`
-	LdapBaseDn       pkg.LdapBaseDn       `json:"ldap-base-dn"`
-	LdapUserDn       pkg.LdapUserDn       `json:"ldap-user-dn"`
-	LdapGroupDn      pkg.LdapGroupDn      `json:"ldap-group-dn"`
-	LdapUserFilter   pkg.LdapUserFilter   `json:"ldap-user-filter"`
-	LdapGroupFilter  pkg.LdapGroupFilter  `json:"ldap-group-filter"`
-	LdapUserField    pkg.LdapUserField    `json:"ldap-user-field"`
-	LdapGroupField   pkg.LdapGroupField   `json:"ldap-group-field"`
-	CrowdURL         CrowdURL             `json:"crowd-url"`
-	CrowdAppName     CrowdAppName         `json:"crowd-app-name"`
-	CrowdAppPassword CrowdAppPassword     `json:"crowd-app-password"`
+	Port                   Port                           `json:"port"`
+	CacheTTL               pkg.CacheTTL                   `json:"cache-ttl"`
+	CacheNegativeTTL       pkg.CacheNegativeTTL           `json:"cache-negative-ttl"`
+	CacheMaxEntries        pkg.CacheMaxEntries            `json:"cache-max-entries"`
+	CacheStaleTTL          pkg.CacheStaleTTL              `json:"cache-stale-ttl"`
+	TargetAddress          TargetAddress                  `json:"target-address"`
+	TargetHealthzUrl       TargetHealthzUrl               `json:"target-healthz-url"`
+	BasicAuthRealm         BasicAuthRealm                 `json:"basic-auth-realm"`
+	Secret                 Secret                         `json:"secret"`
+	RequiredGroups         []pkg.GroupName                `json:"required-groups"`
+	VerifierType           VerifierType                   `json:"verifier"`
+	UserFile               pkg.UserFile                   `json:"file-users"`
+	Kind                   Kind                           `json:"kind"`
+	LdapURLs               []pkg.LdapURL                  `json:"ldap-urls"`
+	LdapHost               pkg.LdapHost                   `json:"ldap-host"`
+	LdapServerName         pkg.LdapServerName             `json:"ldap-servername"`
+	LdapPort               pkg.LdapPort                   `json:"ldap-port"`
+	LdapUseSSL             pkg.LdapUseSSL                 `json:"ldap-use-ssl"`
+	LdapSkipTls            pkg.LdapSkipTls                `json:"ldap-skip-tls"`
+	LdapBindDN             pkg.LdapBindDN                 `json:"ldap-bind-dn"`
+	LdapBindPassword       pkg.LdapBindPassword           `json:"ldap-bind-password"`
+	LdapBaseDn             pkg.LdapBaseDn                 `json:"ldap-base-dn"`
+	LdapUserDn             pkg.LdapUserDn                 `json:"ldap-user-dn"`
+	LdapGroupDn            pkg.LdapGroupDn                `json:"ldap-group-dn"`
+	LdapUserFilter         pkg.LdapUserFilter             `json:"ldap-user-filter"`
+	LdapGroupFilter        pkg.LdapGroupFilter            `json:"ldap-group-filter"`
+	LdapUserField          pkg.LdapUserField              `json:"ldap-user-field"`
+	LdapGroupField         pkg.LdapGroupField             `json:"ldap-group-field"`
+	LdapTlsMode            pkg.LdapTlsMode                `json:"ldap-tls-mode"`
+	LdapTlsCaFile          pkg.LdapTlsCaFile              `json:"ldap-tls-ca-file"`
+	LdapTlsCertFile        pkg.LdapTlsCertFile            `json:"ldap-tls-cert-file"`
+	LdapTlsKeyFile         pkg.LdapTlsKeyFile             `json:"ldap-tls-key-file"`
+	LdapTlsMinVersion      pkg.LdapTlsMinVersion          `json:"ldap-tls-min-version"`
+	LdapTlsInsecure        pkg.LdapTlsInsecureSkipVerify  `json:"ldap-tls-insecure-skip-verify"`
+	LdapGroupNesting       pkg.LdapGroupNesting           `json:"ldap-group-nesting"`
+	LdapGroupMember        pkg.LdapGroupMemberAttribute   `json:"ldap-group-member-attribute"`
+	LdapGroupMaxDepth      pkg.LdapGroupMaxDepth          `json:"ldap-group-max-depth"`
+	LdapMaxConnections     pkg.LdapMaxConnections         `json:"ldap-max-connections"`
+	LdapTimeout            pkg.LdapTimeout                `json:"ldap-timeout"`
+	LdapIdleTimeout        pkg.LdapIdleTimeout            `json:"ldap-idle-timeout"`
+	LdapHealthInterval     pkg.LdapHealthInterval         `json:"ldap-health-interval"`
+	LdapAttributeHeaders   pkg.LdapAttributeHeaders       `json:"ldap-attribute-headers"`
+	LdapRequiredAttributes []string                       `json:"ldap-required-attributes"`
+	CrowdURL               CrowdURL                       `json:"crowd-url"`
+	CrowdAppName           CrowdAppName                   `json:"crowd-app-name"`
+	CrowdAppPassword       CrowdAppPassword               `json:"crowd-app-password"`
+	JwtPublicKeyFiles      []pkg.JwtPublicKeyFile         `json:"jwt-public-key-files"`
+	JwtJwksURL             pkg.JwtJwksURL                 `json:"jwt-jwks-url"`
+	JwtJwksRefreshInterval pkg.JwtJwksRefreshInterval     `json:"jwt-jwks-refresh-interval"`
+	JwtIssuer              pkg.JwtIssuer                  `json:"jwt-issuer"`
+	JwtAudience            pkg.JwtAudience                `json:"jwt-audience"`
+	JwtUsernameClaim       pkg.JwtUsernameClaim           `json:"jwt-username-claim"`
+	JwtGroupsClaim         pkg.JwtGroupsClaim             `json:"jwt-groups-claim"`
+	JwtGroupMapping        pkg.JwtGroupMapping            `json:"jwt-group-mapping"`
+	JwtLeeway              pkg.JwtLeeway                  `json:"jwt-leeway"`
+	ApiKeyFile             pkg.ApiKeyFile                 `json:"api-key-file"`
+	ApiKeyHeader           pkg.ApiKeyHeader               `json:"api-key-header"`
+	TlsCertFile            pkg.TlsCertFile                `json:"tls-cert-file"`
+	TlsKeyFile             pkg.TlsKeyFile                 `json:"tls-key-file"`
+	TlsClientCaFile        pkg.TlsClientCaFile            `json:"tls-client-ca-file"`
+	TlsClientCertMode      pkg.ClientCertMode             `json:"tls-client-cert-mode"`
+	TlsClientUsername      pkg.ClientCertUsernameTemplate `json:"tls-client-username-template"`
+	TlsClientGroupFile     pkg.ClientCertGroupFile        `json:"tls-client-group-file"`
+	TotpSecretFile         pkg.TotpSecretFile             `json:"totp-secret-file"`
+	TotpLdapAttribute      pkg.TotpLdapAttribute          `json:"totp-ldap-attribute"`
+	TotpSkew               pkg.TotpSkew                   `json:"totp-skew"`
+	DigestFile             pkg.DigestFile                 `json:"digest-file"`
+	DigestNonceTTL         pkg.DigestNonceTTL             `json:"digest-nonce-ttl"`
+	AuthorizationFile      pkg.AuthorizationFile          `json:"authorization-file"`
+	AllowUnauthenticated   []string                       `json:"allow-unauthenticated"`
+	CelPolicy              pkg.CelPolicy                  `json:"cel-policy"`
+	IpAllow                []string                       `json:"ip-allow"`
+	IpDeny                 []string                       `json:"ip-deny"`
+	TrustedNetworks        []string                       `json:"trusted-networks"`
+	TrustedNetworkUser     pkg.TrustedNetworkUser         `json:"trusted-network-user"`
+	TrustedProxies         []string                       `json:"trusted-proxies"`
+	LoginMaxFailures       pkg.LoginMaxFailures           `json:"login-max-failures"`
+	LoginMaxFailuresPerIP  pkg.LoginMaxFailures           `json:"login-max-failures-per-ip"`
+	LoginLockout           pkg.LoginLockout               `json:"login-lockout"`
+	LoginLockoutMax        pkg.LoginLockoutMax            `json:"login-lockout-max"`
+	LoginMaxEntries        pkg.LoginMaxEntries            `json:"login-max-entries"`
+	RateLimitFile          pkg.RateLimitFile              `json:"rate-limit-file"`
+	AdminPort              Port                           `json:"admin-port"`
+	AdminApiToken          pkg.AdminToken                 `json:"admin-api-token"`
+	AdminGroup             pkg.GroupName                  `json:"admin-group"`
+	MetricsPort            Port                           `json:"metrics-port"`
+	AccessLog              pkg.LogFile                    `json:"access-log"`
+	AccessLogFormat        pkg.AccessLogFormat            `json:"access-log-format"`
+	AccessLogMaxSize       pkg.LogMaxSize                 `json:"access-log-max-size"`
+	AccessLogMaxBackups    pkg.LogMaxBackups              `json:"access-log-max-backups"`
+	AuditLog               pkg.LogFile                    `json:"audit-log"`
+	AuditLogMaxSize        pkg.LogMaxSize                 `json:"audit-log-max-size"`
+	AuditLogMaxBackups     pkg.LogMaxBackups              `json:"audit-log-max-backups"`
+	AuditSyslog            pkg.AuditSyslog                `json:"audit-syslog"`
+	OtlpEndpoint           pkg.OtlpEndpoint               `json:"otlp-endpoint"`
+	TraceSampleRatio       pkg.TraceSampleRatio           `json:"trace-sample-ratio"`
+	RequestIDHeader        pkg.TrustedRequestIDHeader     `json:"request-id-header"`
 }
 
 func (a *application) parseConfig(ctx context.Context) error {
+	// configured contains the keys of the config, so zero values can be set explicitly
+	configured := map[string]json.RawMessage{}
 	if len(*configPtr) > 0 {
-		file, err := os.Open(*configPtr)
+		content, err := os.ReadFile(*configPtr)
 		if err != nil {
 			return errors.Wrapf(ctx, err, "read config %v failed", *configPtr)
 		}
-		err = json.NewDecoder(file).Decode(a)
-		if err != nil {
+		if err := json.Unmarshal(content, a); err != nil {
+			return errors.Wrap(ctx, err, "parse config json failed")
+		}
+		if err := json.Unmarshal(content, &configured); err != nil {
 			return errors.Wrap(ctx, err, "parse config json failed")
 		}
 	}
@@ -154,6 +348,15 @@ func (a *application) parseConfig(ctx context.Context) error {
 	if a.CacheTTL.IsEmpty() {
 		a.CacheTTL = pkg.CacheTTL(*cacheTTLPtr)
 	}
+	if _, ok := configured["cache-negative-ttl"]; !ok {
+		a.CacheNegativeTTL = pkg.CacheNegativeTTL(*cacheNegativeTTLPtr)
+	}
+	if a.CacheMaxEntries == 0 {
+		a.CacheMaxEntries = pkg.CacheMaxEntries(*cacheMaxEntriesPtr)
+	}
+	if a.CacheStaleTTL == 0 {
+		a.CacheStaleTTL = pkg.CacheStaleTTL(*cacheStaleTTLPtr)
+	}
 	if len(a.RequiredGroups) == 0 {
 		for _, groupName := range strings.Split(*requiredGroupsPtr, ",") {
 			if len(groupName) > 0 {
@@ -165,6 +368,11 @@ func (a *application) parseConfig(ctx context.Context) error {
 	if len(a.LdapBaseDn) == 0 {
 		a.LdapBaseDn = pkg.LdapBaseDn(*ldapBaseDnPtr)
 	}
+	if len(a.LdapURLs) == 0 {
+		for _, ldapURL := range splitList(*ldapURLsPtr) {
+			a.LdapURLs = append(a.LdapURLs, pkg.LdapURL(ldapURL))
+		}
+	}
 	if len(a.LdapHost) == 0 {
 		a.LdapHost = pkg.LdapHost(*ldapHostPtr)
 	}
@@ -204,6 +412,58 @@ func (a *application) parseConfig(ctx context.Context) error {
 	if len(a.LdapGroupDn) == 0 {
 		a.LdapGroupDn = pkg.LdapGroupDn(*ldapGroupDnPtr)
 	}
+	if len(a.LdapTlsMode) == 0 {
+		a.LdapTlsMode = pkg.LdapTlsMode(*ldapTlsModePtr)
+	}
+	if len(a.LdapTlsCaFile) == 0 {
+		a.LdapTlsCaFile = pkg.LdapTlsCaFile(*ldapTlsCaFilePtr)
+	}
+	if len(a.LdapTlsCertFile) == 0 {
+		a.LdapTlsCertFile = pkg.LdapTlsCertFile(*ldapTlsCertFilePtr)
+	}
+	if len(a.LdapTlsKeyFile) == 0 {
+		a.LdapTlsKeyFile = pkg.LdapTlsKeyFile(*ldapTlsKeyFilePtr)
+	}
+	if len(a.LdapTlsMinVersion) == 0 {
+		a.LdapTlsMinVersion = pkg.LdapTlsMinVersion(*ldapTlsMinPtr)
+	}
+	if !a.LdapTlsInsecure {
+		a.LdapTlsInsecure = pkg.LdapTlsInsecureSkipVerify(*ldapTlsInsecurePtr)
+	}
+	if len(a.LdapGroupNesting) == 0 {
+		a.LdapGroupNesting = pkg.LdapGroupNesting(*ldapGroupNestingPtr)
+	}
+	if len(a.LdapGroupMember) == 0 {
+		a.LdapGroupMember = pkg.LdapGroupMemberAttribute(*ldapGroupMemberPtr)
+	}
+	if a.LdapGroupMaxDepth == 0 {
+		a.LdapGroupMaxDepth = pkg.LdapGroupMaxDepth(*ldapGroupDepthPtr)
+	}
+	if a.LdapMaxConnections == 0 {
+		a.LdapMaxConnections = pkg.LdapMaxConnections(*ldapMaxConnectionsPtr)
+	}
+	if a.LdapTimeout == 0 {
+		a.LdapTimeout = pkg.LdapTimeout(*ldapTimeoutPtr)
+	}
+	if a.LdapIdleTimeout == 0 {
+		a.LdapIdleTimeout = pkg.LdapIdleTimeout(*ldapIdleTimeoutPtr)
+	}
+	if a.LdapHealthInterval == 0 {
+		a.LdapHealthInterval = pkg.LdapHealthInterval(*ldapHealthIntervalPtr)
+	}
+	if len(a.LdapAttributeHeaders) == 0 {
+		ldapAttributeHeaders, err := pkg.ParseLdapAttributeHeaders(
+			ctx,
+			splitList(*ldapAttributeHeadersPtr),
+		)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "parse ldap attribute headers failed")
+		}
+		a.LdapAttributeHeaders = ldapAttributeHeaders
+	}
+	if len(a.LdapRequiredAttributes) == 0 {
+		a.LdapRequiredAttributes = splitList(*ldapRequiredAttrsPtr)
+	}
 	if len(a.CrowdURL) == 0 {
 		a.CrowdURL = CrowdURL(*crowdURLPtr)
 	}
@@ -213,9 +473,187 @@ func (a *application) parseConfig(ctx context.Context) error {
 	if len(a.CrowdAppPassword) == 0 {
 		a.CrowdAppPassword = CrowdAppPassword(*crowdAppPassPtr)
 	}
+	if len(a.JwtPublicKeyFiles) == 0 {
+		for _, file := range splitList(*jwtPublicKeyFilesPtr) {
+			a.JwtPublicKeyFiles = append(a.JwtPublicKeyFiles, pkg.JwtPublicKeyFile(file))
+		}
+	}
+	if len(a.JwtJwksURL) == 0 {
+		a.JwtJwksURL = pkg.JwtJwksURL(*jwtJwksURLPtr)
+	}
+	if a.JwtJwksRefreshInterval <= 0 {
+		a.JwtJwksRefreshInterval = pkg.JwtJwksRefreshInterval(*jwtJwksRefreshPtr)
+	}
+	if len(a.JwtIssuer) == 0 {
+		a.JwtIssuer = pkg.JwtIssuer(*jwtIssuerPtr)
+	}
+	if len(a.JwtAudience) == 0 {
+		a.JwtAudience = pkg.JwtAudience(*jwtAudiencePtr)
+	}
+	if len(a.JwtUsernameClaim) == 0 {
+		a.JwtUsernameClaim = pkg.JwtUsernameClaim(*jwtUsernameClaimPtr)
+	}
+	if len(a.JwtGroupsClaim) == 0 {
+		a.JwtGroupsClaim = pkg.JwtGroupsClaim(*jwtGroupsClaimPtr)
+	}
+	if len(a.JwtGroupMapping) == 0 {
+		a.JwtGroupMapping = pkg.JwtGroupMapping{}
+		for _, mapping := range splitList(*jwtGroupMappingPtr) {
+			parts := strings.SplitN(mapping, "=", 2)
+			if len(parts) != 2 {
+				return errors.Errorf(ctx, "invalid jwt group mapping '%s'", mapping)
+			}
+			a.JwtGroupMapping[parts[0]] = pkg.GroupName(parts[1])
+		}
+	}
+	if a.JwtLeeway <= 0 {
+		a.JwtLeeway = pkg.JwtLeeway(*jwtLeewayPtr)
+	}
+	if len(a.ApiKeyFile) == 0 {
+		a.ApiKeyFile = pkg.ApiKeyFile(*apiKeyFilePtr)
+	}
+	if len(a.ApiKeyHeader) == 0 {
+		a.ApiKeyHeader = pkg.ApiKeyHeader(*apiKeyHeaderPtr)
+	}
+	if len(a.TlsCertFile) == 0 {
+		a.TlsCertFile = pkg.TlsCertFile(*tlsCertFilePtr)
+	}
+	if len(a.TlsKeyFile) == 0 {
+		a.TlsKeyFile = pkg.TlsKeyFile(*tlsKeyFilePtr)
+	}
+	if len(a.TlsClientCaFile) == 0 {
+		a.TlsClientCaFile = pkg.TlsClientCaFile(*tlsClientCaFilePtr)
+	}
+	if len(a.TlsClientCertMode) == 0 {
+		a.TlsClientCertMode = pkg.ClientCertMode(*tlsClientCertModePtr)
+	}
+	if len(a.TlsClientUsername) == 0 {
+		a.TlsClientUsername = pkg.ClientCertUsernameTemplate(*tlsClientUsernamePtr)
+	}
+	if len(a.TlsClientGroupFile) == 0 {
+		a.TlsClientGroupFile = pkg.ClientCertGroupFile(*tlsClientGroupFilePtr)
+	}
+	if len(a.TotpSecretFile) == 0 {
+		a.TotpSecretFile = pkg.TotpSecretFile(*totpSecretFilePtr)
+	}
+	if len(a.TotpLdapAttribute) == 0 {
+		a.TotpLdapAttribute = pkg.TotpLdapAttribute(*totpLdapAttributePtr)
+	}
+	if _, ok := configured["totp-skew"]; !ok {
+		a.TotpSkew = pkg.TotpSkew(*totpSkewPtr)
+	}
+	if len(a.DigestFile) == 0 {
+		a.DigestFile = pkg.DigestFile(*digestFilePtr)
+	}
+	if a.DigestNonceTTL == 0 {
+		a.DigestNonceTTL = pkg.DigestNonceTTL(*digestNonceTTLPtr)
+	}
+	if len(a.AuthorizationFile) == 0 {
+		a.AuthorizationFile = pkg.AuthorizationFile(*authorizationFilePtr)
+	}
+	if len(a.CelPolicy) == 0 {
+		a.CelPolicy = pkg.CelPolicy(*celPolicyPtr)
+	}
+	if len(a.IpAllow) == 0 {
+		a.IpAllow = splitList(*ipAllowPtr)
+	}
+	if len(a.IpDeny) == 0 {
+		a.IpDeny = splitList(*ipDenyPtr)
+	}
+	if len(a.TrustedNetworks) == 0 {
+		a.TrustedNetworks = splitList(*trustedNetworksPtr)
+	}
+	if len(a.TrustedNetworkUser) == 0 {
+		a.TrustedNetworkUser = pkg.TrustedNetworkUser(*trustedNetworkUserPt)
+	}
+	if len(a.TrustedProxies) == 0 {
+		a.TrustedProxies = splitList(*trustedProxiesPtr)
+	}
+	if _, ok := configured["login-max-failures"]; !ok {
+		a.LoginMaxFailures = pkg.LoginMaxFailures(*loginMaxFailuresPtr)
+	}
+	if _, ok := configured["login-max-failures-per-ip"]; !ok {
+		a.LoginMaxFailuresPerIP = pkg.LoginMaxFailures(*loginMaxFailuresPerIPPtr)
+	}
+	if a.LoginLockout == 0 {
+		a.LoginLockout = pkg.LoginLockout(*loginLockoutPtr)
+	}
+	if a.LoginLockoutMax == 0 {
+		a.LoginLockoutMax = pkg.LoginLockoutMax(*loginLockoutMaxPtr)
+	}
+	if a.LoginMaxEntries == 0 {
+		a.LoginMaxEntries = pkg.LoginMaxEntries(*loginMaxEntriesPtr)
+	}
+	if a.AdminPort == 0 {
+		a.AdminPort = Port(*adminPortPtr)
+	}
+	if len(a.AdminApiToken) == 0 {
+		a.AdminApiToken = pkg.AdminToken(*adminApiTokenPtr)
+	}
+	if len(a.AdminGroup) == 0 {
+		a.AdminGroup = pkg.GroupName(*adminGroupPtr)
+	}
+	if len(a.RateLimitFile) == 0 {
+		a.RateLimitFile = pkg.RateLimitFile(*rateLimitFilePtr)
+	}
+	if a.MetricsPort == 0 {
+		a.MetricsPort = Port(*metricsPortPtr)
+	}
+	if len(a.AccessLog) == 0 {
+		a.AccessLog = pkg.LogFile(*accessLogPtr)
+	}
+	if len(a.AccessLogFormat) == 0 {
+		a.AccessLogFormat = pkg.AccessLogFormat(*accessLogFormatPtr)
+	}
+	if a.AccessLogMaxSize == 0 {
+		a.AccessLogMaxSize = pkg.LogMaxSize(*accessLogMaxSizePtr)
+	}
+	if a.AccessLogMaxBackups == 0 {
+		a.AccessLogMaxBackups = pkg.LogMaxBackups(*accessLogMaxBackupsPtr)
+	}
+	if len(a.AuditLog) == 0 {
+		a.AuditLog = pkg.LogFile(*auditLogPtr)
+	}
+	if a.AuditLogMaxSize == 0 {
+		a.AuditLogMaxSize = pkg.LogMaxSize(*auditLogMaxSizePtr)
+	}
+	if a.AuditLogMaxBackups == 0 {
+		a.AuditLogMaxBackups = pkg.LogMaxBackups(*auditLogMaxBackupsPtr)
+	}
+	if len(a.AuditSyslog) == 0 {
+		a.AuditSyslog = pkg.AuditSyslog(*auditSyslogPtr)
+	}
+	if len(a.OtlpEndpoint) == 0 {
+		a.OtlpEndpoint = pkg.OtlpEndpoint(*otlpEndpointPtr)
+	}
+	if _, ok := configured["trace-sample-ratio"]; !ok {
+		a.TraceSampleRatio = pkg.TraceSampleRatio(*traceSampleRatioPtr)
+	}
+	if len(a.RequestIDHeader) == 0 {
+		a.RequestIDHeader = pkg.TrustedRequestIDHeader(*requestIDHeaderPtr)
+	}
+	if len(a.AllowUnauthenticated) == 0 {
+		for _, entry := range strings.Split(*allowUnauthPtr, ";") {
+			if entry = strings.TrimSpace(entry); len(entry) > 0 {
+				a.AllowUnauthenticated = append(a.AllowUnauthenticated, entry)
+			}
+		}
+	}
 	return nil
 }
 
+// splitList splits a comma separated flag value and drops empty elements.
+func splitList(value string) []string {
+	var result []string
+	for _, element := range strings.Split(value, ",") {
+		element = strings.TrimSpace(element)
+		if len(element) > 0 {
+			result = append(result, element)
+		}
+	}
+	return result
+}
+
 func (a *application) validate() error {
 	if a.Port <= 0 {
 		return fmt.Errorf("parameter Port missing")
@@ -226,7 +664,7 @@ func (a *application) validate() error {
 	if len(a.Kind) == 0 {
 		return fmt.Errorf("parameter Kind missing")
 	}
-	if a.Kind != "basic" && a.Kind != "html" {
+	if a.Kind != "basic" && a.Kind != "html" && a.Kind != "digest" {
 		return fmt.Errorf("parameter Kind invalid")
 	}
 	if len(a.VerifierType) == 0 {
@@ -237,11 +675,17 @@ func (a *application) validate() error {
 		return fmt.Errorf("parameter VerifierType invalid")
 	}
 	if a.VerifierType == "ldap" {
-		if len(a.LdapHost) == 0 {
-			return fmt.Errorf("parameter LdapHost missing")
-		}
-		if a.LdapPort == 0 {
-			return fmt.Errorf("parameter LdapPort missing")
+		if len(a.LdapURLs) > 0 {
+			if _, err := pkg.ParseLdapURLs(context.Background(), a.LdapURLs); err != nil {
+				return fmt.Errorf("parameter LdapURLs invalid: %v", err)
+			}
+		} else {
+			if len(a.LdapHost) == 0 {
+				return fmt.Errorf("parameter LdapHost missing")
+			}
+			if a.LdapPort == 0 {
+				return fmt.Errorf("parameter LdapPort missing")
+			}
 		}
 		if len(a.LdapBindDN) == 0 {
 			return fmt.Errorf("parameter LdapBindDN missing")
@@ -258,6 +702,38 @@ func (a *application) validate() error {
 		if len(a.LdapGroupFilter) == 0 {
 			return fmt.Errorf("parameter LdapGroupFilter missing")
 		}
+		if err := a.validateLdapTls(); err != nil {
+			return err
+		}
+		switch a.LdapGroupNesting {
+		case pkg.LdapGroupNestingNone, pkg.LdapGroupNestingRecursive, pkg.LdapGroupNestingInChain:
+		default:
+			return fmt.Errorf("parameter LdapGroupNesting invalid: %v", a.LdapGroupNesting)
+		}
+		if a.LdapGroupNesting != pkg.LdapGroupNestingNone && len(a.LdapGroupMember) == 0 {
+			return fmt.Errorf("parameter LdapGroupMember missing")
+		}
+		if a.LdapGroupMaxDepth < 0 {
+			return fmt.Errorf("parameter LdapGroupMaxDepth invalid")
+		}
+		if a.LdapMaxConnections <= 0 {
+			return fmt.Errorf("parameter LdapMaxConnections invalid")
+		}
+		if a.LdapTimeout <= 0 {
+			return fmt.Errorf("parameter LdapTimeout invalid")
+		}
+		if a.LdapIdleTimeout < 0 {
+			return fmt.Errorf("parameter LdapIdleTimeout invalid")
+		}
+		if a.LdapHealthInterval < 0 {
+			return fmt.Errorf("parameter LdapHealthInterval invalid")
+		}
+		_, err := pkg.ParseLdapAttributeRules(context.Background(), a.LdapRequiredAttributes)
+		if err != nil {
+			return fmt.Errorf("parameter LdapRequiredAttributes invalid: %v", err)
+		}
+	} else if len(a.LdapAttributeHeaders) > 0 || len(a.LdapRequiredAttributes) > 0 {
+		return fmt.Errorf("parameter LdapAttributeHeaders and LdapRequiredAttributes need ldap")
 	}
 	if a.VerifierType == "crowd" {
 		if len(a.CrowdAppName) == 0 {
@@ -288,9 +764,149 @@ func (a *application) validate() error {
 			return fmt.Errorf("parameter BasicAuthRealm missing")
 		}
 	}
+	if a.Kind == "digest" {
+		if len(a.BasicAuthRealm) == 0 {
+			return fmt.Errorf("parameter BasicAuthRealm missing")
+		}
+		if len(a.DigestFile) == 0 && a.VerifierType != "file" {
+			return fmt.Errorf("parameter DigestFile missing")
+		}
+		if a.DigestNonceTTL <= 0 {
+			return fmt.Errorf("parameter DigestNonceTTL invalid")
+		}
+	}
+	if len(a.CelPolicy) > 0 {
+		if _, err := pkg.CompileCelPolicy(context.Background(), a.CelPolicy); err != nil {
+			return fmt.Errorf("parameter CelPolicy invalid: %v", err)
+		}
+	}
+	for name, cidrs := range map[string][]string{
+		"IpAllow":         a.IpAllow,
+		"IpDeny":          a.IpDeny,
+		"TrustedNetworks": a.TrustedNetworks,
+		"TrustedProxies":  a.TrustedProxies,
+	} {
+		if _, err := pkg.ParseNetworks(context.Background(), cidrs); err != nil {
+			return fmt.Errorf("parameter %s invalid: %v", name, err)
+		}
+	}
+	if a.LoginMaxFailures > 0 || a.LoginMaxFailuresPerIP > 0 {
+		if a.LoginLockout <= 0 {
+			return fmt.Errorf("parameter LoginLockout invalid")
+		}
+		if a.LoginLockoutMax < pkg.LoginLockoutMax(a.LoginLockout) {
+			return fmt.Errorf("parameter LoginLockoutMax invalid")
+		}
+	}
+	if a.jwtEnabled() {
+		if len(a.JwtJwksURL) > 0 {
+			if _, err := url.ParseRequestURI(a.JwtJwksURL.String()); err != nil {
+				return fmt.Errorf("parameter JwtJwksURL invalid: %v", err)
+			}
+		}
+		if len(a.JwtUsernameClaim) == 0 {
+			return fmt.Errorf("parameter JwtUsernameClaim missing")
+		}
+	}
+	if len(a.ApiKeyFile) > 0 {
+		if len(a.ApiKeyHeader) == 0 {
+			return fmt.Errorf("parameter ApiKeyHeader missing")
+		}
+		if _, err := pkg.ReadApiKeyFile(context.Background(), a.ApiKeyFile); err != nil {
+			return fmt.Errorf("parameter ApiKeyFile invalid: %v", err)
+		}
+	}
+	if len(a.RateLimitFile) > 0 {
+		if _, err := pkg.ReadRateLimitFile(context.Background(), a.RateLimitFile); err != nil {
+			return fmt.Errorf("parameter RateLimitFile invalid: %v", err)
+		}
+	}
+	if len(a.TlsCertFile) > 0 || len(a.TlsKeyFile) > 0 {
+		if len(a.TlsCertFile) == 0 {
+			return fmt.Errorf("parameter TlsCertFile missing")
+		}
+		if len(a.TlsKeyFile) == 0 {
+			return fmt.Errorf("parameter TlsKeyFile missing")
+		}
+	}
+	if len(a.TlsClientCaFile) > 0 {
+		if len(a.TlsCertFile) == 0 {
+			return fmt.Errorf("parameter TlsCertFile missing")
+		}
+		if a.TlsClientCertMode != pkg.ClientCertModeAlternative &&
+			a.TlsClientCertMode != pkg.ClientCertModeAdditional {
+			return fmt.Errorf("parameter TlsClientCertMode invalid")
+		}
+		if _, err := a.TlsClientUsername.Parse(); err != nil {
+			return fmt.Errorf("parameter TlsClientUsername invalid: %v", err)
+		}
+	}
+	if len(a.TotpSecretFile) > 0 || len(a.TotpLdapAttribute) > 0 {
+		if a.Kind != "html" {
+			return fmt.Errorf("parameter Totp requires Kind html")
+		}
+		if len(a.TotpSecretFile) > 0 && len(a.TotpLdapAttribute) > 0 {
+			return fmt.Errorf("parameter TotpSecretFile and TotpLdapAttribute are exclusive")
+		}
+		if len(a.TotpLdapAttribute) > 0 && a.VerifierType != "ldap" {
+			return fmt.Errorf("parameter TotpLdapAttribute requires VerifierType ldap")
+		}
+		if a.TotpSkew < 0 {
+			return fmt.Errorf("parameter TotpSkew invalid")
+		}
+	}
+	if a.AdminPort > 0 {
+		if a.AdminPort == a.Port {
+			return fmt.Errorf("parameter AdminPort must differ from Port")
+		}
+		if len(a.AdminApiToken) == 0 && len(a.AdminGroup) == 0 {
+			return fmt.Errorf("parameter AdminApiToken or AdminGroup missing")
+		}
+		if len(a.AdminGroup) > 0 && a.VerifierType != "ldap" {
+			return fmt.Errorf("parameter AdminGroup requires VerifierType ldap")
+		}
+		if len(a.AdminGroup) > 0 && len(a.TlsCertFile) == 0 {
+			return fmt.Errorf("parameter AdminGroup requires TlsCertFile")
+		}
+	}
+	if a.MetricsPort > 0 && (a.MetricsPort == a.Port || a.MetricsPort == a.AdminPort) {
+		return fmt.Errorf("parameter MetricsPort must differ from Port and AdminPort")
+	}
+	if len(a.AccessLog) > 0 {
+		if a.AccessLogFormat != pkg.AccessLogFormatJson &&
+			a.AccessLogFormat != pkg.AccessLogFormatCombined {
+			return fmt.Errorf("parameter AccessLogFormat invalid")
+		}
+		if a.AccessLogMaxSize <= 0 {
+			return fmt.Errorf("parameter AccessLogMaxSize invalid")
+		}
+	}
+	if len(a.AuditLog) > 0 && len(a.AuditSyslog) > 0 {
+		return fmt.Errorf("parameter AuditLog and AuditSyslog are exclusive")
+	}
+	if len(a.AuditLog) > 0 && a.AuditLogMaxSize <= 0 {
+		return fmt.Errorf("parameter AuditLogMaxSize invalid")
+	}
+	if len(a.AuditSyslog) > 0 {
+		if err := a.AuditSyslog.Validate(context.Background()); err != nil {
+			return fmt.Errorf("parameter AuditSyslog invalid: %v", err)
+		}
+	}
+	if len(a.OtlpEndpoint) > 0 {
+		if err := a.OtlpEndpoint.Validate(context.Background()); err != nil {
+			return fmt.Errorf("parameter OtlpEndpoint invalid: %v", err)
+		}
+	}
+	if a.TraceSampleRatio < 0 || a.TraceSampleRatio > 1 {
+		return fmt.Errorf("parameter TraceSampleRatio must be between 0 and 1")
+	}
 	return nil
 }
 
+func (a *application) jwtEnabled() bool {
+	return len(a.JwtPublicKeyFiles) > 0 || len(a.JwtJwksURL) > 0
+}
+
 func (a *application) run(ctx context.Context) error {
 	glog.V(2).Infof("create http server on %s", a.Port.Address())
 
@@ -313,41 +929,467 @@ func (a *application) run(ctx context.Context) error {
 			return roundTripper.RoundTrip(req)
 		})
 
+	auditLog, closeAuditLog, err := a.createAuditLog(ctx)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "create audit log failed")
+	}
+	defer closeAuditLog()
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if len(a.OtlpEndpoint) > 0 {
+		glog.V(2).Infof("export traces to %s", a.OtlpEndpoint)
+		tracerProvider, err := pkg.NewTracerProvider(ctx, a.OtlpEndpoint, a.TraceSampleRatio)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "create tracer provider failed")
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tracerProvider.Shutdown(ctx); err != nil {
+				glog.Warningf("shutdown tracer provider failed: %v", err)
+			}
+		}()
+		otel.SetTracerProvider(tracerProvider)
+	}
+
 	glog.V(2).Infof("get auth filter for: %v", a.Kind)
-	v, err := a.createVerifier(ctx)
+	ldapAuthenticator, err := a.createLdapAuthenticator(ctx)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "create ldap authenticator failed")
+	}
+	defer ldapAuthenticator.Close()
+	v, err := a.createVerifier(ctx, ldapAuthenticator, auditLog)
 	if err != nil {
 		return errors.Wrapf(ctx, err, "create verifier failed")
 	}
 
-	check := pkg.CheckFunc(func(username string, password string) (bool, error) {
-		return v.Verify(pkg.UserName(username), pkg.Password(password))
+	metricsVerifier := pkg.NewMetricsVerifier(a.VerifierType.String(), v)
+	check := pkg.CheckFunc(func(
+		ctx context.Context,
+		username string,
+		password string,
+	) (bool, error) {
+		return metricsVerifier.Verify(ctx, pkg.UserName(username), pkg.Password(password))
 	})
 
+	apiKeyAuth := pkg.ApiKeyAuthDisabled
+	if len(a.ApiKeyFile) > 0 {
+		glog.V(2).Infof("add api key auth with keys from %s", a.ApiKeyFile)
+		apiKeyAuth = pkg.NewApiKeyAuth(
+			pkg.NewApiKeyFileStore(a.ApiKeyFile),
+			a.ApiKeyHeader,
+			a.RequiredGroups,
+		)
+	}
+
+	clientCertAuth, err := a.createClientCertAuth(ctx)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "create client cert auth failed")
+	}
+
+	groupResolver := a.createGroupResolver(ldapAuthenticator)
+	authorizer, err := a.createAuthorizer(ctx, groupResolver)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "create authorizer failed")
+	}
+
+	loginThrottle := a.createLoginThrottle()
+	sessionStore := pkg.NewSessionStore(time.Now)
+
+	if len(a.RateLimitFile) > 0 {
+		rules, err := pkg.ReadRateLimitFile(ctx, a.RateLimitFile)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "read rate limit file failed")
+		}
+		glog.V(2).Infof("add %d rate limit rules from %s", len(rules), a.RateLimitFile)
+		forwardHandler = pkg.NewRateLimitHandler(
+			forwardHandler,
+			pkg.NewRateLimiter(rules, groupResolver, time.Now),
+		)
+	}
+
+	if len(a.LdapAttributeHeaders) > 0 {
+		glog.V(2).Infof("add ldap attribute headers %v", a.LdapAttributeHeaders)
+		forwardHandler = pkg.NewLdapAttributeHandler(
+			forwardHandler,
+			ldapAuthenticator,
+			a.LdapAttributeHeaders,
+			a.CacheTTL,
+			a.CacheMaxEntries,
+			time.Now,
+		)
+	}
+
 	var httpFilter http.Handler
 	switch a.Kind {
 	case "html":
-		httpFilter = pkg.NewAuthHtmlHandler(forwardHandler, check, pkg.NewCrypter(a.Secret.Bytes()))
+		crypter := pkg.NewCrypter(a.Secret.Bytes())
+		httpFilter = pkg.NewAuthHtmlHandler(
+			forwardHandler,
+			check,
+			apiKeyAuth,
+			clientCertAuth,
+			authorizer,
+			loginThrottle,
+			a.createTotpVerifier(ldapAuthenticator),
+			crypter,
+			sessionStore,
+			auditLog,
+		)
 	case "basic":
-		httpFilter = pkg.NewAuthBasicHandler(forwardHandler, check, a.BasicAuthRealm.String())
+		httpFilter = pkg.NewAuthBasicHandler(
+			forwardHandler,
+			check,
+			apiKeyAuth,
+			clientCertAuth,
+			authorizer,
+			loginThrottle,
+			a.BasicAuthRealm.String(),
+			auditLog,
+		)
+	case "digest":
+		userVerifier, err := a.createDigestUserVerifier(ctx, ldapAuthenticator, auditLog)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "create digest user verifier failed")
+		}
+		httpFilter = pkg.NewAuthDigestHandler(
+			forwardHandler,
+			a.createDigestHa1Store(),
+			pkg.NewDigestNonceStore(a.DigestNonceTTL, time.Now),
+			userVerifier,
+			apiKeyAuth,
+			clientCertAuth,
+			authorizer,
+			loginThrottle,
+			a.BasicAuthRealm.String(),
+			auditLog,
+		)
 	default:
 		return errors.Errorf(ctx, "unknown kind %v", a.Kind)
 	}
 
+	if a.jwtEnabled() {
+		glog.V(2).Infof("add jwt auth")
+		jwtVerifier, err := a.createJwtVerifier(ctx)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "create jwt verifier failed")
+		}
+		httpFilter = pkg.NewAuthJwtHandler(
+			forwardHandler,
+			httpFilter,
+			jwtVerifier,
+			authorizer,
+			a.RequiredGroups,
+		)
+	}
+
+	if len(a.AllowUnauthenticated) > 0 {
+		allowList, err := pkg.ParseAllowList(ctx, a.AllowUnauthenticated)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "parse allow unauthenticated failed")
+		}
+		glog.V(2).Infof("allow without auth: %v", a.AllowUnauthenticated)
+		httpFilter = pkg.NewAllowListHandler(forwardHandler, httpFilter, allowList)
+	}
+
+	if a.ipFilterEnabled() {
+		httpFilter, err = a.createIpFilterHandler(ctx, httpFilter, forwardHandler)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "create ip filter failed")
+		}
+	}
+
 	router := mux.NewRouter()
-	router.Path("/healthz").Handler(a.checkHandler())
-	router.Path("/readiness").Handler(a.checkHandler())
-	router.NotFoundHandler = httpFilter
+	router.Path("/healthz").Handler(pkg.NewMetricsHandler("healthz", a.checkHandler()))
+	router.Path("/readiness").Handler(pkg.NewMetricsHandler("readiness", a.checkHandler()))
+	router.NotFoundHandler = pkg.NewMetricsHandler("proxy", httpFilter)
 
-	var handler http.Handler = router
+	trustedProxies, err := pkg.ParseNetworks(ctx, a.TrustedProxies)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "parse trusted proxies failed")
+	}
+	var handler http.Handler = pkg.NewTracingHandler(router)
 	if glog.V(4) {
 		glog.Infof("add debug handler")
 		handler = pkg.NewDebugHandler(handler)
 	}
-	return gracehttp.Serve(&http.Server{
+	if len(a.AccessLog) > 0 {
+		glog.V(2).Infof("write %s access log to %s", a.AccessLogFormat, a.AccessLog)
+		accessLogWriter := pkg.NewLogWriter(a.AccessLog, a.AccessLogMaxSize, a.AccessLogMaxBackups)
+		defer accessLogWriter.Close()
+		handler = pkg.NewAccessLogHandler(handler, accessLogWriter, a.AccessLogFormat, time.Now)
+	}
+	handler = pkg.NewRequestIDHandler(handler, a.RequestIDHeader, trustedProxies)
+	server := &http.Server{
 		Addr:              a.Port.Address(),
 		Handler:           handler,
 		ReadHeaderTimeout: 10 * time.Second,
-	})
+	}
+	if len(a.TlsCertFile) > 0 {
+		glog.V(2).Infof("enable tls with certificate %s", a.TlsCertFile)
+		server.TLSConfig, err = pkg.NewServerTlsConfig(
+			ctx,
+			a.TlsCertFile,
+			a.TlsKeyFile,
+			a.TlsClientCaFile,
+			a.TlsClientCertMode,
+		)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "create tls config failed")
+		}
+	}
+	servers := []*http.Server{server}
+	if a.AdminPort > 0 {
+		glog.V(2).Infof("add admin api on %s", a.AdminPort.Address())
+		adminServer := &http.Server{
+			Addr: a.AdminPort.Address(),
+			Handler: pkg.NewRequestIDHandler(
+				pkg.NewMetricsHandler("admin", pkg.NewAdminHandler(
+					a.createAdminAuth(check, groupResolver, loginThrottle),
+					v,
+					sessionStore,
+					loginThrottle,
+					auditLog,
+					a.redacted(),
+				)),
+				a.RequestIDHeader,
+				trustedProxies,
+			),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		if len(a.TlsCertFile) > 0 {
+			// admin requests carry tokens and passwords, serve them with the certificate of
+			// the proxy but without asking for client certificates
+			adminServer.TLSConfig, err = pkg.NewServerTlsConfig(
+				ctx,
+				a.TlsCertFile,
+				a.TlsKeyFile,
+				"",
+				a.TlsClientCertMode,
+			)
+			if err != nil {
+				return errors.Wrapf(ctx, err, "create admin tls config failed")
+			}
+		}
+		servers = append(servers, adminServer)
+	}
+	if a.MetricsPort > 0 {
+		glog.V(2).Infof("add metrics on %s", a.MetricsPort.Address())
+		metricsRouter := mux.NewRouter()
+		metricsRouter.Path("/metrics").Handler(promhttp.Handler())
+		servers = append(servers, &http.Server{
+			Addr:              a.MetricsPort.Address(),
+			Handler:           metricsRouter,
+			ReadHeaderTimeout: 10 * time.Second,
+		})
+	}
+	return gracehttp.Serve(servers...)
+}
+
+func (a *application) createAdminAuth(
+	check pkg.Check,
+	groupResolver pkg.GroupResolver,
+	loginThrottle pkg.LoginThrottle,
+) pkg.AdminAuth {
+	var adminAuths []pkg.AdminAuth
+	if len(a.AdminApiToken) > 0 {
+		adminAuths = append(adminAuths, pkg.NewAdminTokenAuth(a.AdminApiToken))
+	}
+	if len(a.AdminGroup) > 0 {
+		adminAuths = append(adminAuths, pkg.NewAdminGroupAuth(
+			check,
+			groupResolver,
+			loginThrottle,
+			a.AdminGroup,
+		))
+	}
+	return pkg.NewAdminAuthList(adminAuths...)
+}
+
+// redacted returns a copy of the configuration without secrets.
+func (a *application) redacted() application {
+	const redacted = "REDACTED"
+	result := *a
+	if len(result.Secret) > 0 {
+		result.Secret = redacted
+	}
+	if len(result.LdapBindPassword) > 0 {
+		result.LdapBindPassword = redacted
+	}
+	if len(result.CrowdAppPassword) > 0 {
+		result.CrowdAppPassword = redacted
+	}
+	if len(result.AdminApiToken) > 0 {
+		result.AdminApiToken = redacted
+	}
+	return result
+}
+
+func (a *application) createLoginThrottle() pkg.LoginThrottle {
+	if a.LoginMaxFailures <= 0 && a.LoginMaxFailuresPerIP <= 0 {
+		return pkg.LoginThrottleDisabled
+	}
+	glog.V(2).Infof(
+		"lock logins after %d failures per user or %d per ip for %v up to %v",
+		a.LoginMaxFailures,
+		a.LoginMaxFailuresPerIP,
+		a.LoginLockout.Duration(),
+		a.LoginLockoutMax.Duration(),
+	)
+	return pkg.NewLoginThrottle(
+		a.LoginMaxFailures,
+		a.LoginMaxFailuresPerIP,
+		a.LoginLockout,
+		a.LoginLockoutMax,
+		a.LoginMaxEntries,
+		time.Now,
+	)
+}
+
+func (a *application) ipFilterEnabled() bool {
+	return len(a.IpAllow) > 0 || len(a.IpDeny) > 0 || len(a.TrustedNetworks) > 0 ||
+		len(a.TrustedProxies) > 0
+}
+
+func (a *application) createIpFilterHandler(
+	ctx context.Context,
+	authHandler http.Handler,
+	forwardHandler http.Handler,
+) (http.Handler, error) {
+	trustedProxies, err := pkg.ParseNetworks(ctx, a.TrustedProxies)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse trusted proxies failed")
+	}
+	allowNetworks, err := pkg.ParseNetworks(ctx, a.IpAllow)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse ip allow failed")
+	}
+	denyNetworks, err := pkg.ParseNetworks(ctx, a.IpDeny)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse ip deny failed")
+	}
+	trustedNetworks, err := pkg.ParseNetworks(ctx, a.TrustedNetworks)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse trusted networks failed")
+	}
+	glog.V(2).Infof(
+		"add ip filter with trusted proxies %v, allow %v, deny %v, trusted networks %v",
+		a.TrustedProxies,
+		a.IpAllow,
+		a.IpDeny,
+		a.TrustedNetworks,
+	)
+	return pkg.NewIpFilterHandler(
+		authHandler,
+		forwardHandler,
+		trustedProxies,
+		allowNetworks,
+		denyNetworks,
+		trustedNetworks,
+		a.TrustedNetworkUser,
+	), nil
+}
+
+func (a *application) createGroupResolver(
+	ldapAuthenticator pkg.LdapAuthenticator,
+) pkg.GroupResolver {
+	groupResolver := pkg.GroupResolverNone
+	if a.VerifierType == "ldap" {
+		groupResolver = pkg.NewCacheGroupResolver(
+			pkg.NewLdapGroupResolver(ldapAuthenticator),
+			a.CacheTTL,
+			a.CacheStaleTTL,
+			a.CacheMaxEntries,
+			time.Now,
+		)
+	}
+	return pkg.NewClientCertGroupResolver(groupResolver)
+}
+
+func (a *application) createAuthorizer(
+	ctx context.Context,
+	groupResolver pkg.GroupResolver,
+) (pkg.Authorizer, error) {
+	var authorizers []pkg.Authorizer
+	if len(a.AuthorizationFile) > 0 {
+		rules, err := pkg.ReadAuthorizationFile(ctx, a.AuthorizationFile)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "read authorization file failed")
+		}
+		glog.V(2).Infof("add %d authorization rules from %s", len(rules), a.AuthorizationFile)
+		authorizers = append(authorizers, pkg.NewAuthorizer(rules, groupResolver))
+	}
+	if len(a.CelPolicy) > 0 {
+		program, err := pkg.CompileCelPolicy(ctx, a.CelPolicy)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "compile cel policy failed")
+		}
+		glog.V(2).Infof("add cel policy: %s", a.CelPolicy)
+		authorizers = append(authorizers, pkg.NewCelAuthorizer(program, groupResolver, time.Now))
+	}
+	switch len(authorizers) {
+	case 0:
+		return pkg.AuthorizerDisabled, nil
+	case 1:
+		return authorizers[0], nil
+	default:
+		return pkg.NewAuthorizerList(authorizers...), nil
+	}
+}
+
+func (a *application) createDigestHa1Store() pkg.DigestHa1Store {
+	if len(a.DigestFile) > 0 {
+		glog.V(2).Infof("add digest auth with ha1 from %s", a.DigestFile)
+		return pkg.NewDigestHtdigestStore(a.DigestFile)
+	}
+	glog.V(2).Infof("add digest auth with passwords from %s", a.UserFile)
+	return pkg.NewDigestUserFileStore(a.UserFile)
+}
+
+func (a *application) createTotpVerifier(ldapAuthenticator pkg.LdapAuthenticator) pkg.TotpVerifier {
+	switch {
+	case len(a.TotpSecretFile) > 0:
+		glog.V(2).Infof("add totp with secrets from %s", a.TotpSecretFile)
+		return pkg.NewTotpVerifier(pkg.NewTotpFileSecretStore(a.TotpSecretFile), a.TotpSkew, time.Now)
+	case len(a.TotpLdapAttribute) > 0:
+		glog.V(2).Infof("add totp with secrets from ldap attribute %s", a.TotpLdapAttribute)
+		return pkg.NewTotpVerifier(
+			pkg.NewCacheTotpSecretStore(
+				pkg.NewTotpLdapSecretStore(ldapAuthenticator, a.TotpLdapAttribute),
+				a.CacheTTL,
+				a.CacheMaxEntries,
+				time.Now,
+			),
+			a.TotpSkew,
+			time.Now,
+		)
+	default:
+		return pkg.TotpVerifierDisabled
+	}
+}
+
+func (a *application) createClientCertAuth(ctx context.Context) (pkg.ClientCertAuth, error) {
+	if len(a.TlsClientCaFile) == 0 {
+		return pkg.ClientCertAuthDisabled, nil
+	}
+	glog.V(2).Infof("add client certificate auth in mode %s", a.TlsClientCertMode)
+	usernameTemplate, err := a.TlsClientUsername.Parse()
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse username template failed")
+	}
+	groupMapping := pkg.ClientCertGroupMapping{}
+	if len(a.TlsClientGroupFile) > 0 {
+		groupMapping, err = pkg.ReadClientCertGroupFile(ctx, a.TlsClientGroupFile)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "read client cert group file failed")
+		}
+	}
+	return pkg.NewClientCertAuth(
+		a.TlsClientCertMode,
+		usernameTemplate,
+		groupMapping,
+		a.RequiredGroups,
+	), nil
 }
 
 func (a *application) checkHandler() http.Handler {
@@ -381,31 +1423,124 @@ func (a *application) checkTcp() error {
 	return conn.Close()
 }
 
-func (a *application) createVerifier(ctx context.Context) (pkg.Verifier, error) {
+func (a *application) validateLdapTls() error {
+	switch a.LdapTlsMode {
+	case "", pkg.LdapTlsModeStartTLS, pkg.LdapTlsModeLdaps, pkg.LdapTlsModePlain:
+	default:
+		return fmt.Errorf("parameter LdapTlsMode invalid: %v", a.LdapTlsMode)
+	}
+	if a.LdapTlsMode == pkg.LdapTlsModeStartTLS && a.LdapSkipTls {
+		return fmt.Errorf("parameter LdapTlsMode starttls conflicts with LdapSkipTls")
+	}
+	ldapServers, err := a.ldapServers(context.Background())
+	if err != nil {
+		return fmt.Errorf("parameter LdapURLs invalid: %v", err)
+	}
+	for _, ldapServer := range ldapServers {
+		if len(a.LdapTlsMode) > 0 && ldapServer.UseSSL != (a.LdapTlsMode == pkg.LdapTlsModeLdaps) {
+			return fmt.Errorf(
+				"parameter LdapTlsMode %v conflicts with server %v",
+				a.LdapTlsMode,
+				ldapServer,
+			)
+		}
+	}
+	if (len(a.LdapTlsCertFile) == 0) != (len(a.LdapTlsKeyFile) == 0) {
+		return fmt.Errorf("parameter LdapTlsCertFile and LdapTlsKeyFile must be set together")
+	}
+	if _, err := a.createLdapTlsConfig(context.Background()); err != nil {
+		return fmt.Errorf("parameter LdapTls invalid: %v", err)
+	}
+	return nil
+}
+
+// ldapTlsMode returns the tls mode of ldap:// servers, by default StartTLS unless skipped.
+func (a *application) ldapTlsMode() pkg.LdapTlsMode {
+	if a.LdapTlsMode == pkg.LdapTlsModeStartTLS || a.LdapTlsMode == pkg.LdapTlsModePlain {
+		return a.LdapTlsMode
+	}
+	if a.LdapSkipTls {
+		return pkg.LdapTlsModePlain
+	}
+	return pkg.LdapTlsModeStartTLS
+}
+
+func (a *application) createLdapTlsConfig(ctx context.Context) (*tls.Config, error) {
+	return pkg.NewLdapTlsConfig(
+		ctx,
+		a.LdapTlsCaFile,
+		a.LdapTlsCertFile,
+		a.LdapTlsKeyFile,
+		a.LdapTlsMinVersion,
+		a.LdapTlsInsecure,
+	)
+}
+
+func (a *application) createLdapAuthenticator(
+	ctx context.Context,
+) (pkg.LdapAuthenticator, error) {
+	ldapServers, err := a.ldapServers(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "get ldap servers failed")
+	}
+	ldapTlsConfig, err := a.createLdapTlsConfig(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "create ldap tls config failed")
+	}
+	return pkg.NewLdapAuthenticator(
+		a.LdapBaseDn,
+		ldapServers,
+		a.LdapServerName,
+		a.ldapTlsMode(),
+		ldapTlsConfig,
+		a.LdapBindDN,
+		a.LdapBindPassword,
+		a.LdapUserDn,
+		a.LdapUserFilter,
+		a.LdapUserField,
+		a.LdapGroupDn,
+		a.LdapGroupFilter,
+		a.LdapGroupField,
+		a.LdapGroupNesting,
+		a.LdapGroupMember,
+		a.LdapGroupMaxDepth,
+		a.LdapMaxConnections,
+		a.LdapTimeout,
+		a.LdapIdleTimeout,
+		a.LdapHealthInterval,
+	), nil
+}
+
+// ldapServers returns the servers of -ldap-urls or the single -ldap-host.
+func (a *application) ldapServers(ctx context.Context) ([]pkg.LdapServer, error) {
+	if len(a.LdapURLs) > 0 {
+		return pkg.ParseLdapURLs(ctx, a.LdapURLs)
+	}
+	if len(a.LdapHost) == 0 {
+		return nil, nil
+	}
+	return []pkg.LdapServer{{
+		Host:   a.LdapHost.String(),
+		Port:   a.LdapPort.Int(),
+		UseSSL: a.LdapUseSSL.Bool() || a.LdapTlsMode == pkg.LdapTlsModeLdaps,
+	}}, nil
+}
+
+func (a *application) createVerifier(
+	ctx context.Context,
+	ldapAuthenticator pkg.LdapAuthenticator,
+	auditLog pkg.AuditLog,
+) (pkg.CacheAuth, error) {
 	glog.V(2).Infof("get verifier for: %v", a.VerifierType)
 	switch a.VerifierType {
 	case "ldap":
-		return pkg.NewCacheAuth(&pkg.LdapAuth{
-			LdapAuthenticator: pkg.NewLdapAuthenticator(
-				a.LdapBaseDn,
-				a.LdapHost,
-				a.LdapServerName,
-				a.LdapPort,
-				a.LdapUseSSL,
-				a.LdapSkipTls,
-				a.LdapBindDN,
-				a.LdapBindPassword,
-				a.LdapUserDn,
-				a.LdapUserFilter,
-				a.LdapUserField,
-				a.LdapGroupDn,
-				a.LdapGroupFilter,
-				a.LdapGroupField,
-			),
-			RequiredGroups: a.RequiredGroups,
-		}, a.CacheTTL), nil
+		ldapAuth, err := a.createLdapAuth(ctx, ldapAuthenticator)
+		if err != nil {
+			return nil, err
+		}
+		return a.createCacheAuth(ldapAuth, auditLog), nil
 	case "file":
-		return pkg.NewCacheAuth(pkg.NewFileAuth(a.UserFile), a.CacheTTL), nil
+		return a.createCacheAuth(pkg.NewFileAuth(a.UserFile), auditLog), nil
 	case "crowd":
 		crowdClient, err := crowd.New(
 			a.CrowdAppName.String(),
@@ -416,12 +1551,108 @@ func (a *application) createVerifier(ctx context.Context) (pkg.Verifier, error)
 			glog.V(2).Infof("create crowd client failed: %v", err)
 			return nil, errors.Wrap(ctx, err, "create crowd client failed")
 		}
-		return pkg.NewCacheAuth(pkg.NewCrowdAuth(crowdClient.Authenticate), a.CacheTTL), nil
+		return a.createCacheAuth(pkg.NewCrowdAuth(crowdClient.Authenticate), auditLog), nil
 	default:
 		return nil, errors.Errorf(ctx, "unknown verifier type: %v", a.VerifierType)
 	}
 }
 
+func (a *application) createLdapAuth(
+	ctx context.Context,
+	ldapAuthenticator pkg.LdapAuthenticator,
+) (*pkg.LdapAuth, error) {
+	requiredAttributes, err := pkg.ParseLdapAttributeRules(ctx, a.LdapRequiredAttributes)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse ldap required attributes failed")
+	}
+	return &pkg.LdapAuth{
+		LdapAuthenticator:  ldapAuthenticator,
+		RequiredGroups:     a.RequiredGroups,
+		RequiredAttributes: requiredAttributes,
+	}, nil
+}
+
+// createDigestUserVerifier checks the required groups and attributes of digest users.
+func (a *application) createDigestUserVerifier(
+	ctx context.Context,
+	ldapAuthenticator pkg.LdapAuthenticator,
+	auditLog pkg.AuditLog,
+) (pkg.Verifier, error) {
+	if a.VerifierType != "ldap" {
+		return pkg.VerifierNone, nil
+	}
+	ldapAuth, err := a.createLdapAuth(ctx, ldapAuthenticator)
+	if err != nil {
+		return nil, err
+	}
+	return a.createCacheAuth(pkg.NewLdapUserVerifier(ldapAuth), auditLog), nil
+}
+
+func (a *application) createCacheAuth(
+	verifier pkg.Verifier,
+	auditLog pkg.AuditLog,
+) pkg.CacheAuth {
+	return pkg.NewCacheAuth(
+		pkg.NewTracingVerifier(a.VerifierType.String(), verifier),
+		a.CacheTTL,
+		a.CacheNegativeTTL,
+		a.CacheStaleTTL,
+		a.CacheMaxEntries,
+		auditLog,
+		time.Now,
+	)
+}
+
+// createAuditLog returns the audit log and a func closing its writer.
+func (a *application) createAuditLog(ctx context.Context) (pkg.AuditLog, func(), error) {
+	var writer io.WriteCloser
+	switch {
+	case len(a.AuditSyslog) > 0:
+		glog.V(2).Infof("write audit log to syslog %s", a.AuditSyslog)
+		var err error
+		writer, err = pkg.NewAuditSyslogWriter(ctx, a.AuditSyslog)
+		if err != nil {
+			return nil, nil, err
+		}
+	case len(a.AuditLog) > 0:
+		glog.V(2).Infof("write audit log to %s", a.AuditLog)
+		writer = pkg.NewLogWriter(a.AuditLog, a.AuditLogMaxSize, a.AuditLogMaxBackups)
+	default:
+		return pkg.AuditLogDisabled, func() {}, nil
+	}
+	return pkg.NewAuditLog(writer, time.Now), func() {
+		if err := writer.Close(); err != nil {
+			glog.Warningf("close audit log failed: %v", err)
+		}
+	}, nil
+}
+
+func (a *application) createJwtVerifier(ctx context.Context) (pkg.JwtVerifier, error) {
+	var keySet pkg.JwtKeySet
+	if len(a.JwtJwksURL) > 0 {
+		keySet = pkg.NewJwtJwksKeySet(
+			&http.Client{Timeout: 30 * time.Second},
+			a.JwtJwksURL,
+			a.JwtJwksRefreshInterval,
+		)
+	} else {
+		keys, err := pkg.LoadJwtPublicKeyFiles(ctx, a.JwtPublicKeyFiles)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "load jwt public keys failed")
+		}
+		keySet = pkg.NewJwtStaticKeySet(keys)
+	}
+	return pkg.NewJwtVerifier(
+		keySet,
+		a.JwtIssuer,
+		a.JwtAudience,
+		a.JwtUsernameClaim,
+		a.JwtGroupsClaim,
+		a.JwtGroupMapping,
+		a.JwtLeeway,
+	), nil
+}
+
 type Port int
 
 func (p Port) Address() string {
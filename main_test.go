@@ -5,21 +5,271 @@
 package main_test
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gexec"
+
+	"github.com/bborbe/auth-http-proxy/ldaptest"
 )
 
+var pathToBinary string
+
+var _ = BeforeSuite(func() {
+	var err error
+	pathToBinary, err = gexec.Build("github.com/bborbe/auth-http-proxy")
+	Expect(err).NotTo(HaveOccurred())
+})
+
+var _ = AfterSuite(func() {
+	gexec.CleanupBuildArtifacts()
+})
+
 var _ = Describe("Auth-Http-Proxy", func() {
 	It("Compiles", func() {
-		var err error
-		_, err = gexec.Build("github.com/bborbe/auth-http-proxy")
+		Expect(pathToBinary).NotTo(BeEmpty())
+	})
+	Context("ldap verifier", func() {
+		var address string
+		var adminAddress string
+		var metricsAddress string
+		var args []string
+		BeforeEach(func() {
+			entries, err := ldaptest.ReadLDIFFile(context.Background(), "sample/ldap.ldif")
+			Expect(err).NotTo(HaveOccurred())
+			ldapServer := ldaptest.NewServer(entries)
+			DeferCleanup(ldapServer.Close)
+
+			target := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				resp.Header().Set("X-Target-Email", req.Header.Get("X-Forwarded-Email"))
+				fmt.Fprint(resp, "hello from target")
+			}))
+			DeferCleanup(target.Close)
+
+			port := freePort()
+			address = fmt.Sprintf("127.0.0.1:%d", port)
+			adminPort := freePort()
+			adminAddress = fmt.Sprintf("127.0.0.1:%d", adminPort)
+			metricsPort := freePort()
+			metricsAddress = fmt.Sprintf("127.0.0.1:%d", metricsPort)
+			configFile := filepath.Join(GinkgoT().TempDir(), "config.json")
+			config := `{"totp-skew":0,"login-max-failures":0,"cache-negative-ttl":0,` +
+				`"trace-sample-ratio":0}`
+			Expect(os.WriteFile(configFile, []byte(config), 0600)).To(Succeed())
+			args = []string{
+				"-logtostderr",
+				"-config=" + configFile,
+				fmt.Sprintf("-port=%d", port),
+				"-target-address=" + target.Listener.Addr().String(),
+				"-kind=basic",
+				"-basic-auth-realm=test",
+				"-verifier=ldap",
+				"-ldap-urls=" + ldapServer.URL(),
+				"-ldap-tls-mode=plain",
+				"-ldap-bind-dn=cn=admin,dc=example,dc=com",
+				"-ldap-bind-password=S3CR3T",
+				"-ldap-base-dn=dc=example,dc=com",
+				"-ldap-user-dn=ou=users",
+				"-ldap-group-dn=ou=groups",
+				"-ldap-user-filter=(uid=%s)",
+				"-ldap-group-filter=(member=uid=%s,ou=users,dc=example,dc=com)",
+				"-ldap-user-field=uid",
+				"-ldap-group-field=cn",
+				"-ldap-group-nesting=recursive",
+				"-required-groups=staff",
+				"-ldap-attribute-headers=mail=X-Forwarded-Email",
+				"-ldap-required-attributes=mail!=",
+				fmt.Sprintf("-admin-port=%d", adminPort),
+				"-admin-api-token=admin-secret",
+				fmt.Sprintf("-metrics-port=%d", metricsPort),
+			}
+		})
+		JustBeforeEach(func() {
+			session, err := gexec.Start(exec.Command(pathToBinary, args...), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				session.Terminate().Wait(5 * time.Second)
+			})
+			Eventually(func() error {
+				conn, err := net.Dial("tcp", address)
+				if err != nil {
+					return err
+				}
+				return conn.Close()
+			}, 10*time.Second, 50*time.Millisecond).Should(Succeed())
+		})
+		getPath := func(path, username, password string) (int, http.Header, string) {
+			req, err := http.NewRequest(http.MethodGet, "http://"+address+path, nil)
+			Expect(err).NotTo(HaveOccurred())
+			req.SetBasicAuth(username, password)
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			return resp.StatusCode, resp.Header, string(body)
+		}
+		get := func(username, password string) (int, http.Header, string) {
+			return getPath("/", username, password)
+		}
+		It("forwards request of group member", func() {
+			status, header, body := get("alice", "alice-secret")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("hello from target"))
+			Expect(header.Get("X-Target-Email")).To(Equal("alice@example.com"))
+		})
+		It("forwards request of nested group member", func() {
+			status, _, _ := get("bob", "bob-secret")
+			Expect(status).To(Equal(http.StatusOK))
+		})
+		It("rejects wrong password", func() {
+			status, _, _ := get("alice", "wrong")
+			Expect(status).To(Equal(http.StatusUnauthorized))
+		})
+		It("serves config without secrets on admin port", func() {
+			req, err := http.NewRequest(http.MethodGet, "http://"+adminAddress+"/admin/config", nil)
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Authorization", "Bearer admin-secret")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(ContainSubstring(`"ldap-bind-password":"REDACTED"`))
+			Expect(string(body)).NotTo(ContainSubstring("S3CR3T"))
+			Expect(string(body)).NotTo(ContainSubstring("admin-secret"))
+			Expect(string(body)).To(ContainSubstring(`"totp-skew":0`))
+			Expect(string(body)).To(ContainSubstring(`"login-max-failures":0`))
+			Expect(string(body)).To(ContainSubstring(`"cache-negative-ttl":0`))
+			Expect(string(body)).To(ContainSubstring(`"trace-sample-ratio":0`))
+		})
+		It("rejects user without required group", func() {
+			status, _, _ := get("carol", "carol-secret")
+			Expect(status).To(Equal(http.StatusUnauthorized))
+		})
+		It("forwards /metrics of the target", func() {
+			status, _, body := getPath("/metrics", "alice", "alice-secret")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("hello from target"))
+		})
+		It("serves metrics on metrics port", func() {
+			status, _, _ := get("alice", "alice-secret")
+			Expect(status).To(Equal(http.StatusOK))
+			resp, err := http.Get("http://" + metricsAddress + "/metrics")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(ContainSubstring(
+				`auth_http_proxy_requests_total{code="200",route="proxy"} 1`,
+			))
+			Expect(string(body)).To(ContainSubstring(
+				`auth_http_proxy_auth_attempts_total{outcome="success",verifier="ldap"} 1`,
+			))
+			Expect(string(body)).To(ContainSubstring(
+				`auth_http_proxy_ldap_connections{server=`,
+			))
+		})
+		Context("with tls", func() {
+			var client *http.Client
+			BeforeEach(func() {
+				certificates, err := ldaptest.NewCertificates()
+				Expect(err).NotTo(HaveOccurred())
+				dir := GinkgoT().TempDir()
+				certFile := filepath.Join(dir, "server.crt")
+				keyFile := filepath.Join(dir, "server.key")
+				Expect(os.WriteFile(certFile, certificates.ServerCertPEM, 0600)).To(Succeed())
+				Expect(os.WriteFile(keyFile, certificates.ServerKeyPEM, 0600)).To(Succeed())
+				args = append(
+					args,
+					"-tls-cert-file="+certFile,
+					"-tls-key-file="+keyFile,
+					"-admin-group=staff",
+				)
+				rootCAs := x509.NewCertPool()
+				Expect(rootCAs.AppendCertsFromPEM(certificates.CAPEM)).To(BeTrue())
+				client = &http.Client{
+					Transport: &http.Transport{
+						TLSClientConfig: &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12},
+					},
+				}
+			})
+			getAdmin := func(scheme string) (*http.Response, error) {
+				req, err := http.NewRequest(
+					http.MethodGet,
+					scheme+"://"+adminAddress+"/admin/config",
+					nil,
+				)
+				Expect(err).NotTo(HaveOccurred())
+				req.SetBasicAuth("alice", "alice-secret")
+				return client.Do(req)
+			}
+			It("serves admin api with https to group member", func() {
+				resp, err := getAdmin("https")
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			})
+			It("does not serve admin api with http", func() {
+				resp, err := getAdmin("http")
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+	It("refuses admin group without tls", func() {
+		command := exec.Command(
+			pathToBinary,
+			"-logtostderr",
+			"-port=8080",
+			"-target-address=localhost:7777",
+			"-kind=basic",
+			"-basic-auth-realm=test",
+			"-verifier=ldap",
+			"-ldap-urls=ldap://localhost:389",
+			"-ldap-bind-dn=cn=admin,dc=example,dc=com",
+			"-ldap-bind-password=S3CR3T",
+			"-ldap-base-dn=dc=example,dc=com",
+			"-ldap-user-filter=(uid=%s)",
+			"-ldap-group-filter=(member=uid=%s,ou=users,dc=example,dc=com)",
+			"-ldap-user-field=uid",
+			"-ldap-group-field=cn",
+			"-admin-port=9090",
+			"-admin-group=staff",
+		)
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
 		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, 10*time.Second).Should(gexec.Exit())
+		Expect(session.ExitCode()).NotTo(Equal(0))
+		Expect(string(session.Err.Contents())).To(
+			ContainSubstring("parameter AdminGroup requires TlsCertFile"),
+		)
 	})
 })
 
+func freePort() int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
 func TestSuite(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Auth-Http-Proxy Suite")
@@ -0,0 +1,122 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type AdminAuth struct {
+	AuthenticateStub        func(*http.Request) (pkg.UserName, bool, error)
+	authenticateMutex       sync.RWMutex
+	authenticateArgsForCall []struct {
+		arg1 *http.Request
+	}
+	authenticateReturns struct {
+		result1 pkg.UserName
+		result2 bool
+		result3 error
+	}
+	authenticateReturnsOnCall map[int]struct {
+		result1 pkg.UserName
+		result2 bool
+		result3 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *AdminAuth) Authenticate(arg1 *http.Request) (pkg.UserName, bool, error) {
+	fake.authenticateMutex.Lock()
+	ret, specificReturn := fake.authenticateReturnsOnCall[len(fake.authenticateArgsForCall)]
+	fake.authenticateArgsForCall = append(fake.authenticateArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	stub := fake.AuthenticateStub
+	fakeReturns := fake.authenticateReturns
+	fake.recordInvocation("Authenticate", []interface{}{arg1})
+	fake.authenticateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *AdminAuth) AuthenticateCallCount() int {
+	fake.authenticateMutex.RLock()
+	defer fake.authenticateMutex.RUnlock()
+	return len(fake.authenticateArgsForCall)
+}
+
+func (fake *AdminAuth) AuthenticateCalls(stub func(*http.Request) (pkg.UserName, bool, error)) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = stub
+}
+
+func (fake *AdminAuth) AuthenticateArgsForCall(i int) *http.Request {
+	fake.authenticateMutex.RLock()
+	defer fake.authenticateMutex.RUnlock()
+	argsForCall := fake.authenticateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *AdminAuth) AuthenticateReturns(result1 pkg.UserName, result2 bool, result3 error) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = nil
+	fake.authenticateReturns = struct {
+		result1 pkg.UserName
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *AdminAuth) AuthenticateReturnsOnCall(i int, result1 pkg.UserName, result2 bool, result3 error) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = nil
+	if fake.authenticateReturnsOnCall == nil {
+		fake.authenticateReturnsOnCall = make(map[int]struct {
+			result1 pkg.UserName
+			result2 bool
+			result3 error
+		})
+	}
+	fake.authenticateReturnsOnCall[i] = struct {
+		result1 pkg.UserName
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *AdminAuth) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.authenticateMutex.RLock()
+	defer fake.authenticateMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *AdminAuth) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.AdminAuth = new(AdminAuth)
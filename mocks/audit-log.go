@@ -0,0 +1,79 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type AuditLog struct {
+	LogStub        func(context.Context, pkg.AuditEvent)
+	logMutex       sync.RWMutex
+	logArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.AuditEvent
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *AuditLog) Log(arg1 context.Context, arg2 pkg.AuditEvent) {
+	fake.logMutex.Lock()
+	fake.logArgsForCall = append(fake.logArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.AuditEvent
+	}{arg1, arg2})
+	stub := fake.LogStub
+	fake.recordInvocation("Log", []interface{}{arg1, arg2})
+	fake.logMutex.Unlock()
+	if stub != nil {
+		fake.LogStub(arg1, arg2)
+	}
+}
+
+func (fake *AuditLog) LogCallCount() int {
+	fake.logMutex.RLock()
+	defer fake.logMutex.RUnlock()
+	return len(fake.logArgsForCall)
+}
+
+func (fake *AuditLog) LogCalls(stub func(context.Context, pkg.AuditEvent)) {
+	fake.logMutex.Lock()
+	defer fake.logMutex.Unlock()
+	fake.LogStub = stub
+}
+
+func (fake *AuditLog) LogArgsForCall(i int) (context.Context, pkg.AuditEvent) {
+	fake.logMutex.RLock()
+	defer fake.logMutex.RUnlock()
+	argsForCall := fake.logArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *AuditLog) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.logMutex.RLock()
+	defer fake.logMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *AuditLog) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.AuditLog = new(AuditLog)
@@ -0,0 +1,325 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type CacheAuth struct {
+	EntriesStub        func() []pkg.CacheAuthEntry
+	entriesMutex       sync.RWMutex
+	entriesArgsForCall []struct {
+	}
+	entriesReturns struct {
+		result1 []pkg.CacheAuthEntry
+	}
+	entriesReturnsOnCall map[int]struct {
+		result1 []pkg.CacheAuthEntry
+	}
+	FlushStub        func(pkg.UserName) int
+	flushMutex       sync.RWMutex
+	flushArgsForCall []struct {
+		arg1 pkg.UserName
+	}
+	flushReturns struct {
+		result1 int
+	}
+	flushReturnsOnCall map[int]struct {
+		result1 int
+	}
+	FlushAllStub        func() int
+	flushAllMutex       sync.RWMutex
+	flushAllArgsForCall []struct {
+	}
+	flushAllReturns struct {
+		result1 int
+	}
+	flushAllReturnsOnCall map[int]struct {
+		result1 int
+	}
+	VerifyStub        func(context.Context, pkg.UserName, pkg.Password) (bool, error)
+	verifyMutex       sync.RWMutex
+	verifyArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+		arg3 pkg.Password
+	}
+	verifyReturns struct {
+		result1 bool
+		result2 error
+	}
+	verifyReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *CacheAuth) Entries() []pkg.CacheAuthEntry {
+	fake.entriesMutex.Lock()
+	ret, specificReturn := fake.entriesReturnsOnCall[len(fake.entriesArgsForCall)]
+	fake.entriesArgsForCall = append(fake.entriesArgsForCall, struct {
+	}{})
+	stub := fake.EntriesStub
+	fakeReturns := fake.entriesReturns
+	fake.recordInvocation("Entries", []interface{}{})
+	fake.entriesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CacheAuth) EntriesCallCount() int {
+	fake.entriesMutex.RLock()
+	defer fake.entriesMutex.RUnlock()
+	return len(fake.entriesArgsForCall)
+}
+
+func (fake *CacheAuth) EntriesCalls(stub func() []pkg.CacheAuthEntry) {
+	fake.entriesMutex.Lock()
+	defer fake.entriesMutex.Unlock()
+	fake.EntriesStub = stub
+}
+
+func (fake *CacheAuth) EntriesReturns(result1 []pkg.CacheAuthEntry) {
+	fake.entriesMutex.Lock()
+	defer fake.entriesMutex.Unlock()
+	fake.EntriesStub = nil
+	fake.entriesReturns = struct {
+		result1 []pkg.CacheAuthEntry
+	}{result1}
+}
+
+func (fake *CacheAuth) EntriesReturnsOnCall(i int, result1 []pkg.CacheAuthEntry) {
+	fake.entriesMutex.Lock()
+	defer fake.entriesMutex.Unlock()
+	fake.EntriesStub = nil
+	if fake.entriesReturnsOnCall == nil {
+		fake.entriesReturnsOnCall = make(map[int]struct {
+			result1 []pkg.CacheAuthEntry
+		})
+	}
+	fake.entriesReturnsOnCall[i] = struct {
+		result1 []pkg.CacheAuthEntry
+	}{result1}
+}
+
+func (fake *CacheAuth) Flush(arg1 pkg.UserName) int {
+	fake.flushMutex.Lock()
+	ret, specificReturn := fake.flushReturnsOnCall[len(fake.flushArgsForCall)]
+	fake.flushArgsForCall = append(fake.flushArgsForCall, struct {
+		arg1 pkg.UserName
+	}{arg1})
+	stub := fake.FlushStub
+	fakeReturns := fake.flushReturns
+	fake.recordInvocation("Flush", []interface{}{arg1})
+	fake.flushMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CacheAuth) FlushCallCount() int {
+	fake.flushMutex.RLock()
+	defer fake.flushMutex.RUnlock()
+	return len(fake.flushArgsForCall)
+}
+
+func (fake *CacheAuth) FlushCalls(stub func(pkg.UserName) int) {
+	fake.flushMutex.Lock()
+	defer fake.flushMutex.Unlock()
+	fake.FlushStub = stub
+}
+
+func (fake *CacheAuth) FlushArgsForCall(i int) pkg.UserName {
+	fake.flushMutex.RLock()
+	defer fake.flushMutex.RUnlock()
+	argsForCall := fake.flushArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *CacheAuth) FlushReturns(result1 int) {
+	fake.flushMutex.Lock()
+	defer fake.flushMutex.Unlock()
+	fake.FlushStub = nil
+	fake.flushReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *CacheAuth) FlushReturnsOnCall(i int, result1 int) {
+	fake.flushMutex.Lock()
+	defer fake.flushMutex.Unlock()
+	fake.FlushStub = nil
+	if fake.flushReturnsOnCall == nil {
+		fake.flushReturnsOnCall = make(map[int]struct {
+			result1 int
+		})
+	}
+	fake.flushReturnsOnCall[i] = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *CacheAuth) FlushAll() int {
+	fake.flushAllMutex.Lock()
+	ret, specificReturn := fake.flushAllReturnsOnCall[len(fake.flushAllArgsForCall)]
+	fake.flushAllArgsForCall = append(fake.flushAllArgsForCall, struct {
+	}{})
+	stub := fake.FlushAllStub
+	fakeReturns := fake.flushAllReturns
+	fake.recordInvocation("FlushAll", []interface{}{})
+	fake.flushAllMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CacheAuth) FlushAllCallCount() int {
+	fake.flushAllMutex.RLock()
+	defer fake.flushAllMutex.RUnlock()
+	return len(fake.flushAllArgsForCall)
+}
+
+func (fake *CacheAuth) FlushAllCalls(stub func() int) {
+	fake.flushAllMutex.Lock()
+	defer fake.flushAllMutex.Unlock()
+	fake.FlushAllStub = stub
+}
+
+func (fake *CacheAuth) FlushAllReturns(result1 int) {
+	fake.flushAllMutex.Lock()
+	defer fake.flushAllMutex.Unlock()
+	fake.FlushAllStub = nil
+	fake.flushAllReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *CacheAuth) FlushAllReturnsOnCall(i int, result1 int) {
+	fake.flushAllMutex.Lock()
+	defer fake.flushAllMutex.Unlock()
+	fake.FlushAllStub = nil
+	if fake.flushAllReturnsOnCall == nil {
+		fake.flushAllReturnsOnCall = make(map[int]struct {
+			result1 int
+		})
+	}
+	fake.flushAllReturnsOnCall[i] = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *CacheAuth) Verify(arg1 context.Context, arg2 pkg.UserName, arg3 pkg.Password) (bool, error) {
+	fake.verifyMutex.Lock()
+	ret, specificReturn := fake.verifyReturnsOnCall[len(fake.verifyArgsForCall)]
+	fake.verifyArgsForCall = append(fake.verifyArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+		arg3 pkg.Password
+	}{arg1, arg2, arg3})
+	stub := fake.VerifyStub
+	fakeReturns := fake.verifyReturns
+	fake.recordInvocation("Verify", []interface{}{arg1, arg2, arg3})
+	fake.verifyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *CacheAuth) VerifyCallCount() int {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	return len(fake.verifyArgsForCall)
+}
+
+func (fake *CacheAuth) VerifyCalls(stub func(context.Context, pkg.UserName, pkg.Password) (bool, error)) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = stub
+}
+
+func (fake *CacheAuth) VerifyArgsForCall(i int) (context.Context, pkg.UserName, pkg.Password) {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	argsForCall := fake.verifyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *CacheAuth) VerifyReturns(result1 bool, result2 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	fake.verifyReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *CacheAuth) VerifyReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	if fake.verifyReturnsOnCall == nil {
+		fake.verifyReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.verifyReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *CacheAuth) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.entriesMutex.RLock()
+	defer fake.entriesMutex.RUnlock()
+	fake.flushMutex.RLock()
+	defer fake.flushMutex.RUnlock()
+	fake.flushAllMutex.RLock()
+	defer fake.flushAllMutex.RUnlock()
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *CacheAuth) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.CacheAuth = new(CacheAuth)
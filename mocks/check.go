@@ -2,17 +2,19 @@
 package mocks
 
 import (
+	"context"
 	"sync"
 
 	"github.com/bborbe/auth-http-proxy/pkg"
 )
 
 type Check struct {
-	CheckStub        func(string, string) (bool, error)
+	CheckStub        func(context.Context, string, string) (bool, error)
 	checkMutex       sync.RWMutex
 	checkArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
+		arg3 string
 	}
 	checkReturns struct {
 		result1 bool
@@ -26,19 +28,20 @@ type Check struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *Check) Check(arg1 string, arg2 string) (bool, error) {
+func (fake *Check) Check(arg1 context.Context, arg2 string, arg3 string) (bool, error) {
 	fake.checkMutex.Lock()
 	ret, specificReturn := fake.checkReturnsOnCall[len(fake.checkArgsForCall)]
 	fake.checkArgsForCall = append(fake.checkArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-	}{arg1, arg2})
+		arg3 string
+	}{arg1, arg2, arg3})
 	stub := fake.CheckStub
 	fakeReturns := fake.checkReturns
-	fake.recordInvocation("Check", []interface{}{arg1, arg2})
+	fake.recordInvocation("Check", []interface{}{arg1, arg2, arg3})
 	fake.checkMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -52,17 +55,17 @@ func (fake *Check) CheckCallCount() int {
 	return len(fake.checkArgsForCall)
 }
 
-func (fake *Check) CheckCalls(stub func(string, string) (bool, error)) {
+func (fake *Check) CheckCalls(stub func(context.Context, string, string) (bool, error)) {
 	fake.checkMutex.Lock()
 	defer fake.checkMutex.Unlock()
 	fake.CheckStub = stub
 }
 
-func (fake *Check) CheckArgsForCall(i int) (string, string) {
+func (fake *Check) CheckArgsForCall(i int) (context.Context, string, string) {
 	fake.checkMutex.RLock()
 	defer fake.checkMutex.RUnlock()
 	argsForCall := fake.checkArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *Check) CheckReturns(result1 bool, result2 error) {
@@ -94,6 +97,8 @@ func (fake *Check) CheckReturnsOnCall(i int, result1 bool, result2 error) {
 func (fake *Check) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,187 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type ClientCertAuth struct {
+	AuthenticateStub        func(*http.Request) (*pkg.ClientCertUser, bool, error)
+	authenticateMutex       sync.RWMutex
+	authenticateArgsForCall []struct {
+		arg1 *http.Request
+	}
+	authenticateReturns struct {
+		result1 *pkg.ClientCertUser
+		result2 bool
+		result3 error
+	}
+	authenticateReturnsOnCall map[int]struct {
+		result1 *pkg.ClientCertUser
+		result2 bool
+		result3 error
+	}
+	SufficientStub        func() bool
+	sufficientMutex       sync.RWMutex
+	sufficientArgsForCall []struct {
+	}
+	sufficientReturns struct {
+		result1 bool
+	}
+	sufficientReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ClientCertAuth) Authenticate(arg1 *http.Request) (*pkg.ClientCertUser, bool, error) {
+	fake.authenticateMutex.Lock()
+	ret, specificReturn := fake.authenticateReturnsOnCall[len(fake.authenticateArgsForCall)]
+	fake.authenticateArgsForCall = append(fake.authenticateArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	stub := fake.AuthenticateStub
+	fakeReturns := fake.authenticateReturns
+	fake.recordInvocation("Authenticate", []interface{}{arg1})
+	fake.authenticateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *ClientCertAuth) AuthenticateCallCount() int {
+	fake.authenticateMutex.RLock()
+	defer fake.authenticateMutex.RUnlock()
+	return len(fake.authenticateArgsForCall)
+}
+
+func (fake *ClientCertAuth) AuthenticateCalls(stub func(*http.Request) (*pkg.ClientCertUser, bool, error)) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = stub
+}
+
+func (fake *ClientCertAuth) AuthenticateArgsForCall(i int) *http.Request {
+	fake.authenticateMutex.RLock()
+	defer fake.authenticateMutex.RUnlock()
+	argsForCall := fake.authenticateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *ClientCertAuth) AuthenticateReturns(result1 *pkg.ClientCertUser, result2 bool, result3 error) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = nil
+	fake.authenticateReturns = struct {
+		result1 *pkg.ClientCertUser
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *ClientCertAuth) AuthenticateReturnsOnCall(i int, result1 *pkg.ClientCertUser, result2 bool, result3 error) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = nil
+	if fake.authenticateReturnsOnCall == nil {
+		fake.authenticateReturnsOnCall = make(map[int]struct {
+			result1 *pkg.ClientCertUser
+			result2 bool
+			result3 error
+		})
+	}
+	fake.authenticateReturnsOnCall[i] = struct {
+		result1 *pkg.ClientCertUser
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *ClientCertAuth) Sufficient() bool {
+	fake.sufficientMutex.Lock()
+	ret, specificReturn := fake.sufficientReturnsOnCall[len(fake.sufficientArgsForCall)]
+	fake.sufficientArgsForCall = append(fake.sufficientArgsForCall, struct {
+	}{})
+	stub := fake.SufficientStub
+	fakeReturns := fake.sufficientReturns
+	fake.recordInvocation("Sufficient", []interface{}{})
+	fake.sufficientMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *ClientCertAuth) SufficientCallCount() int {
+	fake.sufficientMutex.RLock()
+	defer fake.sufficientMutex.RUnlock()
+	return len(fake.sufficientArgsForCall)
+}
+
+func (fake *ClientCertAuth) SufficientCalls(stub func() bool) {
+	fake.sufficientMutex.Lock()
+	defer fake.sufficientMutex.Unlock()
+	fake.SufficientStub = stub
+}
+
+func (fake *ClientCertAuth) SufficientReturns(result1 bool) {
+	fake.sufficientMutex.Lock()
+	defer fake.sufficientMutex.Unlock()
+	fake.SufficientStub = nil
+	fake.sufficientReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *ClientCertAuth) SufficientReturnsOnCall(i int, result1 bool) {
+	fake.sufficientMutex.Lock()
+	defer fake.sufficientMutex.Unlock()
+	fake.SufficientStub = nil
+	if fake.sufficientReturnsOnCall == nil {
+		fake.sufficientReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.sufficientReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *ClientCertAuth) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.authenticateMutex.RLock()
+	defer fake.authenticateMutex.RUnlock()
+	fake.sufficientMutex.RLock()
+	defer fake.sufficientMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ClientCertAuth) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.ClientCertAuth = new(ClientCertAuth)
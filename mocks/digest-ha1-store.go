@@ -0,0 +1,128 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type DigestHa1Store struct {
+	Ha1Stub        func(context.Context, pkg.UserName, string, pkg.DigestAlgorithm) (string, bool, error)
+	ha1Mutex       sync.RWMutex
+	ha1ArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+		arg3 string
+		arg4 pkg.DigestAlgorithm
+	}
+	ha1Returns struct {
+		result1 string
+		result2 bool
+		result3 error
+	}
+	ha1ReturnsOnCall map[int]struct {
+		result1 string
+		result2 bool
+		result3 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *DigestHa1Store) Ha1(arg1 context.Context, arg2 pkg.UserName, arg3 string, arg4 pkg.DigestAlgorithm) (string, bool, error) {
+	fake.ha1Mutex.Lock()
+	ret, specificReturn := fake.ha1ReturnsOnCall[len(fake.ha1ArgsForCall)]
+	fake.ha1ArgsForCall = append(fake.ha1ArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+		arg3 string
+		arg4 pkg.DigestAlgorithm
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.Ha1Stub
+	fakeReturns := fake.ha1Returns
+	fake.recordInvocation("Ha1", []interface{}{arg1, arg2, arg3, arg4})
+	fake.ha1Mutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *DigestHa1Store) Ha1CallCount() int {
+	fake.ha1Mutex.RLock()
+	defer fake.ha1Mutex.RUnlock()
+	return len(fake.ha1ArgsForCall)
+}
+
+func (fake *DigestHa1Store) Ha1Calls(stub func(context.Context, pkg.UserName, string, pkg.DigestAlgorithm) (string, bool, error)) {
+	fake.ha1Mutex.Lock()
+	defer fake.ha1Mutex.Unlock()
+	fake.Ha1Stub = stub
+}
+
+func (fake *DigestHa1Store) Ha1ArgsForCall(i int) (context.Context, pkg.UserName, string, pkg.DigestAlgorithm) {
+	fake.ha1Mutex.RLock()
+	defer fake.ha1Mutex.RUnlock()
+	argsForCall := fake.ha1ArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *DigestHa1Store) Ha1Returns(result1 string, result2 bool, result3 error) {
+	fake.ha1Mutex.Lock()
+	defer fake.ha1Mutex.Unlock()
+	fake.Ha1Stub = nil
+	fake.ha1Returns = struct {
+		result1 string
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *DigestHa1Store) Ha1ReturnsOnCall(i int, result1 string, result2 bool, result3 error) {
+	fake.ha1Mutex.Lock()
+	defer fake.ha1Mutex.Unlock()
+	fake.Ha1Stub = nil
+	if fake.ha1ReturnsOnCall == nil {
+		fake.ha1ReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 bool
+			result3 error
+		})
+	}
+	fake.ha1ReturnsOnCall[i] = struct {
+		result1 string
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *DigestHa1Store) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.ha1Mutex.RLock()
+	defer fake.ha1Mutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *DigestHa1Store) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.DigestHa1Store = new(DigestHa1Store)
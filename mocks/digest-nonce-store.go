@@ -0,0 +1,188 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type DigestNonceStore struct {
+	CreateStub        func() (string, error)
+	createMutex       sync.RWMutex
+	createArgsForCall []struct {
+	}
+	createReturns struct {
+		result1 string
+		result2 error
+	}
+	createReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	UseStub        func(string, uint64) (bool, bool)
+	useMutex       sync.RWMutex
+	useArgsForCall []struct {
+		arg1 string
+		arg2 uint64
+	}
+	useReturns struct {
+		result1 bool
+		result2 bool
+	}
+	useReturnsOnCall map[int]struct {
+		result1 bool
+		result2 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *DigestNonceStore) Create() (string, error) {
+	fake.createMutex.Lock()
+	ret, specificReturn := fake.createReturnsOnCall[len(fake.createArgsForCall)]
+	fake.createArgsForCall = append(fake.createArgsForCall, struct {
+	}{})
+	stub := fake.CreateStub
+	fakeReturns := fake.createReturns
+	fake.recordInvocation("Create", []interface{}{})
+	fake.createMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DigestNonceStore) CreateCallCount() int {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return len(fake.createArgsForCall)
+}
+
+func (fake *DigestNonceStore) CreateCalls(stub func() (string, error)) {
+	fake.createMutex.Lock()
+	defer fake.createMutex.Unlock()
+	fake.CreateStub = stub
+}
+
+func (fake *DigestNonceStore) CreateReturns(result1 string, result2 error) {
+	fake.createMutex.Lock()
+	defer fake.createMutex.Unlock()
+	fake.CreateStub = nil
+	fake.createReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DigestNonceStore) CreateReturnsOnCall(i int, result1 string, result2 error) {
+	fake.createMutex.Lock()
+	defer fake.createMutex.Unlock()
+	fake.CreateStub = nil
+	if fake.createReturnsOnCall == nil {
+		fake.createReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.createReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DigestNonceStore) Use(arg1 string, arg2 uint64) (bool, bool) {
+	fake.useMutex.Lock()
+	ret, specificReturn := fake.useReturnsOnCall[len(fake.useArgsForCall)]
+	fake.useArgsForCall = append(fake.useArgsForCall, struct {
+		arg1 string
+		arg2 uint64
+	}{arg1, arg2})
+	stub := fake.UseStub
+	fakeReturns := fake.useReturns
+	fake.recordInvocation("Use", []interface{}{arg1, arg2})
+	fake.useMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DigestNonceStore) UseCallCount() int {
+	fake.useMutex.RLock()
+	defer fake.useMutex.RUnlock()
+	return len(fake.useArgsForCall)
+}
+
+func (fake *DigestNonceStore) UseCalls(stub func(string, uint64) (bool, bool)) {
+	fake.useMutex.Lock()
+	defer fake.useMutex.Unlock()
+	fake.UseStub = stub
+}
+
+func (fake *DigestNonceStore) UseArgsForCall(i int) (string, uint64) {
+	fake.useMutex.RLock()
+	defer fake.useMutex.RUnlock()
+	argsForCall := fake.useArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *DigestNonceStore) UseReturns(result1 bool, result2 bool) {
+	fake.useMutex.Lock()
+	defer fake.useMutex.Unlock()
+	fake.UseStub = nil
+	fake.useReturns = struct {
+		result1 bool
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *DigestNonceStore) UseReturnsOnCall(i int, result1 bool, result2 bool) {
+	fake.useMutex.Lock()
+	defer fake.useMutex.Unlock()
+	fake.UseStub = nil
+	if fake.useReturnsOnCall == nil {
+		fake.useReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 bool
+		})
+	}
+	fake.useReturnsOnCall[i] = struct {
+		result1 bool
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *DigestNonceStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	fake.useMutex.RLock()
+	defer fake.useMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *DigestNonceStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.DigestNonceStore = new(DigestNonceStore)
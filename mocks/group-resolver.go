@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type GroupResolver struct {
+	GroupsOfUserStub        func(context.Context, pkg.UserName) (pkg.GroupNames, error)
+	groupsOfUserMutex       sync.RWMutex
+	groupsOfUserArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+	}
+	groupsOfUserReturns struct {
+		result1 pkg.GroupNames
+		result2 error
+	}
+	groupsOfUserReturnsOnCall map[int]struct {
+		result1 pkg.GroupNames
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *GroupResolver) GroupsOfUser(arg1 context.Context, arg2 pkg.UserName) (pkg.GroupNames, error) {
+	fake.groupsOfUserMutex.Lock()
+	ret, specificReturn := fake.groupsOfUserReturnsOnCall[len(fake.groupsOfUserArgsForCall)]
+	fake.groupsOfUserArgsForCall = append(fake.groupsOfUserArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+	}{arg1, arg2})
+	stub := fake.GroupsOfUserStub
+	fakeReturns := fake.groupsOfUserReturns
+	fake.recordInvocation("GroupsOfUser", []interface{}{arg1, arg2})
+	fake.groupsOfUserMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *GroupResolver) GroupsOfUserCallCount() int {
+	fake.groupsOfUserMutex.RLock()
+	defer fake.groupsOfUserMutex.RUnlock()
+	return len(fake.groupsOfUserArgsForCall)
+}
+
+func (fake *GroupResolver) GroupsOfUserCalls(stub func(context.Context, pkg.UserName) (pkg.GroupNames, error)) {
+	fake.groupsOfUserMutex.Lock()
+	defer fake.groupsOfUserMutex.Unlock()
+	fake.GroupsOfUserStub = stub
+}
+
+func (fake *GroupResolver) GroupsOfUserArgsForCall(i int) (context.Context, pkg.UserName) {
+	fake.groupsOfUserMutex.RLock()
+	defer fake.groupsOfUserMutex.RUnlock()
+	argsForCall := fake.groupsOfUserArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *GroupResolver) GroupsOfUserReturns(result1 pkg.GroupNames, result2 error) {
+	fake.groupsOfUserMutex.Lock()
+	defer fake.groupsOfUserMutex.Unlock()
+	fake.GroupsOfUserStub = nil
+	fake.groupsOfUserReturns = struct {
+		result1 pkg.GroupNames
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *GroupResolver) GroupsOfUserReturnsOnCall(i int, result1 pkg.GroupNames, result2 error) {
+	fake.groupsOfUserMutex.Lock()
+	defer fake.groupsOfUserMutex.Unlock()
+	fake.GroupsOfUserStub = nil
+	if fake.groupsOfUserReturnsOnCall == nil {
+		fake.groupsOfUserReturnsOnCall = make(map[int]struct {
+			result1 pkg.GroupNames
+			result2 error
+		})
+	}
+	fake.groupsOfUserReturnsOnCall[i] = struct {
+		result1 pkg.GroupNames
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *GroupResolver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.groupsOfUserMutex.RLock()
+	defer fake.groupsOfUserMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *GroupResolver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.GroupResolver = new(GroupResolver)
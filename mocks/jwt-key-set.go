@@ -0,0 +1,120 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"crypto"
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type JwtKeySet struct {
+	KeysStub        func(context.Context, string) ([]crypto.PublicKey, error)
+	keysMutex       sync.RWMutex
+	keysArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	keysReturns struct {
+		result1 []crypto.PublicKey
+		result2 error
+	}
+	keysReturnsOnCall map[int]struct {
+		result1 []crypto.PublicKey
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *JwtKeySet) Keys(arg1 context.Context, arg2 string) ([]crypto.PublicKey, error) {
+	fake.keysMutex.Lock()
+	ret, specificReturn := fake.keysReturnsOnCall[len(fake.keysArgsForCall)]
+	fake.keysArgsForCall = append(fake.keysArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.KeysStub
+	fakeReturns := fake.keysReturns
+	fake.recordInvocation("Keys", []interface{}{arg1, arg2})
+	fake.keysMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *JwtKeySet) KeysCallCount() int {
+	fake.keysMutex.RLock()
+	defer fake.keysMutex.RUnlock()
+	return len(fake.keysArgsForCall)
+}
+
+func (fake *JwtKeySet) KeysCalls(stub func(context.Context, string) ([]crypto.PublicKey, error)) {
+	fake.keysMutex.Lock()
+	defer fake.keysMutex.Unlock()
+	fake.KeysStub = stub
+}
+
+func (fake *JwtKeySet) KeysArgsForCall(i int) (context.Context, string) {
+	fake.keysMutex.RLock()
+	defer fake.keysMutex.RUnlock()
+	argsForCall := fake.keysArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *JwtKeySet) KeysReturns(result1 []crypto.PublicKey, result2 error) {
+	fake.keysMutex.Lock()
+	defer fake.keysMutex.Unlock()
+	fake.KeysStub = nil
+	fake.keysReturns = struct {
+		result1 []crypto.PublicKey
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *JwtKeySet) KeysReturnsOnCall(i int, result1 []crypto.PublicKey, result2 error) {
+	fake.keysMutex.Lock()
+	defer fake.keysMutex.Unlock()
+	fake.KeysStub = nil
+	if fake.keysReturnsOnCall == nil {
+		fake.keysReturnsOnCall = make(map[int]struct {
+			result1 []crypto.PublicKey
+			result2 error
+		})
+	}
+	fake.keysReturnsOnCall[i] = struct {
+		result1 []crypto.PublicKey
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *JwtKeySet) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.keysMutex.RLock()
+	defer fake.keysMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *JwtKeySet) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.JwtKeySet = new(JwtKeySet)
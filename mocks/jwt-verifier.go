@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type JwtVerifier struct {
+	VerifyTokenStub        func(context.Context, string) (*pkg.JwtUser, error)
+	verifyTokenMutex       sync.RWMutex
+	verifyTokenArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	verifyTokenReturns struct {
+		result1 *pkg.JwtUser
+		result2 error
+	}
+	verifyTokenReturnsOnCall map[int]struct {
+		result1 *pkg.JwtUser
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *JwtVerifier) VerifyToken(arg1 context.Context, arg2 string) (*pkg.JwtUser, error) {
+	fake.verifyTokenMutex.Lock()
+	ret, specificReturn := fake.verifyTokenReturnsOnCall[len(fake.verifyTokenArgsForCall)]
+	fake.verifyTokenArgsForCall = append(fake.verifyTokenArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.VerifyTokenStub
+	fakeReturns := fake.verifyTokenReturns
+	fake.recordInvocation("VerifyToken", []interface{}{arg1, arg2})
+	fake.verifyTokenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *JwtVerifier) VerifyTokenCallCount() int {
+	fake.verifyTokenMutex.RLock()
+	defer fake.verifyTokenMutex.RUnlock()
+	return len(fake.verifyTokenArgsForCall)
+}
+
+func (fake *JwtVerifier) VerifyTokenCalls(stub func(context.Context, string) (*pkg.JwtUser, error)) {
+	fake.verifyTokenMutex.Lock()
+	defer fake.verifyTokenMutex.Unlock()
+	fake.VerifyTokenStub = stub
+}
+
+func (fake *JwtVerifier) VerifyTokenArgsForCall(i int) (context.Context, string) {
+	fake.verifyTokenMutex.RLock()
+	defer fake.verifyTokenMutex.RUnlock()
+	argsForCall := fake.verifyTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *JwtVerifier) VerifyTokenReturns(result1 *pkg.JwtUser, result2 error) {
+	fake.verifyTokenMutex.Lock()
+	defer fake.verifyTokenMutex.Unlock()
+	fake.VerifyTokenStub = nil
+	fake.verifyTokenReturns = struct {
+		result1 *pkg.JwtUser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *JwtVerifier) VerifyTokenReturnsOnCall(i int, result1 *pkg.JwtUser, result2 error) {
+	fake.verifyTokenMutex.Lock()
+	defer fake.verifyTokenMutex.Unlock()
+	fake.VerifyTokenStub = nil
+	if fake.verifyTokenReturnsOnCall == nil {
+		fake.verifyTokenReturnsOnCall = make(map[int]struct {
+			result1 *pkg.JwtUser
+			result2 error
+		})
+	}
+	fake.verifyTokenReturnsOnCall[i] = struct {
+		result1 *pkg.JwtUser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *JwtVerifier) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.verifyTokenMutex.RLock()
+	defer fake.verifyTokenMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *JwtVerifier) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.JwtVerifier = new(JwtVerifier)
@@ -0,0 +1,303 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type LoginThrottle struct {
+	ClearStub        func(pkg.UserName, net.IP)
+	clearMutex       sync.RWMutex
+	clearArgsForCall []struct {
+		arg1 pkg.UserName
+		arg2 net.IP
+	}
+	FailureStub        func(pkg.UserName, net.IP)
+	failureMutex       sync.RWMutex
+	failureArgsForCall []struct {
+		arg1 pkg.UserName
+		arg2 net.IP
+	}
+	LockoutsStub        func() []pkg.LoginLockoutEntry
+	lockoutsMutex       sync.RWMutex
+	lockoutsArgsForCall []struct {
+	}
+	lockoutsReturns struct {
+		result1 []pkg.LoginLockoutEntry
+	}
+	lockoutsReturnsOnCall map[int]struct {
+		result1 []pkg.LoginLockoutEntry
+	}
+	RetryAfterStub        func(pkg.UserName, net.IP) time.Duration
+	retryAfterMutex       sync.RWMutex
+	retryAfterArgsForCall []struct {
+		arg1 pkg.UserName
+		arg2 net.IP
+	}
+	retryAfterReturns struct {
+		result1 time.Duration
+	}
+	retryAfterReturnsOnCall map[int]struct {
+		result1 time.Duration
+	}
+	SuccessStub        func(pkg.UserName, net.IP)
+	successMutex       sync.RWMutex
+	successArgsForCall []struct {
+		arg1 pkg.UserName
+		arg2 net.IP
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *LoginThrottle) Clear(arg1 pkg.UserName, arg2 net.IP) {
+	fake.clearMutex.Lock()
+	fake.clearArgsForCall = append(fake.clearArgsForCall, struct {
+		arg1 pkg.UserName
+		arg2 net.IP
+	}{arg1, arg2})
+	stub := fake.ClearStub
+	fake.recordInvocation("Clear", []interface{}{arg1, arg2})
+	fake.clearMutex.Unlock()
+	if stub != nil {
+		fake.ClearStub(arg1, arg2)
+	}
+}
+
+func (fake *LoginThrottle) ClearCallCount() int {
+	fake.clearMutex.RLock()
+	defer fake.clearMutex.RUnlock()
+	return len(fake.clearArgsForCall)
+}
+
+func (fake *LoginThrottle) ClearCalls(stub func(pkg.UserName, net.IP)) {
+	fake.clearMutex.Lock()
+	defer fake.clearMutex.Unlock()
+	fake.ClearStub = stub
+}
+
+func (fake *LoginThrottle) ClearArgsForCall(i int) (pkg.UserName, net.IP) {
+	fake.clearMutex.RLock()
+	defer fake.clearMutex.RUnlock()
+	argsForCall := fake.clearArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *LoginThrottle) Failure(arg1 pkg.UserName, arg2 net.IP) {
+	fake.failureMutex.Lock()
+	fake.failureArgsForCall = append(fake.failureArgsForCall, struct {
+		arg1 pkg.UserName
+		arg2 net.IP
+	}{arg1, arg2})
+	stub := fake.FailureStub
+	fake.recordInvocation("Failure", []interface{}{arg1, arg2})
+	fake.failureMutex.Unlock()
+	if stub != nil {
+		fake.FailureStub(arg1, arg2)
+	}
+}
+
+func (fake *LoginThrottle) FailureCallCount() int {
+	fake.failureMutex.RLock()
+	defer fake.failureMutex.RUnlock()
+	return len(fake.failureArgsForCall)
+}
+
+func (fake *LoginThrottle) FailureCalls(stub func(pkg.UserName, net.IP)) {
+	fake.failureMutex.Lock()
+	defer fake.failureMutex.Unlock()
+	fake.FailureStub = stub
+}
+
+func (fake *LoginThrottle) FailureArgsForCall(i int) (pkg.UserName, net.IP) {
+	fake.failureMutex.RLock()
+	defer fake.failureMutex.RUnlock()
+	argsForCall := fake.failureArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *LoginThrottle) Lockouts() []pkg.LoginLockoutEntry {
+	fake.lockoutsMutex.Lock()
+	ret, specificReturn := fake.lockoutsReturnsOnCall[len(fake.lockoutsArgsForCall)]
+	fake.lockoutsArgsForCall = append(fake.lockoutsArgsForCall, struct {
+	}{})
+	stub := fake.LockoutsStub
+	fakeReturns := fake.lockoutsReturns
+	fake.recordInvocation("Lockouts", []interface{}{})
+	fake.lockoutsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *LoginThrottle) LockoutsCallCount() int {
+	fake.lockoutsMutex.RLock()
+	defer fake.lockoutsMutex.RUnlock()
+	return len(fake.lockoutsArgsForCall)
+}
+
+func (fake *LoginThrottle) LockoutsCalls(stub func() []pkg.LoginLockoutEntry) {
+	fake.lockoutsMutex.Lock()
+	defer fake.lockoutsMutex.Unlock()
+	fake.LockoutsStub = stub
+}
+
+func (fake *LoginThrottle) LockoutsReturns(result1 []pkg.LoginLockoutEntry) {
+	fake.lockoutsMutex.Lock()
+	defer fake.lockoutsMutex.Unlock()
+	fake.LockoutsStub = nil
+	fake.lockoutsReturns = struct {
+		result1 []pkg.LoginLockoutEntry
+	}{result1}
+}
+
+func (fake *LoginThrottle) LockoutsReturnsOnCall(i int, result1 []pkg.LoginLockoutEntry) {
+	fake.lockoutsMutex.Lock()
+	defer fake.lockoutsMutex.Unlock()
+	fake.LockoutsStub = nil
+	if fake.lockoutsReturnsOnCall == nil {
+		fake.lockoutsReturnsOnCall = make(map[int]struct {
+			result1 []pkg.LoginLockoutEntry
+		})
+	}
+	fake.lockoutsReturnsOnCall[i] = struct {
+		result1 []pkg.LoginLockoutEntry
+	}{result1}
+}
+
+func (fake *LoginThrottle) RetryAfter(arg1 pkg.UserName, arg2 net.IP) time.Duration {
+	fake.retryAfterMutex.Lock()
+	ret, specificReturn := fake.retryAfterReturnsOnCall[len(fake.retryAfterArgsForCall)]
+	fake.retryAfterArgsForCall = append(fake.retryAfterArgsForCall, struct {
+		arg1 pkg.UserName
+		arg2 net.IP
+	}{arg1, arg2})
+	stub := fake.RetryAfterStub
+	fakeReturns := fake.retryAfterReturns
+	fake.recordInvocation("RetryAfter", []interface{}{arg1, arg2})
+	fake.retryAfterMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *LoginThrottle) RetryAfterCallCount() int {
+	fake.retryAfterMutex.RLock()
+	defer fake.retryAfterMutex.RUnlock()
+	return len(fake.retryAfterArgsForCall)
+}
+
+func (fake *LoginThrottle) RetryAfterCalls(stub func(pkg.UserName, net.IP) time.Duration) {
+	fake.retryAfterMutex.Lock()
+	defer fake.retryAfterMutex.Unlock()
+	fake.RetryAfterStub = stub
+}
+
+func (fake *LoginThrottle) RetryAfterArgsForCall(i int) (pkg.UserName, net.IP) {
+	fake.retryAfterMutex.RLock()
+	defer fake.retryAfterMutex.RUnlock()
+	argsForCall := fake.retryAfterArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *LoginThrottle) RetryAfterReturns(result1 time.Duration) {
+	fake.retryAfterMutex.Lock()
+	defer fake.retryAfterMutex.Unlock()
+	fake.RetryAfterStub = nil
+	fake.retryAfterReturns = struct {
+		result1 time.Duration
+	}{result1}
+}
+
+func (fake *LoginThrottle) RetryAfterReturnsOnCall(i int, result1 time.Duration) {
+	fake.retryAfterMutex.Lock()
+	defer fake.retryAfterMutex.Unlock()
+	fake.RetryAfterStub = nil
+	if fake.retryAfterReturnsOnCall == nil {
+		fake.retryAfterReturnsOnCall = make(map[int]struct {
+			result1 time.Duration
+		})
+	}
+	fake.retryAfterReturnsOnCall[i] = struct {
+		result1 time.Duration
+	}{result1}
+}
+
+func (fake *LoginThrottle) Success(arg1 pkg.UserName, arg2 net.IP) {
+	fake.successMutex.Lock()
+	fake.successArgsForCall = append(fake.successArgsForCall, struct {
+		arg1 pkg.UserName
+		arg2 net.IP
+	}{arg1, arg2})
+	stub := fake.SuccessStub
+	fake.recordInvocation("Success", []interface{}{arg1, arg2})
+	fake.successMutex.Unlock()
+	if stub != nil {
+		fake.SuccessStub(arg1, arg2)
+	}
+}
+
+func (fake *LoginThrottle) SuccessCallCount() int {
+	fake.successMutex.RLock()
+	defer fake.successMutex.RUnlock()
+	return len(fake.successArgsForCall)
+}
+
+func (fake *LoginThrottle) SuccessCalls(stub func(pkg.UserName, net.IP)) {
+	fake.successMutex.Lock()
+	defer fake.successMutex.Unlock()
+	fake.SuccessStub = stub
+}
+
+func (fake *LoginThrottle) SuccessArgsForCall(i int) (pkg.UserName, net.IP) {
+	fake.successMutex.RLock()
+	defer fake.successMutex.RUnlock()
+	argsForCall := fake.successArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *LoginThrottle) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.clearMutex.RLock()
+	defer fake.clearMutex.RUnlock()
+	fake.failureMutex.RLock()
+	defer fake.failureMutex.RUnlock()
+	fake.lockoutsMutex.RLock()
+	defer fake.lockoutsMutex.RUnlock()
+	fake.retryAfterMutex.RLock()
+	defer fake.retryAfterMutex.RUnlock()
+	fake.successMutex.RLock()
+	defer fake.successMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *LoginThrottle) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.LoginThrottle = new(LoginThrottle)
@@ -0,0 +1,112 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type RateLimiter struct {
+	TakeStub        func(*http.Request) pkg.RateLimitResult
+	takeMutex       sync.RWMutex
+	takeArgsForCall []struct {
+		arg1 *http.Request
+	}
+	takeReturns struct {
+		result1 pkg.RateLimitResult
+	}
+	takeReturnsOnCall map[int]struct {
+		result1 pkg.RateLimitResult
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *RateLimiter) Take(arg1 *http.Request) pkg.RateLimitResult {
+	fake.takeMutex.Lock()
+	ret, specificReturn := fake.takeReturnsOnCall[len(fake.takeArgsForCall)]
+	fake.takeArgsForCall = append(fake.takeArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	stub := fake.TakeStub
+	fakeReturns := fake.takeReturns
+	fake.recordInvocation("Take", []interface{}{arg1})
+	fake.takeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *RateLimiter) TakeCallCount() int {
+	fake.takeMutex.RLock()
+	defer fake.takeMutex.RUnlock()
+	return len(fake.takeArgsForCall)
+}
+
+func (fake *RateLimiter) TakeCalls(stub func(*http.Request) pkg.RateLimitResult) {
+	fake.takeMutex.Lock()
+	defer fake.takeMutex.Unlock()
+	fake.TakeStub = stub
+}
+
+func (fake *RateLimiter) TakeArgsForCall(i int) *http.Request {
+	fake.takeMutex.RLock()
+	defer fake.takeMutex.RUnlock()
+	argsForCall := fake.takeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *RateLimiter) TakeReturns(result1 pkg.RateLimitResult) {
+	fake.takeMutex.Lock()
+	defer fake.takeMutex.Unlock()
+	fake.TakeStub = nil
+	fake.takeReturns = struct {
+		result1 pkg.RateLimitResult
+	}{result1}
+}
+
+func (fake *RateLimiter) TakeReturnsOnCall(i int, result1 pkg.RateLimitResult) {
+	fake.takeMutex.Lock()
+	defer fake.takeMutex.Unlock()
+	fake.TakeStub = nil
+	if fake.takeReturnsOnCall == nil {
+		fake.takeReturnsOnCall = make(map[int]struct {
+			result1 pkg.RateLimitResult
+		})
+	}
+	fake.takeReturnsOnCall[i] = struct {
+		result1 pkg.RateLimitResult
+	}{result1}
+}
+
+func (fake *RateLimiter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.takeMutex.RLock()
+	defer fake.takeMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *RateLimiter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.RateLimiter = new(RateLimiter)
@@ -0,0 +1,209 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type ReasonVerifier struct {
+	VerifyStub        func(context.Context, pkg.UserName, pkg.Password) (bool, error)
+	verifyMutex       sync.RWMutex
+	verifyArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+		arg3 pkg.Password
+	}
+	verifyReturns struct {
+		result1 bool
+		result2 error
+	}
+	verifyReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	VerifyWithReasonStub        func(context.Context, pkg.UserName, pkg.Password) (bool, pkg.AuditReason, error)
+	verifyWithReasonMutex       sync.RWMutex
+	verifyWithReasonArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+		arg3 pkg.Password
+	}
+	verifyWithReasonReturns struct {
+		result1 bool
+		result2 pkg.AuditReason
+		result3 error
+	}
+	verifyWithReasonReturnsOnCall map[int]struct {
+		result1 bool
+		result2 pkg.AuditReason
+		result3 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ReasonVerifier) Verify(arg1 context.Context, arg2 pkg.UserName, arg3 pkg.Password) (bool, error) {
+	fake.verifyMutex.Lock()
+	ret, specificReturn := fake.verifyReturnsOnCall[len(fake.verifyArgsForCall)]
+	fake.verifyArgsForCall = append(fake.verifyArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+		arg3 pkg.Password
+	}{arg1, arg2, arg3})
+	stub := fake.VerifyStub
+	fakeReturns := fake.verifyReturns
+	fake.recordInvocation("Verify", []interface{}{arg1, arg2, arg3})
+	fake.verifyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReasonVerifier) VerifyCallCount() int {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	return len(fake.verifyArgsForCall)
+}
+
+func (fake *ReasonVerifier) VerifyCalls(stub func(context.Context, pkg.UserName, pkg.Password) (bool, error)) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = stub
+}
+
+func (fake *ReasonVerifier) VerifyArgsForCall(i int) (context.Context, pkg.UserName, pkg.Password) {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	argsForCall := fake.verifyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *ReasonVerifier) VerifyReturns(result1 bool, result2 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	fake.verifyReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReasonVerifier) VerifyReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	if fake.verifyReturnsOnCall == nil {
+		fake.verifyReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.verifyReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReasonVerifier) VerifyWithReason(arg1 context.Context, arg2 pkg.UserName, arg3 pkg.Password) (bool, pkg.AuditReason, error) {
+	fake.verifyWithReasonMutex.Lock()
+	ret, specificReturn := fake.verifyWithReasonReturnsOnCall[len(fake.verifyWithReasonArgsForCall)]
+	fake.verifyWithReasonArgsForCall = append(fake.verifyWithReasonArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+		arg3 pkg.Password
+	}{arg1, arg2, arg3})
+	stub := fake.VerifyWithReasonStub
+	fakeReturns := fake.verifyWithReasonReturns
+	fake.recordInvocation("VerifyWithReason", []interface{}{arg1, arg2, arg3})
+	fake.verifyWithReasonMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *ReasonVerifier) VerifyWithReasonCallCount() int {
+	fake.verifyWithReasonMutex.RLock()
+	defer fake.verifyWithReasonMutex.RUnlock()
+	return len(fake.verifyWithReasonArgsForCall)
+}
+
+func (fake *ReasonVerifier) VerifyWithReasonCalls(stub func(context.Context, pkg.UserName, pkg.Password) (bool, pkg.AuditReason, error)) {
+	fake.verifyWithReasonMutex.Lock()
+	defer fake.verifyWithReasonMutex.Unlock()
+	fake.VerifyWithReasonStub = stub
+}
+
+func (fake *ReasonVerifier) VerifyWithReasonArgsForCall(i int) (context.Context, pkg.UserName, pkg.Password) {
+	fake.verifyWithReasonMutex.RLock()
+	defer fake.verifyWithReasonMutex.RUnlock()
+	argsForCall := fake.verifyWithReasonArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *ReasonVerifier) VerifyWithReasonReturns(result1 bool, result2 pkg.AuditReason, result3 error) {
+	fake.verifyWithReasonMutex.Lock()
+	defer fake.verifyWithReasonMutex.Unlock()
+	fake.VerifyWithReasonStub = nil
+	fake.verifyWithReasonReturns = struct {
+		result1 bool
+		result2 pkg.AuditReason
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *ReasonVerifier) VerifyWithReasonReturnsOnCall(i int, result1 bool, result2 pkg.AuditReason, result3 error) {
+	fake.verifyWithReasonMutex.Lock()
+	defer fake.verifyWithReasonMutex.Unlock()
+	fake.VerifyWithReasonStub = nil
+	if fake.verifyWithReasonReturnsOnCall == nil {
+		fake.verifyWithReasonReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 pkg.AuditReason
+			result3 error
+		})
+	}
+	fake.verifyWithReasonReturnsOnCall[i] = struct {
+		result1 bool
+		result2 pkg.AuditReason
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *ReasonVerifier) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	fake.verifyWithReasonMutex.RLock()
+	defer fake.verifyWithReasonMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ReasonVerifier) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.ReasonVerifier = new(ReasonVerifier)
@@ -0,0 +1,411 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type SessionStore struct {
+	CreateStub        func(pkg.UserName, net.IP, time.Time) (*pkg.Session, error)
+	createMutex       sync.RWMutex
+	createArgsForCall []struct {
+		arg1 pkg.UserName
+		arg2 net.IP
+		arg3 time.Time
+	}
+	createReturns struct {
+		result1 *pkg.Session
+		result2 error
+	}
+	createReturnsOnCall map[int]struct {
+		result1 *pkg.Session
+		result2 error
+	}
+	RevokeStub        func(string) bool
+	revokeMutex       sync.RWMutex
+	revokeArgsForCall []struct {
+		arg1 string
+	}
+	revokeReturns struct {
+		result1 bool
+	}
+	revokeReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	RevokeUserStub        func(pkg.UserName) int
+	revokeUserMutex       sync.RWMutex
+	revokeUserArgsForCall []struct {
+		arg1 pkg.UserName
+	}
+	revokeUserReturns struct {
+		result1 int
+	}
+	revokeUserReturnsOnCall map[int]struct {
+		result1 int
+	}
+	SessionsStub        func() []pkg.Session
+	sessionsMutex       sync.RWMutex
+	sessionsArgsForCall []struct {
+	}
+	sessionsReturns struct {
+		result1 []pkg.Session
+	}
+	sessionsReturnsOnCall map[int]struct {
+		result1 []pkg.Session
+	}
+	ValidStub        func(pkg.Session, net.IP) bool
+	validMutex       sync.RWMutex
+	validArgsForCall []struct {
+		arg1 pkg.Session
+		arg2 net.IP
+	}
+	validReturns struct {
+		result1 bool
+	}
+	validReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *SessionStore) Create(arg1 pkg.UserName, arg2 net.IP, arg3 time.Time) (*pkg.Session, error) {
+	fake.createMutex.Lock()
+	ret, specificReturn := fake.createReturnsOnCall[len(fake.createArgsForCall)]
+	fake.createArgsForCall = append(fake.createArgsForCall, struct {
+		arg1 pkg.UserName
+		arg2 net.IP
+		arg3 time.Time
+	}{arg1, arg2, arg3})
+	stub := fake.CreateStub
+	fakeReturns := fake.createReturns
+	fake.recordInvocation("Create", []interface{}{arg1, arg2, arg3})
+	fake.createMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SessionStore) CreateCallCount() int {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return len(fake.createArgsForCall)
+}
+
+func (fake *SessionStore) CreateCalls(stub func(pkg.UserName, net.IP, time.Time) (*pkg.Session, error)) {
+	fake.createMutex.Lock()
+	defer fake.createMutex.Unlock()
+	fake.CreateStub = stub
+}
+
+func (fake *SessionStore) CreateArgsForCall(i int) (pkg.UserName, net.IP, time.Time) {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	argsForCall := fake.createArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *SessionStore) CreateReturns(result1 *pkg.Session, result2 error) {
+	fake.createMutex.Lock()
+	defer fake.createMutex.Unlock()
+	fake.CreateStub = nil
+	fake.createReturns = struct {
+		result1 *pkg.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) CreateReturnsOnCall(i int, result1 *pkg.Session, result2 error) {
+	fake.createMutex.Lock()
+	defer fake.createMutex.Unlock()
+	fake.CreateStub = nil
+	if fake.createReturnsOnCall == nil {
+		fake.createReturnsOnCall = make(map[int]struct {
+			result1 *pkg.Session
+			result2 error
+		})
+	}
+	fake.createReturnsOnCall[i] = struct {
+		result1 *pkg.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) Revoke(arg1 string) bool {
+	fake.revokeMutex.Lock()
+	ret, specificReturn := fake.revokeReturnsOnCall[len(fake.revokeArgsForCall)]
+	fake.revokeArgsForCall = append(fake.revokeArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.RevokeStub
+	fakeReturns := fake.revokeReturns
+	fake.recordInvocation("Revoke", []interface{}{arg1})
+	fake.revokeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SessionStore) RevokeCallCount() int {
+	fake.revokeMutex.RLock()
+	defer fake.revokeMutex.RUnlock()
+	return len(fake.revokeArgsForCall)
+}
+
+func (fake *SessionStore) RevokeCalls(stub func(string) bool) {
+	fake.revokeMutex.Lock()
+	defer fake.revokeMutex.Unlock()
+	fake.RevokeStub = stub
+}
+
+func (fake *SessionStore) RevokeArgsForCall(i int) string {
+	fake.revokeMutex.RLock()
+	defer fake.revokeMutex.RUnlock()
+	argsForCall := fake.revokeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *SessionStore) RevokeReturns(result1 bool) {
+	fake.revokeMutex.Lock()
+	defer fake.revokeMutex.Unlock()
+	fake.RevokeStub = nil
+	fake.revokeReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *SessionStore) RevokeReturnsOnCall(i int, result1 bool) {
+	fake.revokeMutex.Lock()
+	defer fake.revokeMutex.Unlock()
+	fake.RevokeStub = nil
+	if fake.revokeReturnsOnCall == nil {
+		fake.revokeReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.revokeReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *SessionStore) RevokeUser(arg1 pkg.UserName) int {
+	fake.revokeUserMutex.Lock()
+	ret, specificReturn := fake.revokeUserReturnsOnCall[len(fake.revokeUserArgsForCall)]
+	fake.revokeUserArgsForCall = append(fake.revokeUserArgsForCall, struct {
+		arg1 pkg.UserName
+	}{arg1})
+	stub := fake.RevokeUserStub
+	fakeReturns := fake.revokeUserReturns
+	fake.recordInvocation("RevokeUser", []interface{}{arg1})
+	fake.revokeUserMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SessionStore) RevokeUserCallCount() int {
+	fake.revokeUserMutex.RLock()
+	defer fake.revokeUserMutex.RUnlock()
+	return len(fake.revokeUserArgsForCall)
+}
+
+func (fake *SessionStore) RevokeUserCalls(stub func(pkg.UserName) int) {
+	fake.revokeUserMutex.Lock()
+	defer fake.revokeUserMutex.Unlock()
+	fake.RevokeUserStub = stub
+}
+
+func (fake *SessionStore) RevokeUserArgsForCall(i int) pkg.UserName {
+	fake.revokeUserMutex.RLock()
+	defer fake.revokeUserMutex.RUnlock()
+	argsForCall := fake.revokeUserArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *SessionStore) RevokeUserReturns(result1 int) {
+	fake.revokeUserMutex.Lock()
+	defer fake.revokeUserMutex.Unlock()
+	fake.RevokeUserStub = nil
+	fake.revokeUserReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *SessionStore) RevokeUserReturnsOnCall(i int, result1 int) {
+	fake.revokeUserMutex.Lock()
+	defer fake.revokeUserMutex.Unlock()
+	fake.RevokeUserStub = nil
+	if fake.revokeUserReturnsOnCall == nil {
+		fake.revokeUserReturnsOnCall = make(map[int]struct {
+			result1 int
+		})
+	}
+	fake.revokeUserReturnsOnCall[i] = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *SessionStore) Sessions() []pkg.Session {
+	fake.sessionsMutex.Lock()
+	ret, specificReturn := fake.sessionsReturnsOnCall[len(fake.sessionsArgsForCall)]
+	fake.sessionsArgsForCall = append(fake.sessionsArgsForCall, struct {
+	}{})
+	stub := fake.SessionsStub
+	fakeReturns := fake.sessionsReturns
+	fake.recordInvocation("Sessions", []interface{}{})
+	fake.sessionsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SessionStore) SessionsCallCount() int {
+	fake.sessionsMutex.RLock()
+	defer fake.sessionsMutex.RUnlock()
+	return len(fake.sessionsArgsForCall)
+}
+
+func (fake *SessionStore) SessionsCalls(stub func() []pkg.Session) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = stub
+}
+
+func (fake *SessionStore) SessionsReturns(result1 []pkg.Session) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = nil
+	fake.sessionsReturns = struct {
+		result1 []pkg.Session
+	}{result1}
+}
+
+func (fake *SessionStore) SessionsReturnsOnCall(i int, result1 []pkg.Session) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = nil
+	if fake.sessionsReturnsOnCall == nil {
+		fake.sessionsReturnsOnCall = make(map[int]struct {
+			result1 []pkg.Session
+		})
+	}
+	fake.sessionsReturnsOnCall[i] = struct {
+		result1 []pkg.Session
+	}{result1}
+}
+
+func (fake *SessionStore) Valid(arg1 pkg.Session, arg2 net.IP) bool {
+	fake.validMutex.Lock()
+	ret, specificReturn := fake.validReturnsOnCall[len(fake.validArgsForCall)]
+	fake.validArgsForCall = append(fake.validArgsForCall, struct {
+		arg1 pkg.Session
+		arg2 net.IP
+	}{arg1, arg2})
+	stub := fake.ValidStub
+	fakeReturns := fake.validReturns
+	fake.recordInvocation("Valid", []interface{}{arg1, arg2})
+	fake.validMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SessionStore) ValidCallCount() int {
+	fake.validMutex.RLock()
+	defer fake.validMutex.RUnlock()
+	return len(fake.validArgsForCall)
+}
+
+func (fake *SessionStore) ValidCalls(stub func(pkg.Session, net.IP) bool) {
+	fake.validMutex.Lock()
+	defer fake.validMutex.Unlock()
+	fake.ValidStub = stub
+}
+
+func (fake *SessionStore) ValidArgsForCall(i int) (pkg.Session, net.IP) {
+	fake.validMutex.RLock()
+	defer fake.validMutex.RUnlock()
+	argsForCall := fake.validArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *SessionStore) ValidReturns(result1 bool) {
+	fake.validMutex.Lock()
+	defer fake.validMutex.Unlock()
+	fake.ValidStub = nil
+	fake.validReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *SessionStore) ValidReturnsOnCall(i int, result1 bool) {
+	fake.validMutex.Lock()
+	defer fake.validMutex.Unlock()
+	fake.ValidStub = nil
+	if fake.validReturnsOnCall == nil {
+		fake.validReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.validReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *SessionStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	fake.revokeMutex.RLock()
+	defer fake.revokeMutex.RUnlock()
+	fake.revokeUserMutex.RLock()
+	defer fake.revokeUserMutex.RUnlock()
+	fake.sessionsMutex.RLock()
+	defer fake.sessionsMutex.RUnlock()
+	fake.validMutex.RLock()
+	defer fake.validMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *SessionStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.SessionStore = new(SessionStore)
@@ -0,0 +1,124 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+type TotpSecretStore struct {
+	TotpSecretStub        func(context.Context, pkg.UserName) (pkg.TotpSecret, bool, error)
+	totpSecretMutex       sync.RWMutex
+	totpSecretArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+	}
+	totpSecretReturns struct {
+		result1 pkg.TotpSecret
+		result2 bool
+		result3 error
+	}
+	totpSecretReturnsOnCall map[int]struct {
+		result1 pkg.TotpSecret
+		result2 bool
+		result3 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *TotpSecretStore) TotpSecret(arg1 context.Context, arg2 pkg.UserName) (pkg.TotpSecret, bool, error) {
+	fake.totpSecretMutex.Lock()
+	ret, specificReturn := fake.totpSecretReturnsOnCall[len(fake.totpSecretArgsForCall)]
+	fake.totpSecretArgsForCall = append(fake.totpSecretArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.UserName
+	}{arg1, arg2})
+	stub := fake.TotpSecretStub
+	fakeReturns := fake.totpSecretReturns
+	fake.recordInvocation("TotpSecret", []interface{}{arg1, arg2})
+	fake.totpSecretMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *TotpSecretStore) TotpSecretCallCount() int {
+	fake.totpSecretMutex.RLock()
+	defer fake.totpSecretMutex.RUnlock()
+	return len(fake.totpSecretArgsForCall)
+}
+
+func (fake *TotpSecretStore) TotpSecretCalls(stub func(context.Context, pkg.UserName) (pkg.TotpSecret, bool, error)) {
+	fake.totpSecretMutex.Lock()
+	defer fake.totpSecretMutex.Unlock()
+	fake.TotpSecretStub = stub
+}
+
+func (fake *TotpSecretStore) TotpSecretArgsForCall(i int) (context.Context, pkg.UserName) {
+	fake.totpSecretMutex.RLock()
+	defer fake.totpSecretMutex.RUnlock()
+	argsForCall := fake.totpSecretArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *TotpSecretStore) TotpSecretReturns(result1 pkg.TotpSecret, result2 bool, result3 error) {
+	fake.totpSecretMutex.Lock()
+	defer fake.totpSecretMutex.Unlock()
+	fake.TotpSecretStub = nil
+	fake.totpSecretReturns = struct {
+		result1 pkg.TotpSecret
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *TotpSecretStore) TotpSecretReturnsOnCall(i int, result1 pkg.TotpSecret, result2 bool, result3 error) {
+	fake.totpSecretMutex.Lock()
+	defer fake.totpSecretMutex.Unlock()
+	fake.TotpSecretStub = nil
+	if fake.totpSecretReturnsOnCall == nil {
+		fake.totpSecretReturnsOnCall = make(map[int]struct {
+			result1 pkg.TotpSecret
+			result2 bool
+			result3 error
+		})
+	}
+	fake.totpSecretReturnsOnCall[i] = struct {
+		result1 pkg.TotpSecret
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *TotpSecretStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.totpSecretMutex.RLock()
+	defer fake.totpSecretMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *TotpSecretStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.TotpSecretStore = new(TotpSecretStore)
@@ -0,0 +1,239 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// AuthMethod describes how the credentials of a request were transmitted.
+type AuthMethod string
+
+func (a AuthMethod) String() string {
+	return string(a)
+}
+
+const (
+	AuthMethodBasic          AuthMethod = "basic"
+	AuthMethodCookie         AuthMethod = "cookie"
+	AuthMethodForm           AuthMethod = "form"
+	AuthMethodApiKey         AuthMethod = "apikey"
+	AuthMethodDigest         AuthMethod = "digest"
+	AuthMethodClientCert     AuthMethod = "client-cert"
+	AuthMethodJwt            AuthMethod = "jwt"
+	AuthMethodTrustedNetwork AuthMethod = "trusted-network"
+)
+
+// AccessLogFormat is the line format of the access log.
+type AccessLogFormat string
+
+func (a AccessLogFormat) String() string {
+	return string(a)
+}
+
+const (
+	AccessLogFormatJson AccessLogFormat = "json"
+	// AccessLogFormatCombined is the Apache combined log format followed by
+	// the auth method, request id and upstream latency in seconds.
+	AccessLogFormatCombined AccessLogFormat = "combined"
+)
+
+// AccessLogEntry is a line of the access log. It never contains credentials,
+// cookies or query strings.
+type AccessLogEntry struct {
+	Time       time.Time  `json:"time"`
+	ClientIP   string     `json:"client_ip"`
+	User       UserName   `json:"user,omitempty"`
+	Method     string     `json:"method"`
+	Path       string     `json:"path"`
+	Protocol   string     `json:"protocol"`
+	Status     int        `json:"status"`
+	Bytes      int64      `json:"bytes"`
+	Duration   float64    `json:"duration_seconds"`
+	Upstream   float64    `json:"upstream_seconds,omitempty"`
+	AuthMethod AuthMethod `json:"auth_method,omitempty"`
+	RequestID  string     `json:"request_id,omitempty"`
+	Referer    string     `json:"referer,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+}
+
+type accessLogEntryContextKey struct{}
+
+// WithAccessLogEntry returns a copy of ctx carrying the entry completed by the handlers.
+func WithAccessLogEntry(ctx context.Context, entry *AccessLogEntry) context.Context {
+	return context.WithValue(ctx, accessLogEntryContextKey{}, entry)
+}
+
+// AccessLogEntryFromContext returns the entry stored by the access log handler.
+func AccessLogEntryFromContext(ctx context.Context) (*AccessLogEntry, bool) {
+	entry, ok := ctx.Value(accessLogEntryContextKey{}).(*AccessLogEntry)
+	return entry, ok
+}
+
+// recordAuth stores the authenticated user in the access log entry and the span of the request.
+func recordAuth(ctx context.Context, user UserName, method AuthMethod) {
+	if entry, ok := AccessLogEntryFromContext(ctx); ok {
+		entry.User = user
+		entry.AuthMethod = method
+	}
+	recordSpanAuth(ctx, user, method)
+}
+
+// recordClientIP stores the client ip resolved from X-Forwarded-For.
+func recordClientIP(ctx context.Context, ip net.IP) {
+	if entry, ok := AccessLogEntryFromContext(ctx); ok && ip != nil {
+		entry.ClientIP = ip.String()
+	}
+}
+
+// recordUpstream stores the latency of the target.
+func recordUpstream(ctx context.Context, duration time.Duration) {
+	if entry, ok := AccessLogEntryFromContext(ctx); ok {
+		entry.Upstream = duration.Seconds()
+	}
+}
+
+// NewAccessLogHandler writes a line per request to writer after the subhandler completed.
+func NewAccessLogHandler(
+	subhandler http.Handler,
+	writer io.Writer,
+	format AccessLogFormat,
+	now func() time.Time,
+) http.Handler {
+	h := new(accessLogHandler)
+	h.subhandler = subhandler
+	h.writer = writer
+	h.format = format
+	h.now = now
+	return h
+}
+
+type accessLogHandler struct {
+	subhandler http.Handler
+	format     AccessLogFormat
+	now        func() time.Time
+
+	mux    sync.Mutex
+	writer io.Writer
+}
+
+func (h *accessLogHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	start := h.now()
+	entry := &AccessLogEntry{
+		Time:      start,
+		ClientIP:  ipKey(ClientIP(request)),
+		Method:    request.Method,
+		Path:      request.URL.Path,
+		Protocol:  request.Proto,
+		RequestID: RequestIDFromContext(request.Context()),
+		Referer:   withoutQuery(request.Referer()),
+		UserAgent: request.UserAgent(),
+	}
+	statusResponseWriter := NewStatusResponseWriter(responseWriter)
+	h.subhandler.ServeHTTP(
+		statusResponseWriter,
+		request.WithContext(WithAccessLogEntry(request.Context(), entry)),
+	)
+	entry.Status = statusResponseWriter.Status()
+	entry.Bytes = statusResponseWriter.Bytes()
+	entry.Duration = h.now().Sub(start).Seconds()
+	if err := h.write(entry); err != nil {
+		glog.Warningf("write access log failed: %v", err)
+	}
+}
+
+func (h *accessLogHandler) write(entry *AccessLogEntry) error {
+	var line []byte
+	if h.format == AccessLogFormatCombined {
+		line = []byte(combinedLogLine(entry))
+	} else {
+		var err error
+		if line, err = json.Marshal(entry); err != nil {
+			return err
+		}
+	}
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	_, err := h.writer.Write(append(line, '\n'))
+	return err
+}
+
+// combinedLogLine formats the entry like
+// %h - %u [%t] "%r" %>s %b "%{Referer}i" "%{User-agent}i" "auth method" "request id" upstream.
+func combinedLogLine(entry *AccessLogEntry) string {
+	return fmt.Sprintf(
+		`%s - %s [%s] "%s %s %s" %d %s "%s" "%s" "%s" "%s" %s`,
+		dash(entry.ClientIP),
+		dash(quoteValue(entry.User.String())),
+		entry.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		quoteValue(entry.Method),
+		quoteValue(entry.Path),
+		quoteValue(entry.Protocol),
+		entry.Status,
+		dash(combinedBytes(entry.Bytes)),
+		dash(quoteValue(entry.Referer)),
+		dash(quoteValue(entry.UserAgent)),
+		dash(entry.AuthMethod.String()),
+		dash(quoteValue(entry.RequestID)),
+		dash(combinedSeconds(entry.Upstream)),
+	)
+}
+
+func combinedBytes(bytes int64) string {
+	if bytes == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", bytes)
+}
+
+func combinedSeconds(seconds float64) string {
+	if seconds == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.6f", seconds)
+}
+
+func dash(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+	return value
+}
+
+// quoteValue escapes quotes, backslashes and control characters so a value
+// can not break the line format.
+func quoteValue(value string) string {
+	var builder strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '"' || r == '\\':
+			builder.WriteRune('\\')
+			builder.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&builder, "\\x%02x", r)
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// withoutQuery removes query and fragment, which might contain credentials.
+func withoutQuery(value string) string {
+	if pos := strings.IndexAny(value, "?#"); pos >= 0 {
+		return value[:pos]
+	}
+	return value
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("AccessLogHandler", func() {
+	var buffer *bytes.Buffer
+	var format pkg.AccessLogFormat
+	var subhandler http.Handler
+	var check *mocks.Check
+	var request *http.Request
+	var recorder *httptest.ResponseRecorder
+	var now time.Time
+	BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		format = pkg.AccessLogFormatJson
+		check = &mocks.Check{}
+		check.CheckReturns(true, nil)
+		authorizer := &mocks.Authorizer{}
+		authorizer.AuthorizeReturns(true, nil)
+		subhandler = pkg.NewAuthBasicHandler(
+			http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+				responseWriter.WriteHeader(http.StatusCreated)
+				_, _ = responseWriter.Write([]byte("hello"))
+			}),
+			check,
+			&mocks.ApiKeyAuth{},
+			&mocks.ClientCertAuth{},
+			authorizer,
+			&mocks.LoginThrottle{},
+			"realm",
+			pkg.AuditLogDisabled,
+		)
+		request = httptest.NewRequest(http.MethodPost, "/path?password=secret", nil)
+		request.RemoteAddr = "10.0.0.1:1234"
+		request.SetBasicAuth("alice", "secret")
+		request.Header.Set("X-Request-ID", "req-1")
+		request.Header.Set("Cookie", "auth-http-proxy-token=token")
+		request.Header.Set("User-Agent", "curl/8.0")
+		recorder = httptest.NewRecorder()
+		now = time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	})
+	JustBeforeEach(func() {
+		pkg.NewRequestIDHandler(
+			pkg.NewAccessLogHandler(subhandler, buffer, format, func() time.Time {
+				return now
+			}),
+			"X-Request-ID",
+			nil,
+		).ServeHTTP(recorder, request)
+	})
+	Context("json", func() {
+		It("writes entry", func() {
+			var entry pkg.AccessLogEntry
+			Expect(json.Unmarshal(buffer.Bytes(), &entry)).To(Succeed())
+			Expect(entry.Time).To(Equal(now))
+			Expect(entry.ClientIP).To(Equal("10.0.0.1"))
+			Expect(entry.User).To(Equal(pkg.UserName("alice")))
+			Expect(entry.Method).To(Equal(http.MethodPost))
+			Expect(entry.Path).To(Equal("/path"))
+			Expect(entry.Status).To(Equal(http.StatusCreated))
+			Expect(entry.Bytes).To(Equal(int64(5)))
+			Expect(entry.AuthMethod).To(Equal(pkg.AuthMethodBasic))
+			Expect(entry.RequestID).To(Equal("req-1"))
+			Expect(entry.UserAgent).To(Equal("curl/8.0"))
+		})
+		It("writes one line", func() {
+			Expect(bytes.Count(buffer.Bytes(), []byte("\n"))).To(Equal(1))
+		})
+		It("contains no credentials", func() {
+			Expect(buffer.String()).NotTo(ContainSubstring("secret"))
+			Expect(buffer.String()).NotTo(ContainSubstring("token"))
+		})
+	})
+	Context("combined", func() {
+		BeforeEach(func() {
+			format = pkg.AccessLogFormatCombined
+		})
+		It("writes line", func() {
+			Expect(buffer.String()).To(Equal(
+				`10.0.0.1 - alice [19/Oct/2026:12:30:00 +0000] "POST /path HTTP/1.1" 201 5 ` +
+					`"-" "curl/8.0" "basic" "req-1" -` + "\n",
+			))
+		})
+	})
+	Context("invalid credentials", func() {
+		BeforeEach(func() {
+			check.CheckReturns(false, nil)
+		})
+		It("writes entry without user", func() {
+			var entry pkg.AccessLogEntry
+			Expect(json.Unmarshal(buffer.Bytes(), &entry)).To(Succeed())
+			Expect(entry.User).To(BeEmpty())
+			Expect(entry.Status).To(Equal(http.StatusUnauthorized))
+		})
+	})
+	Context("forward", func() {
+		BeforeEach(func() {
+			subhandler = pkg.NewForwardHandler(
+				"target",
+				func(address string, req *http.Request) (*http.Response, error) {
+					recorder := httptest.NewRecorder()
+					recorder.WriteHeader(http.StatusOK)
+					return recorder.Result(), nil
+				},
+			)
+		})
+		It("records upstream latency", func() {
+			var entry pkg.AccessLogEntry
+			Expect(json.Unmarshal(buffer.Bytes(), &entry)).To(Succeed())
+			Expect(entry.Status).To(Equal(http.StatusOK))
+			Expect(entry.Upstream).To(BeNumerically(">", 0))
+		})
+	})
+})
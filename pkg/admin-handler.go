@@ -0,0 +1,129 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// NewAdminHandler serves the admin api for requests accepted by the admin auth:
+//
+//	GET    /admin/cache[?user=name]    cached verifications
+//	DELETE /admin/cache[?user=name]    flush cached verifications of the user or all
+//	GET    /admin/sessions[?user=name] html login sessions
+//	DELETE /admin/sessions?id=id       revoke a session
+//	DELETE /admin/sessions?user=name   revoke all sessions of the user
+//	GET    /admin/lockouts             locked users and ips
+//	DELETE /admin/lockouts?user=&ip=   clear lockouts
+//	GET    /admin/config               the effective configuration, secrets must be redacted
+func NewAdminHandler(
+	adminAuth AdminAuth,
+	cacheAuth CacheAuth,
+	sessionStore SessionStore,
+	loginThrottle LoginThrottle,
+	auditLog AuditLog,
+	config interface{},
+) http.Handler {
+	h := new(adminHandler)
+	h.cacheAuth = cacheAuth
+	h.sessionStore = sessionStore
+	h.auditLog = auditLog
+	h.config = config
+
+	router := mux.NewRouter()
+	router.Path("/admin/cache").Methods(http.MethodGet).HandlerFunc(h.listCache)
+	router.Path("/admin/cache").Methods(http.MethodDelete).HandlerFunc(h.flushCache)
+	router.Path("/admin/sessions").Methods(http.MethodGet).HandlerFunc(h.listSessions)
+	router.Path("/admin/sessions").Methods(http.MethodDelete).HandlerFunc(h.revokeSessions)
+	router.Path("/admin/lockouts").Handler(newLoginLockoutHandler(loginThrottle))
+	router.Path("/admin/config").Methods(http.MethodGet).HandlerFunc(h.showConfig)
+	return NewAdminAuthHandler(router, adminAuth, auditLog)
+}
+
+type adminHandler struct {
+	cacheAuth    CacheAuth
+	sessionStore SessionStore
+	auditLog     AuditLog
+	config       interface{}
+}
+
+func (h *adminHandler) listCache(responseWriter http.ResponseWriter, request *http.Request) {
+	user := UserName(request.URL.Query().Get("user"))
+	result := []CacheAuthEntry{}
+	for _, entry := range h.cacheAuth.Entries() {
+		if len(user) == 0 || entry.User == user {
+			result = append(result, entry)
+		}
+	}
+	writeJson(request.Context(), responseWriter, result)
+}
+
+func (h *adminHandler) flushCache(responseWriter http.ResponseWriter, request *http.Request) {
+	var count int
+	if user := UserName(request.URL.Query().Get("user")); len(user) > 0 {
+		count = h.cacheAuth.Flush(user)
+		infof(request.Context(), 1, "flushed %d cache entries of user %v", count, user)
+	} else {
+		count = h.cacheAuth.FlushAll()
+		infof(request.Context(), 1, "flushed all %d cache entries", count)
+	}
+	writeJson(request.Context(), responseWriter, map[string]int{"flushed": count})
+}
+
+func (h *adminHandler) listSessions(responseWriter http.ResponseWriter, request *http.Request) {
+	user := UserName(request.URL.Query().Get("user"))
+	result := []Session{}
+	for _, session := range h.sessionStore.Sessions() {
+		if len(user) == 0 || session.User == user {
+			result = append(result, session)
+		}
+	}
+	writeJson(request.Context(), responseWriter, result)
+}
+
+func (h *adminHandler) revokeSessions(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) {
+	if id := request.URL.Query().Get("id"); len(id) > 0 {
+		if !h.sessionStore.Revoke(id) {
+			http.Error(responseWriter, "session not found", http.StatusNotFound)
+			return
+		}
+		h.auditLog.Log(request.Context(), AuditEvent{
+			Type:   AuditSessionRevoked,
+			Detail: "session " + id,
+		})
+		writeJson(request.Context(), responseWriter, map[string]int{"revoked": 1})
+		return
+	}
+	if user := UserName(request.URL.Query().Get("user")); len(user) > 0 {
+		count := h.sessionStore.RevokeUser(user)
+		h.auditLog.Log(request.Context(), AuditEvent{
+			Type:   AuditSessionRevoked,
+			User:   user,
+			Detail: fmt.Sprintf("%d sessions", count),
+		})
+		writeJson(request.Context(), responseWriter, map[string]int{"revoked": count})
+		return
+	}
+	http.Error(responseWriter, "id or user missing", http.StatusBadRequest)
+}
+
+func (h *adminHandler) showConfig(responseWriter http.ResponseWriter, request *http.Request) {
+	writeJson(request.Context(), responseWriter, h.config)
+}
+
+func writeJson(ctx context.Context, responseWriter http.ResponseWriter, value interface{}) {
+	responseWriter.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(responseWriter).Encode(value); err != nil {
+		warningf(ctx, "encode json failed: %v", err)
+	}
+}
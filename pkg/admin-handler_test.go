@@ -0,0 +1,272 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("AdminHandler", func() {
+	var adminAuth *mocks.AdminAuth
+	var cacheAuth *mocks.CacheAuth
+	var sessionStore *mocks.SessionStore
+	var loginThrottle *mocks.LoginThrottle
+	var auditLog *mocks.AuditLog
+	var recorder *httptest.ResponseRecorder
+	var method string
+	var target string
+	BeforeEach(func() {
+		adminAuth = &mocks.AdminAuth{}
+		adminAuth.AuthenticateReturns("admin", true, nil)
+		cacheAuth = &mocks.CacheAuth{}
+		cacheAuth.EntriesReturns([]pkg.CacheAuthEntry{{User: "alice"}, {User: "bob"}})
+		sessionStore = &mocks.SessionStore{}
+		sessionStore.SessionsReturns([]pkg.Session{{ID: "1", User: "alice"}, {ID: "2", User: "bob"}})
+		loginThrottle = &mocks.LoginThrottle{}
+		auditLog = &mocks.AuditLog{}
+		recorder = httptest.NewRecorder()
+		method = http.MethodGet
+	})
+	JustBeforeEach(func() {
+		pkg.NewAdminHandler(
+			adminAuth,
+			cacheAuth,
+			sessionStore,
+			loginThrottle,
+			auditLog,
+			map[string]string{"secret": "REDACTED"},
+		).ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+	})
+	Context("not authenticated", func() {
+		BeforeEach(func() {
+			adminAuth.AuthenticateReturns("", false, nil)
+			target = "/admin/cache"
+		})
+		It("returns unauthorized", func() {
+			Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+			Expect(cacheAuth.EntriesCallCount()).To(Equal(0))
+		})
+		It("audits login failure", func() {
+			Expect(auditLog.LogCallCount()).To(Equal(1))
+			_, event := auditLog.LogArgsForCall(0)
+			Expect(event.Type).To(Equal(pkg.AuditAdminLoginFailure))
+		})
+	})
+	Context("auth fails", func() {
+		BeforeEach(func() {
+			adminAuth.AuthenticateReturns("", false, errors.New("banana"))
+			target = "/admin/cache"
+		})
+		It("returns service unavailable", func() {
+			Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+		})
+	})
+	Context("list cache of user", func() {
+		BeforeEach(func() {
+			target = "/admin/cache?user=bob"
+		})
+		It("returns entries of user", func() {
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"user":"bob"`))
+			Expect(recorder.Body.String()).NotTo(ContainSubstring(`"user":"alice"`))
+		})
+	})
+	Context("flush cache of user", func() {
+		BeforeEach(func() {
+			method = http.MethodDelete
+			target = "/admin/cache?user=alice"
+			cacheAuth.FlushReturns(2)
+		})
+		It("flushes user", func() {
+			Expect(cacheAuth.FlushCallCount()).To(Equal(1))
+			Expect(cacheAuth.FlushArgsForCall(0)).To(Equal(pkg.UserName("alice")))
+			Expect(cacheAuth.FlushAllCallCount()).To(Equal(0))
+			Expect(recorder.Body.String()).To(MatchJSON(`{"flushed":2}`))
+		})
+	})
+	Context("flush cache", func() {
+		BeforeEach(func() {
+			method = http.MethodDelete
+			target = "/admin/cache"
+		})
+		It("flushes all", func() {
+			Expect(cacheAuth.FlushAllCallCount()).To(Equal(1))
+		})
+	})
+	Context("list sessions", func() {
+		BeforeEach(func() {
+			target = "/admin/sessions?user=alice"
+		})
+		It("returns sessions of user", func() {
+			Expect(recorder.Body.String()).To(ContainSubstring(`"id":"1"`))
+			Expect(recorder.Body.String()).NotTo(ContainSubstring(`"id":"2"`))
+		})
+	})
+	Context("revoke session", func() {
+		BeforeEach(func() {
+			method = http.MethodDelete
+			target = "/admin/sessions?id=1"
+			sessionStore.RevokeReturns(true)
+		})
+		It("revokes session", func() {
+			Expect(sessionStore.RevokeCallCount()).To(Equal(1))
+			Expect(sessionStore.RevokeArgsForCall(0)).To(Equal("1"))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+		})
+		It("audits admin action and revoked session", func() {
+			Expect(auditLog.LogCallCount()).To(Equal(2))
+			_, event := auditLog.LogArgsForCall(0)
+			Expect(event.Type).To(Equal(pkg.AuditAdminAction))
+			Expect(event.User).To(Equal(pkg.UserName("admin")))
+			Expect(event.Detail).To(Equal("DELETE /admin/sessions?id=1"))
+			_, event = auditLog.LogArgsForCall(1)
+			Expect(event.Type).To(Equal(pkg.AuditSessionRevoked))
+		})
+	})
+	Context("revoke unknown session", func() {
+		BeforeEach(func() {
+			method = http.MethodDelete
+			target = "/admin/sessions?id=3"
+		})
+		It("returns not found", func() {
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+	Context("revoke sessions of user", func() {
+		BeforeEach(func() {
+			method = http.MethodDelete
+			target = "/admin/sessions?user=alice"
+			sessionStore.RevokeUserReturns(1)
+		})
+		It("revokes sessions", func() {
+			Expect(sessionStore.RevokeUserArgsForCall(0)).To(Equal(pkg.UserName("alice")))
+			Expect(recorder.Body.String()).To(MatchJSON(`{"revoked":1}`))
+		})
+	})
+	Context("revoke sessions without id and user", func() {
+		BeforeEach(func() {
+			method = http.MethodDelete
+			target = "/admin/sessions"
+		})
+		It("returns bad request", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+	Context("clear lockout", func() {
+		BeforeEach(func() {
+			method = http.MethodDelete
+			target = "/admin/lockouts?user=alice&ip=1.2.3.4"
+		})
+		It("clears lockout", func() {
+			Expect(loginThrottle.ClearCallCount()).To(Equal(1))
+			argUser, argIP := loginThrottle.ClearArgsForCall(0)
+			Expect(argUser).To(Equal(pkg.UserName("alice")))
+			Expect(argIP.String()).To(Equal("1.2.3.4"))
+			Expect(recorder.Code).To(Equal(http.StatusNoContent))
+		})
+	})
+	Context("clear lockout with invalid ip", func() {
+		BeforeEach(func() {
+			method = http.MethodDelete
+			target = "/admin/lockouts?ip=banana"
+		})
+		It("returns bad request", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(loginThrottle.ClearCallCount()).To(Equal(0))
+		})
+	})
+	Context("list lockouts", func() {
+		BeforeEach(func() {
+			target = "/admin/lockouts"
+			loginThrottle.LockoutsReturns([]pkg.LoginLockoutEntry{{User: "alice", Failures: 3}})
+		})
+		It("returns lockouts", func() {
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"user":"alice"`))
+		})
+	})
+	Context("config", func() {
+		BeforeEach(func() {
+			target = "/admin/config"
+		})
+		It("returns config", func() {
+			Expect(recorder.Body.String()).To(MatchJSON(`{"secret":"REDACTED"}`))
+		})
+	})
+})
+
+var _ = Describe("AdminAuth", func() {
+	var check *mocks.Check
+	var groupResolver *mocks.GroupResolver
+	var loginThrottle *mocks.LoginThrottle
+	var adminAuth pkg.AdminAuth
+	var request *http.Request
+	BeforeEach(func() {
+		check = &mocks.Check{}
+		check.CheckReturns(true, nil)
+		groupResolver = &mocks.GroupResolver{}
+		groupResolver.GroupsOfUserReturns(pkg.GroupNames{"admins"}, nil)
+		loginThrottle = &mocks.LoginThrottle{}
+		adminAuth = pkg.NewAdminAuthList(
+			pkg.NewAdminTokenAuth("secret"),
+			pkg.NewAdminGroupAuth(check, groupResolver, loginThrottle, "admins"),
+		)
+		request = httptest.NewRequest(http.MethodGet, "/admin/cache", nil)
+	})
+	It("accepts admin token", func() {
+		request.Header.Set("Authorization", "Bearer secret")
+		user, ok, err := adminAuth.Authenticate(request)
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeTrue())
+		Expect(user).To(Equal(pkg.AdminTokenUser))
+	})
+	It("rejects wrong admin token", func() {
+		request.Header.Set("Authorization", "Bearer other")
+		_, ok, err := adminAuth.Authenticate(request)
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeFalse())
+	})
+	It("accepts member of admin group", func() {
+		request.SetBasicAuth("alice", "secret")
+		user, ok, err := adminAuth.Authenticate(request)
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeTrue())
+		Expect(user).To(Equal(pkg.UserName("alice")))
+		_, argUser := groupResolver.GroupsOfUserArgsForCall(0)
+		Expect(argUser).To(Equal(pkg.UserName("alice")))
+	})
+	It("rejects user without admin group", func() {
+		groupResolver.GroupsOfUserReturns(pkg.GroupNames{"staff"}, nil)
+		request.SetBasicAuth("alice", "secret")
+		_, ok, err := adminAuth.Authenticate(request)
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeFalse())
+	})
+	It("records failure of wrong password", func() {
+		check.CheckReturns(false, nil)
+		request.SetBasicAuth("alice", "wrong")
+		_, ok, err := adminAuth.Authenticate(request)
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeFalse())
+		Expect(loginThrottle.FailureCallCount()).To(Equal(1))
+		Expect(groupResolver.GroupsOfUserCallCount()).To(Equal(0))
+	})
+	It("returns error if group lookup fails", func() {
+		groupResolver.GroupsOfUserReturns(nil, errors.New("banana"))
+		request = request.WithContext(context.Background())
+		request.SetBasicAuth("alice", "secret")
+		_, _, err := adminAuth.Authenticate(request)
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,142 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/bborbe/errors"
+)
+
+// AdminToken is the bearer token of the admin api.
+type AdminToken string
+
+func (a AdminToken) String() string {
+	return string(a)
+}
+
+// AdminTokenUser is the name of admins authenticated with the admin token.
+const AdminTokenUser UserName = "admin-token"
+
+//counterfeiter:generate -o ../mocks/admin-auth.go --fake-name AdminAuth . AdminAuth
+type AdminAuth interface {
+	// Authenticate returns the name of the admin if the request carries admin credentials.
+	Authenticate(request *http.Request) (UserName, bool, error)
+}
+
+type AdminAuthFunc func(request *http.Request) (UserName, bool, error)
+
+func (a AdminAuthFunc) Authenticate(request *http.Request) (UserName, bool, error) {
+	return a(request)
+}
+
+// NewAdminTokenAuth accepts requests with the admin token as bearer token.
+func NewAdminTokenAuth(adminToken AdminToken) AdminAuth {
+	return AdminAuthFunc(func(request *http.Request) (UserName, bool, error) {
+		token, err := ParseAuthorizationBearerTokenHttpRequest(request)
+		if err != nil || len(adminToken) == 0 ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			return "", false, nil
+		}
+		return AdminTokenUser, true, nil
+	})
+}
+
+// NewAdminGroupAuth accepts basic auth of users with a valid password and the admin group.
+// Failed logins are counted by the login throttle.
+func NewAdminGroupAuth(
+	check Check,
+	groupResolver GroupResolver,
+	loginThrottle LoginThrottle,
+	adminGroup GroupName,
+) AdminAuth {
+	return AdminAuthFunc(func(request *http.Request) (UserName, bool, error) {
+		user, pass, err := ParseAuthorizationBasisHttpRequest(request)
+		if err != nil {
+			return "", false, nil
+		}
+		ip := ClientIP(request)
+		if retryAfter := loginThrottle.RetryAfter(UserName(user), ip); retryAfter > 0 {
+			infof(request.Context(), 2, "admin %v from %v is locked for %v", user, ip, retryAfter)
+			return "", false, nil
+		}
+		valid, err := check.Check(request.Context(), user, pass)
+		if err != nil {
+			return "", false, errors.Wrapf(request.Context(), err, "check admin %s failed", user)
+		}
+		if !valid {
+			loginThrottle.Failure(UserName(user), ip)
+			return "", false, nil
+		}
+		groups, err := groupResolver.GroupsOfUser(request.Context(), UserName(user))
+		if err != nil {
+			return "", false, errors.Wrapf(request.Context(), err, "get groups of admin %s failed", user)
+		}
+		if !groups.Contains(adminGroup) {
+			infof(request.Context(), 1, "user %v is not member of admin group %v", user, adminGroup)
+			return "", false, nil
+		}
+		loginThrottle.Success(UserName(user), ip)
+		return UserName(user), true, nil
+	})
+}
+
+// NewAdminAuthList accepts requests accepted by any of the given auths.
+func NewAdminAuthList(adminAuths ...AdminAuth) AdminAuth {
+	return AdminAuthFunc(func(request *http.Request) (UserName, bool, error) {
+		for _, adminAuth := range adminAuths {
+			user, ok, err := adminAuth.Authenticate(request)
+			if err != nil {
+				return "", false, err
+			}
+			if ok {
+				return user, true, nil
+			}
+		}
+		return "", false, nil
+	})
+}
+
+// NewAdminAuthHandler forwards requests accepted by the admin auth to the subhandler
+// and responds with 401 otherwise.
+func NewAdminAuthHandler(
+	subhandler http.Handler,
+	adminAuth AdminAuth,
+	auditLog AuditLog,
+) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		user, ok, err := adminAuth.Authenticate(request)
+		if err != nil {
+			warningf(request.Context(), "authenticate admin failed: %v", err)
+			responseWriter.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if !ok {
+			infof(request.Context(), 1, "admin credentials invalid")
+			auditLogin(
+				request,
+				auditLog,
+				AuditAdminLoginFailure,
+				user,
+				"",
+				AuditReasonInvalidCredentials,
+			)
+			responseWriter.Header().Set("WWW-Authenticate", `Basic realm="admin"`)
+			responseWriter.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		infof(request.Context(), 2, "admin %v %s %s", user, request.Method, request.URL.Path)
+		if request.Method != http.MethodGet && request.Method != http.MethodHead {
+			auditLog.Log(request.Context(), AuditEvent{
+				Type:     AuditAdminAction,
+				User:     user,
+				ClientIP: ipKey(ClientIP(request)),
+				Detail:   request.Method + " " + request.URL.RequestURI(),
+			})
+		}
+		subhandler.ServeHTTP(responseWriter, request)
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/bborbe/errors"
+)
+
+// AllowRule matches requests forwarded without authentication.
+type AllowRule struct {
+	// Method is a http method or "*" for every method.
+	Method string
+	// Pattern is a path, a path.Match pattern or a prefix ending with "*".
+	Pattern string
+	// Networks restrict the source address if not empty.
+	Networks Networks
+}
+
+// ParseAllowRule parses "METHOD PATTERN [CIDR,...]", e.g. "POST /hooks/github 140.82.112.0/20".
+func ParseAllowRule(ctx context.Context, entry string) (*AllowRule, error) {
+	fields := strings.Fields(entry)
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, errors.Errorf(
+			ctx,
+			"allow rule '%s' invalid, expected 'METHOD PATTERN [CIDR,...]'",
+			entry,
+		)
+	}
+	if !strings.HasPrefix(fields[1], "/") {
+		return nil, errors.Errorf(ctx, "pattern of allow rule '%s' must start with /", entry)
+	}
+	if _, err := path.Match(fields[1], "/"); err != nil {
+		return nil, errors.Wrapf(ctx, err, "pattern of allow rule '%s' invalid", entry)
+	}
+	result := &AllowRule{
+		Method:  strings.ToUpper(fields[0]),
+		Pattern: fields[1],
+	}
+	if len(fields) == 3 {
+		for _, cidr := range strings.Split(fields[2], ",") {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, errors.Wrapf(ctx, err, "cidr of allow rule '%s' invalid", entry)
+			}
+			result.Networks = append(result.Networks, network)
+		}
+	}
+	return result, nil
+}
+
+// Matches returns true if method, path and source address match the rule.
+func (a *AllowRule) Matches(request *http.Request) bool {
+	if a.Method != "*" && a.Method != request.Method {
+		return false
+	}
+	if !a.matchesPath(request.URL.Path) {
+		return false
+	}
+	return len(a.Networks) == 0 || a.Networks.Contains(ClientIP(request))
+}
+
+func (a *AllowRule) matchesPath(requestPath string) bool {
+	if !isCleanPath(requestPath) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(a.Pattern, "*"); ok && !strings.ContainsAny(prefix, "*?[") {
+		return strings.HasPrefix(requestPath, prefix)
+	}
+	matched, err := path.Match(a.Pattern, requestPath)
+	return err == nil && matched
+}
+
+// isCleanPath rejects paths like /static/../admin that could resolve to another path upstream.
+func isCleanPath(requestPath string) bool {
+	cleaned := path.Clean(requestPath)
+	if strings.HasSuffix(requestPath, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned == requestPath
+}
+
+// AllowList is a list of rules for requests bypassing authentication.
+type AllowList []AllowRule
+
+// ParseAllowList parses all entries with ParseAllowRule.
+func ParseAllowList(ctx context.Context, entries []string) (AllowList, error) {
+	var result AllowList
+	for _, entry := range entries {
+		rule, err := ParseAllowRule(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *rule)
+	}
+	return result, nil
+}
+
+// Matches returns true if any rule matches the request.
+func (a AllowList) Matches(request *http.Request) bool {
+	for i := range a {
+		if a[i].Matches(request) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAllowListHandler forwards requests matching the allow list without authentication
+// and passes all other requests to the auth handler.
+func NewAllowListHandler(
+	forwardHandler http.Handler,
+	authHandler http.Handler,
+	allowList AllowList,
+) http.Handler {
+	h := new(allowListHandler)
+	h.forwardHandler = forwardHandler
+	h.authHandler = authHandler
+	h.allowList = allowList
+	return h
+}
+
+type allowListHandler struct {
+	forwardHandler http.Handler
+	authHandler    http.Handler
+	allowList      AllowList
+}
+
+func (h *allowListHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	if !h.allowList.Matches(request) {
+		h.authHandler.ServeHTTP(responseWriter, request)
+		return
+	}
+	infof(request.Context(), 4, "allow %s %s without auth", request.Method, request.URL.Path)
+	request.Header.Del(ForwardForUserHeader)
+	h.forwardHandler.ServeHTTP(responseWriter, request)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("AllowList", func() {
+	var ctx context.Context
+	var allowList pkg.AllowList
+	var newRequest func(method string, target string, remoteAddr string) *http.Request
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		allowList, err = pkg.ParseAllowList(ctx, []string{
+			"GET /static/*",
+			"POST /hooks/github 140.82.112.0/20,2001:db8::/32",
+			"* /health",
+			"GET /api/*/status",
+		})
+		Expect(err).To(BeNil())
+		newRequest = func(method string, target string, remoteAddr string) *http.Request {
+			req, err := http.NewRequestWithContext(ctx, method, target, nil)
+			Expect(err).To(BeNil())
+			req.RemoteAddr = remoteAddr
+			return req
+		}
+	})
+	DescribeTable("ParseAllowRule returns error",
+		func(entry string) {
+			_, err := pkg.ParseAllowRule(ctx, entry)
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("missing pattern", "GET"),
+		Entry("relative pattern", "GET static/*"),
+		Entry("invalid pattern", "GET /[a"),
+		Entry("invalid cidr", "GET /static 10.0.0.0/99"),
+	)
+	DescribeTable("Matches",
+		func(method string, target string, remoteAddr string, expected bool) {
+			Expect(allowList.Matches(newRequest(method, target, remoteAddr))).To(Equal(expected))
+		},
+		Entry("static get", http.MethodGet, "/static/css/app.css", "10.0.0.1:1234", true),
+		Entry("static post", http.MethodPost, "/static/css/app.css", "10.0.0.1:1234", false),
+		Entry("static traversal", http.MethodGet, "/static/../admin", "10.0.0.1:1234", false),
+		Entry("hook from github", http.MethodPost, "/hooks/github", "140.82.115.1:1234", true),
+		Entry("hook from ipv6", http.MethodPost, "/hooks/github", "[2001:db8::1]:1234", true),
+		Entry("hook from other", http.MethodPost, "/hooks/github", "10.0.0.1:1234", false),
+		Entry("hook subpath", http.MethodPost, "/hooks/github/x", "140.82.115.1:1234", false),
+		Entry("health any method", http.MethodHead, "/health", "10.0.0.1:1234", true),
+		Entry("pattern match", http.MethodGet, "/api/v1/status", "10.0.0.1:1234", true),
+		Entry("pattern no match", http.MethodGet, "/api/v1/x/status", "10.0.0.1:1234", false),
+		Entry("other path", http.MethodGet, "/admin", "10.0.0.1:1234", false),
+	)
+	Context("handler", func() {
+		var forwardHandler *mocks.HttpHandler
+		var authHandler *mocks.HttpHandler
+		var handler http.Handler
+		BeforeEach(func() {
+			forwardHandler = &mocks.HttpHandler{}
+			authHandler = &mocks.HttpHandler{}
+			handler = pkg.NewAllowListHandler(forwardHandler, authHandler, allowList)
+		})
+		It("forwards allowed request without user", func() {
+			req := newRequest(http.MethodGet, "/static/app.js", "10.0.0.1:1234")
+			req.Header.Set(pkg.ForwardForUserHeader, "spoofed")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			Expect(forwardHandler.ServeHTTPCallCount()).To(Equal(1))
+			Expect(authHandler.ServeHTTPCallCount()).To(Equal(0))
+			_, argRequest := forwardHandler.ServeHTTPArgsForCall(0)
+			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(BeEmpty())
+		})
+		It("passes other request to auth handler", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodGet, "/", "10.0.0.1:1234"))
+			Expect(forwardHandler.ServeHTTPCallCount()).To(Equal(0))
+			Expect(authHandler.ServeHTTPCallCount()).To(Equal(1))
+		})
+	})
+})
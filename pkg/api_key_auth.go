@@ -0,0 +1,137 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/bborbe/errors"
+)
+
+// ErrApiKeyNotFound is returned if the presented key is unknown.
+var ErrApiKeyNotFound = stderrors.New("api key not found")
+
+// ErrApiKeyForbidden is returned if the key is valid but not allowed for the request.
+var ErrApiKeyForbidden = stderrors.New("api key forbidden")
+
+type ApiKeyHeader string
+
+func (a ApiKeyHeader) String() string {
+	return string(a)
+}
+
+//counterfeiter:generate -o ../mocks/api-key-auth.go --fake-name ApiKeyAuth . ApiKeyAuth
+type ApiKeyAuth interface {
+	// Authenticate returns the api key of the request.
+	// found is false if the request does not contain an api key.
+	Authenticate(request *http.Request) (apiKey *ApiKey, found bool, err error)
+}
+
+type ApiKeyAuthFunc func(request *http.Request) (*ApiKey, bool, error)
+
+func (a ApiKeyAuthFunc) Authenticate(request *http.Request) (*ApiKey, bool, error) {
+	return a(request)
+}
+
+// ApiKeyAuthDisabled is used if no api key file is configured.
+var ApiKeyAuthDisabled ApiKeyAuth = ApiKeyAuthFunc(
+	func(request *http.Request) (*ApiKey, bool, error) {
+		return nil, false, nil
+	},
+)
+
+// NewApiKeyAuth reads the key from the given header or "Authorization: Bearer <key>".
+func NewApiKeyAuth(
+	apiKeyStore ApiKeyStore,
+	apiKeyHeader ApiKeyHeader,
+	requiredGroups []GroupName,
+) ApiKeyAuth {
+	return &apiKeyAuth{
+		apiKeyStore:    apiKeyStore,
+		apiKeyHeader:   apiKeyHeader,
+		requiredGroups: requiredGroups,
+	}
+}
+
+type apiKeyAuth struct {
+	apiKeyStore    ApiKeyStore
+	apiKeyHeader   ApiKeyHeader
+	requiredGroups []GroupName
+}
+
+func (a *apiKeyAuth) Authenticate(request *http.Request) (*ApiKey, bool, error) {
+	ctx := request.Context()
+	header := a.apiKeyHeader.String()
+	key := request.Header.Get(header)
+	if len(key) == 0 {
+		token, err := ParseAuthorizationBearerTokenHttpRequest(request)
+		if err != nil {
+			return nil, false, nil
+		}
+		key = token
+		header = "Authorization"
+	}
+	apiKey, err := a.apiKeyStore.Find(ctx, key)
+	if err != nil {
+		return nil, true, errors.Wrapf(ctx, err, "find api key failed")
+	}
+	if apiKey.Expired(time.Now()) {
+		return nil, true, errors.Errorf(ctx, "api key %s expired", apiKey.Name)
+	}
+	if !apiKey.AllowsPath(request.URL.Path) {
+		return nil, true, errors.Wrapf(
+			ctx,
+			ErrApiKeyForbidden,
+			"api key %s not allowed for path %s",
+			apiKey.Name,
+			request.URL.Path,
+		)
+	}
+	if missing := apiKey.Groups.Missing(a.requiredGroups); len(missing) > 0 {
+		return nil, true, errors.Wrapf(
+			ctx,
+			ErrApiKeyForbidden,
+			"api key %s has not required groups %v",
+			apiKey.Name,
+			missing,
+		)
+	}
+	request.Header.Del(header)
+	return apiKey, true, nil
+}
+
+// serveApiKey forwards requests with a valid api key to the subhandler.
+// It returns false if the request contains no api key and password auth should continue.
+func serveApiKey(
+	apiKeyAuth ApiKeyAuth,
+	authorizer Authorizer,
+	subhandler http.Handler,
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) bool {
+	apiKey, found, err := apiKeyAuth.Authenticate(request)
+	if !found {
+		return false
+	}
+	if err != nil {
+		infof(request.Context(), 1, "api key auth failed: %v", err)
+		if stderrors.Is(err, ErrApiKeyForbidden) {
+			responseWriter.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		responseWriter.WriteHeader(http.StatusUnauthorized)
+		return true
+	}
+	infof(request.Context(), 2, "api key %s is valid", apiKey.Name)
+	serveIdentity(authorizer, subhandler, responseWriter, request, &Identity{
+		Name:   UserName(apiKey.Name),
+		Groups: apiKey.Groups,
+		Source: IdentitySourceApiKey,
+		Method: AuthMethodApiKey,
+	})
+	return true
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("ApiKeyAuth", func() {
+	var ctx context.Context
+	var err error
+	var apiKeyFile pkg.ApiKeyFile
+	var requiredGroups []pkg.GroupName
+	var req *http.Request
+	var apiKey *pkg.ApiKey
+	var found bool
+	BeforeEach(func() {
+		ctx = context.Background()
+		apiKeyFile = pkg.ApiKeyFile(filepath.Join(GinkgoT().TempDir(), "api-keys.json"))
+		Expect(os.WriteFile(apiKeyFile.String(), []byte(fmt.Sprintf(`[
+	{"name": "ci", "key-sha256": "%s", "groups": ["ci"]},
+	{"name": "scraper", "key-sha256": "%s", "allowed-path-prefixes": ["/metrics"]},
+	{"name": "old", "key-sha256": "%s", "expires": "2020-01-01T00:00:00Z"}
+]`,
+			pkg.HashApiKey("ci-secret"),
+			pkg.HashApiKey("scraper-secret"),
+			pkg.HashApiKey("old-secret"),
+		)), 0600)).To(Succeed())
+		requiredGroups = nil
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, "/metrics", nil)
+		Expect(err).To(BeNil())
+	})
+	JustBeforeEach(func() {
+		apiKeyAuth := pkg.NewApiKeyAuth(
+			pkg.NewApiKeyFileStore(apiKeyFile),
+			"X-Api-Key",
+			requiredGroups,
+		)
+		apiKey, found, err = apiKeyAuth.Authenticate(req)
+	})
+	Context("no api key", func() {
+		It("returns not found", func() {
+			Expect(found).To(BeFalse())
+			Expect(err).To(BeNil())
+		})
+	})
+	Context("valid key in header", func() {
+		BeforeEach(func() {
+			req.Header.Set("X-Api-Key", "ci-secret")
+			req.Header.Set("Authorization", "Bearer upstream-token")
+		})
+		It("returns api key", func() {
+			Expect(found).To(BeTrue())
+			Expect(err).To(BeNil())
+			Expect(apiKey.Name).To(Equal("ci"))
+		})
+		It("removes the key header", func() {
+			Expect(req.Header.Get("X-Api-Key")).To(BeEmpty())
+		})
+		It("keeps the authorization header", func() {
+			Expect(req.Header.Get("Authorization")).To(Equal("Bearer upstream-token"))
+		})
+	})
+	Context("valid key as bearer", func() {
+		BeforeEach(func() {
+			req.Header.Set("Authorization", "Bearer scraper-secret")
+		})
+		It("returns api key", func() {
+			Expect(found).To(BeTrue())
+			Expect(err).To(BeNil())
+			Expect(apiKey.Name).To(Equal("scraper"))
+		})
+		It("removes the authorization header", func() {
+			Expect(req.Header.Get("Authorization")).To(BeEmpty())
+		})
+	})
+	Context("unknown key", func() {
+		BeforeEach(func() {
+			req.Header.Set("X-Api-Key", "banana")
+		})
+		It("returns error", func() {
+			Expect(found).To(BeTrue())
+			Expect(err).To(MatchError(pkg.ErrApiKeyNotFound))
+		})
+	})
+	Context("expired key", func() {
+		BeforeEach(func() {
+			req.Header.Set("X-Api-Key", "old-secret")
+		})
+		It("returns error", func() {
+			Expect(found).To(BeTrue())
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("path not allowed", func() {
+		BeforeEach(func() {
+			req.URL.Path = "/admin"
+			req.Header.Set("X-Api-Key", "scraper-secret")
+		})
+		It("returns forbidden", func() {
+			Expect(found).To(BeTrue())
+			Expect(err).To(MatchError(pkg.ErrApiKeyForbidden))
+		})
+	})
+	Context("required group missing", func() {
+		BeforeEach(func() {
+			requiredGroups = []pkg.GroupName{"admin"}
+			req.Header.Set("X-Api-Key", "ci-secret")
+		})
+		It("returns forbidden", func() {
+			Expect(found).To(BeTrue())
+			Expect(err).To(MatchError(pkg.ErrApiKeyForbidden))
+		})
+	})
+})
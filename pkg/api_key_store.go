@@ -0,0 +1,142 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+)
+
+type ApiKeyFile string
+
+func (a ApiKeyFile) String() string {
+	return string(a)
+}
+
+// ApiKey is a service account authenticated by a static key.
+// Only the sha256 hex of the key is stored.
+type ApiKey struct {
+	Name                string     `json:"name"`
+	KeySha256           string     `json:"key-sha256"`
+	Groups              GroupNames `json:"groups"`
+	Expires             *time.Time `json:"expires,omitempty"`
+	AllowedPathPrefixes []string   `json:"allowed-path-prefixes,omitempty"`
+}
+
+// Expired returns true if the key has an expiry before now.
+func (a ApiKey) Expired(now time.Time) bool {
+	return a.Expires != nil && now.After(*a.Expires)
+}
+
+// AllowsPath returns true if no prefixes are configured or the clean path is one of them
+// or below one of them. The prefix /api allows /api and /api/x but not /apiadmin.
+func (a ApiKey) AllowsPath(path string) bool {
+	if len(a.AllowedPathPrefixes) == 0 {
+		return true
+	}
+	if !isCleanPath(path) {
+		return false
+	}
+	for _, prefix := range a.AllowedPathPrefixes {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// HashApiKey returns the sha256 hex used in the api key file.
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+//counterfeiter:generate -o ../mocks/api-key-store.go --fake-name ApiKeyStore . ApiKeyStore
+type ApiKeyStore interface {
+	// Find returns the api key matching the given plain key or ErrApiKeyNotFound.
+	Find(ctx context.Context, key string) (*ApiKey, error)
+}
+
+// NewApiKeyFileStore returns a store reading a json list of api keys.
+// The file is reloaded if its modification time changes.
+func NewApiKeyFileStore(apiKeyFile ApiKeyFile) ApiKeyStore {
+	return &apiKeyFileStore{
+		apiKeyFile: apiKeyFile,
+	}
+}
+
+type apiKeyFileStore struct {
+	apiKeyFile ApiKeyFile
+
+	mux     sync.Mutex
+	modTime time.Time
+	apiKeys []ApiKey
+}
+
+func (a *apiKeyFileStore) Find(ctx context.Context, key string) (*ApiKey, error) {
+	apiKeys, err := a.load(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "load api keys failed")
+	}
+	hash := []byte(HashApiKey(key))
+	for _, apiKey := range apiKeys {
+		if subtle.ConstantTimeCompare(hash, []byte(strings.ToLower(apiKey.KeySha256))) == 1 {
+			infof(ctx, 2, "found api key %s", apiKey.Name)
+			return &apiKey, nil
+		}
+	}
+	return nil, ErrApiKeyNotFound
+}
+
+func (a *apiKeyFileStore) load(ctx context.Context) ([]ApiKey, error) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	fileInfo, err := os.Stat(a.apiKeyFile.String())
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "stat api key file %s failed", a.apiKeyFile)
+	}
+	if a.apiKeys != nil && fileInfo.ModTime().Equal(a.modTime) {
+		return a.apiKeys, nil
+	}
+	apiKeys, err := ReadApiKeyFile(ctx, a.apiKeyFile)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "read api key file %s failed", a.apiKeyFile)
+	}
+	infof(ctx, 2, "loaded %d api keys from %s", len(apiKeys), a.apiKeyFile)
+	a.apiKeys = apiKeys
+	a.modTime = fileInfo.ModTime()
+	return a.apiKeys, nil
+}
+
+// ReadApiKeyFile parses and validates the given api key file.
+func ReadApiKeyFile(ctx context.Context, apiKeyFile ApiKeyFile) ([]ApiKey, error) {
+	content, err := os.ReadFile(apiKeyFile.String())
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "read file failed")
+	}
+	apiKeys := []ApiKey{}
+	if err := json.Unmarshal(content, &apiKeys); err != nil {
+		return nil, errors.Wrapf(ctx, err, "unmarshal api keys failed")
+	}
+	for _, apiKey := range apiKeys {
+		if len(apiKey.Name) == 0 {
+			return nil, errors.Errorf(ctx, "api key without name")
+		}
+		if len(apiKey.KeySha256) != sha256.Size*2 {
+			return nil, errors.Errorf(ctx, "api key %s has invalid key-sha256", apiKey.Name)
+		}
+	}
+	return apiKeys, nil
+}
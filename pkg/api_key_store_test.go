@@ -0,0 +1,30 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("ApiKey", func() {
+	DescribeTable("AllowsPath",
+		func(prefixes []string, path string, expected bool) {
+			apiKey := pkg.ApiKey{AllowedPathPrefixes: prefixes}
+			Expect(apiKey.AllowsPath(path)).To(Equal(expected))
+		},
+		Entry("no prefixes", nil, "/admin", true),
+		Entry("equal path", []string{"/api"}, "/api", true),
+		Entry("path below prefix", []string{"/api"}, "/api/x", true),
+		Entry("path with same beginning", []string{"/api"}, "/apiadmin", false),
+		Entry("other path", []string{"/api"}, "/admin", false),
+		Entry("prefix with slash", []string{"/api/"}, "/api/x", true),
+		Entry("prefix with slash and path without", []string{"/api/"}, "/api", false),
+		Entry("second prefix", []string{"/metrics", "/api"}, "/api/x", true),
+		Entry("path leaving prefix", []string{"/api"}, "/api/../admin", false),
+	)
+})
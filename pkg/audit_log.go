@@ -0,0 +1,216 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/syslog"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+)
+
+// AuditEventType is the kind of a security relevant event.
+type AuditEventType string
+
+const (
+	// AuditLoginSuccess is a login with the html form.
+	AuditLoginSuccess AuditEventType = "login_success"
+	// AuditLoginFailure is a rejected login with the html form, basic or digest auth.
+	AuditLoginFailure AuditEventType = "login_failure"
+	// AuditLockout is a user or ip locked after too many failed logins.
+	AuditLockout AuditEventType = "lockout"
+	// AuditVerifySuccess and AuditVerifyFailure are verifications of credentials by the
+	// verifier. Credentials found in the cache are not verified again.
+	AuditVerifySuccess AuditEventType = "verify_success"
+	AuditVerifyFailure AuditEventType = "verify_failure"
+	// AuditVerifyStale are credentials accepted from the cache because the verifier failed.
+	AuditVerifyStale       AuditEventType = "verify_stale"
+	AuditSessionRevoked    AuditEventType = "session_revoked"
+	AuditAdminAction       AuditEventType = "admin_action"
+	AuditAdminLoginFailure AuditEventType = "admin_login_failure"
+)
+
+// AuditReason describes why a login or verification failed.
+type AuditReason string
+
+const (
+	AuditReasonInvalidCredentials AuditReason = "invalid_credentials"
+	AuditReasonMissingGroup       AuditReason = "missing_group"
+	AuditReasonMissingAttribute   AuditReason = "missing_attribute"
+	AuditReasonBackendError       AuditReason = "backend_error"
+	AuditReasonLocked             AuditReason = "locked"
+	AuditReasonTotpInvalid        AuditReason = "totp_invalid"
+)
+
+// AuditEvent is a line of the audit log. It never contains credentials.
+type AuditEvent struct {
+	Time       time.Time      `json:"time"`
+	Type       AuditEventType `json:"type"`
+	User       UserName       `json:"user,omitempty"`
+	ClientIP   string         `json:"client_ip,omitempty"`
+	AuthMethod AuthMethod     `json:"auth_method,omitempty"`
+	Reason     AuditReason    `json:"reason,omitempty"`
+	Detail     string         `json:"detail,omitempty"`
+	RequestID  string         `json:"request_id,omitempty"`
+}
+
+//counterfeiter:generate -o ../mocks/audit-log.go --fake-name AuditLog . AuditLog
+type AuditLog interface {
+	// Log writes the event. The request id is taken from ctx if not set.
+	Log(ctx context.Context, event AuditEvent)
+}
+
+type AuditLogFunc func(ctx context.Context, event AuditEvent)
+
+func (a AuditLogFunc) Log(ctx context.Context, event AuditEvent) {
+	a(ctx, event)
+}
+
+// AuditLogDisabled is used if no audit log is configured.
+var AuditLogDisabled AuditLog = AuditLogFunc(func(ctx context.Context, event AuditEvent) {})
+
+// NewAuditLog writes events as json lines to writer.
+func NewAuditLog(writer io.Writer, now func() time.Time) AuditLog {
+	return &auditLog{
+		writer: writer,
+		now:    now,
+	}
+}
+
+type auditLog struct {
+	now func() time.Time
+
+	mux    sync.Mutex
+	writer io.Writer
+}
+
+func (a *auditLog) Log(ctx context.Context, event AuditEvent) {
+	if event.Time.IsZero() {
+		event.Time = a.now()
+	}
+	if len(event.RequestID) == 0 {
+		event.RequestID = RequestIDFromContext(ctx)
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		glog.Warningf("encode audit event failed: %v", err)
+		return
+	}
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	if _, err := a.writer.Write(append(line, '\n')); err != nil {
+		glog.Warningf("write audit event %s failed: %v", event.Type, err)
+	}
+}
+
+// AuditSyslog is the address of a syslog daemon like udp://localhost:514,
+// tcp://localhost:514 or unix:///dev/log, or "local" for the local syslog daemon.
+type AuditSyslog string
+
+func (a AuditSyslog) String() string {
+	return string(a)
+}
+
+// AuditSyslogLocal writes to the local syslog daemon.
+const AuditSyslogLocal AuditSyslog = "local"
+
+// NewAuditSyslogWriter connects to the syslog daemon. Events are sent with
+// facility auth and severity info.
+func NewAuditSyslogWriter(ctx context.Context, address AuditSyslog) (io.WriteCloser, error) {
+	network, raddr, err := address.parse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := syslog.Dial(network, raddr, syslog.LOG_AUTH|syslog.LOG_INFO, "auth-http-proxy")
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "connect to syslog %s failed", address)
+	}
+	return writer, nil
+}
+
+// Validate returns an error if the address is not local or a supported url.
+func (a AuditSyslog) Validate(ctx context.Context) error {
+	_, _, err := a.parse(ctx)
+	return err
+}
+
+func (a AuditSyslog) parse(ctx context.Context) (string, string, error) {
+	if a == AuditSyslogLocal {
+		return "", "", nil
+	}
+	address, err := url.Parse(a.String())
+	if err != nil {
+		return "", "", errors.Wrapf(ctx, err, "parse syslog address %s failed", a)
+	}
+	switch address.Scheme {
+	case "udp", "tcp":
+		if _, _, err := net.SplitHostPort(address.Host); err != nil {
+			return "", "", errors.Wrapf(ctx, err, "syslog address %s invalid", a)
+		}
+		return address.Scheme, address.Host, nil
+	case "unix", "unixgram":
+		if len(address.Path) == 0 {
+			return "", "", errors.Errorf(ctx, "syslog address %s without path", a)
+		}
+		return address.Scheme, address.Path, nil
+	default:
+		return "", "", errors.Errorf(ctx, "syslog address %s has unsupported scheme", a)
+	}
+}
+
+// auditLoginFailure records the failed login in the login throttle and audits it,
+// followed by a lockout event if the failure locked the user or ip.
+func auditLoginFailure(
+	request *http.Request,
+	auditLog AuditLog,
+	loginThrottle LoginThrottle,
+	user UserName,
+	method AuthMethod,
+	reason AuditReason,
+) {
+	ip := ClientIP(request)
+	loginThrottle.Failure(user, ip)
+	auditLog.Log(request.Context(), AuditEvent{
+		Type:       AuditLoginFailure,
+		User:       user,
+		ClientIP:   ipKey(ip),
+		AuthMethod: method,
+		Reason:     reason,
+	})
+	if retryAfter := loginThrottle.RetryAfter(user, ip); retryAfter > 0 {
+		auditLog.Log(request.Context(), AuditEvent{
+			Type:     AuditLockout,
+			User:     user,
+			ClientIP: ipKey(ip),
+			Detail:   "locked for " + retryAfter.String(),
+		})
+	}
+}
+
+// auditLogin audits a login event that does not change the login throttle.
+func auditLogin(
+	request *http.Request,
+	auditLog AuditLog,
+	eventType AuditEventType,
+	user UserName,
+	method AuthMethod,
+	reason AuditReason,
+) {
+	auditLog.Log(request.Context(), AuditEvent{
+		Type:       eventType,
+		User:       user,
+		ClientIP:   ipKey(ClientIP(request)),
+		AuthMethod: method,
+		Reason:     reason,
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("AuditLog", func() {
+	var buffer *bytes.Buffer
+	var ctx context.Context
+	var now time.Time
+	BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		ctx = context.Background()
+		now = time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	})
+	JustBeforeEach(func() {
+		pkg.NewAuditLog(buffer, func() time.Time { return now }).Log(ctx, pkg.AuditEvent{
+			Type:       pkg.AuditLoginFailure,
+			User:       "alice",
+			ClientIP:   "10.0.0.1",
+			AuthMethod: pkg.AuthMethodForm,
+			Reason:     pkg.AuditReasonInvalidCredentials,
+		})
+	})
+	It("writes json line", func() {
+		Expect(buffer.String()).To(Equal(
+			`{"time":"2026-10-19T12:30:00Z","type":"login_failure","user":"alice",` +
+				`"client_ip":"10.0.0.1","auth_method":"form","reason":"invalid_credentials"}` + "\n",
+		))
+	})
+	Context("request id in context", func() {
+		BeforeEach(func() {
+			ctx = pkg.WithRequestID(ctx, "req-1")
+		})
+		It("writes request id", func() {
+			var event pkg.AuditEvent
+			Expect(json.Unmarshal(buffer.Bytes(), &event)).To(Succeed())
+			Expect(event.RequestID).To(Equal("req-1"))
+		})
+	})
+})
+
+var _ = Describe("AuditSyslog", func() {
+	DescribeTable("Validate",
+		func(address pkg.AuditSyslog, valid bool) {
+			err := address.Validate(context.Background())
+			Expect(err == nil).To(Equal(valid))
+		},
+		Entry("local", pkg.AuditSyslog("local"), true),
+		Entry("udp", pkg.AuditSyslog("udp://localhost:514"), true),
+		Entry("tcp", pkg.AuditSyslog("tcp://10.0.0.1:6514"), true),
+		Entry("unix", pkg.AuditSyslog("unix:///dev/log"), true),
+		Entry("udp without port", pkg.AuditSyslog("udp://localhost"), false),
+		Entry("unix without path", pkg.AuditSyslog("unix://"), false),
+		Entry("unsupported scheme", pkg.AuditSyslog("http://localhost:514"), false),
+	)
+})
@@ -7,30 +7,53 @@ package pkg
 import (
 	"fmt"
 	"net/http"
-
-	"github.com/golang/glog"
 )
 
 func NewAuthBasicHandler(
 	subhandler http.Handler,
 	check Check,
+	apiKeyAuth ApiKeyAuth,
+	clientCertAuth ClientCertAuth,
+	authorizer Authorizer,
+	loginThrottle LoginThrottle,
 	realm string,
+	auditLog AuditLog,
 ) http.Handler {
 	h := new(authBasicHandler)
 	h.handler = subhandler
 	h.check = check
+	h.apiKeyAuth = apiKeyAuth
+	h.clientCertAuth = clientCertAuth
+	h.authorizer = authorizer
+	h.loginThrottle = loginThrottle
 	h.realm = realm
+	h.auditLog = auditLog
 	return h
 }
 
 type authBasicHandler struct {
-	handler http.Handler
-	check   Check
-	realm   string
+	handler        http.Handler
+	check          Check
+	apiKeyAuth     ApiKeyAuth
+	clientCertAuth ClientCertAuth
+	authorizer     Authorizer
+	loginThrottle  LoginThrottle
+	realm          string
+	auditLog       AuditLog
 }
 
 func (a *authBasicHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
-	glog.V(4).Infof("check basic auth")
+	infof(request.Context(), 4, "check basic auth")
+	if servePublic(a.authorizer, a.handler, responseWriter, request) {
+		return
+	}
+	request, served := serveClientCert(a.clientCertAuth, a.authorizer, a.handler, responseWriter, request)
+	if served {
+		return
+	}
+	if serveApiKey(a.apiKeyAuth, a.authorizer, a.handler, responseWriter, request) {
+		return
+	}
 	if err := a.serveHTTP(responseWriter, request); err != nil {
 		responseWriter.Header().Add("WWW-Authenticate", fmt.Sprintf("Basic realm=\"%s\"", a.realm))
 		responseWriter.WriteHeader(http.StatusUnauthorized)
@@ -41,22 +64,47 @@ func (a *authBasicHandler) serveHTTP(
 	responseWriter http.ResponseWriter,
 	request *http.Request,
 ) error {
-	glog.V(4).Infof("check basic auth")
+	infof(request.Context(), 4, "check basic auth")
 	user, pass, err := ParseAuthorizationBasisHttpRequest(request)
 	if err != nil {
-		glog.Warningf("parse header failed: %v", err)
+		warningf(request.Context(), "parse header failed: %v", err)
 		return err
 	}
-	valid, err := a.check.Check(user, pass)
+	ip := ClientIP(request)
+	if retryAfter := a.loginThrottle.RetryAfter(UserName(user), ip); retryAfter > 0 {
+		infof(request.Context(), 2, "user %v from %v is locked for %v", user, ip, retryAfter)
+		a.auditFailure(request, user, AuditReasonLocked)
+		serveTooManyRequests(responseWriter, retryAfter)
+		return nil
+	}
+	valid, err := a.check.Check(request.Context(), user, pass)
 	if err != nil {
-		glog.Warningf("check auth for user %v failed: %v", user, err)
+		warningf(request.Context(), "check auth for user %v failed: %v", user, err)
+		a.auditFailure(request, user, AuditReasonBackendError)
 		return err
 	}
 	if !valid {
-		glog.V(2).Infof("auth invalid for user %v", user)
+		infof(request.Context(), 2, "auth invalid for user %v", user)
+		auditLoginFailure(
+			request,
+			a.auditLog,
+			a.loginThrottle,
+			UserName(user),
+			AuthMethodBasic,
+			AuditReasonInvalidCredentials,
+		)
 		return fmt.Errorf("auth invalid for user %v", user)
 	}
-	request.Header.Add(ForwardForUserHeader, user)
-	a.handler.ServeHTTP(responseWriter, request)
+	a.loginThrottle.Success(UserName(user), ip)
+	serveIdentity(a.authorizer, a.handler, responseWriter, request, &Identity{
+		Name:   UserName(user),
+		Source: IdentitySourcePassword,
+		Method: AuthMethodBasic,
+	})
 	return nil
 }
+
+// auditFailure audits a failed login not counted by the login throttle.
+func (a *authBasicHandler) auditFailure(request *http.Request, user string, reason AuditReason) {
+	auditLogin(request, a.auditLog, AuditLoginFailure, UserName(user), AuthMethodBasic, reason)
+}
@@ -6,8 +6,10 @@ package pkg_test
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -25,12 +27,23 @@ var _ = Describe("AuthBasicHandler", func() {
 	var realm string
 	var subhandler *mocks.HttpHandler
 	var check *mocks.Check
+	var apiKeyAuth *mocks.ApiKeyAuth
+	var clientCertAuth *mocks.ClientCertAuth
+	var authorizer *mocks.Authorizer
+	var loginThrottle *mocks.LoginThrottle
+	var auditLog *mocks.AuditLog
 	BeforeEach(func() {
 		ctx = context.Background()
 
 		subhandler = &mocks.HttpHandler{}
 		check = &mocks.Check{}
 		check.CheckReturns(true, nil)
+		apiKeyAuth = &mocks.ApiKeyAuth{}
+		clientCertAuth = &mocks.ClientCertAuth{}
+		authorizer = &mocks.Authorizer{}
+		authorizer.AuthorizeReturns(true, nil)
+		loginThrottle = &mocks.LoginThrottle{}
+		auditLog = &mocks.AuditLog{}
 		realm = "realm"
 
 		req, err = http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
@@ -38,7 +51,16 @@ var _ = Describe("AuthBasicHandler", func() {
 		recorder = httptest.NewRecorder()
 	})
 	JustBeforeEach(func() {
-		basicHandler = pkg.NewAuthBasicHandler(subhandler, check, realm)
+		basicHandler = pkg.NewAuthBasicHandler(
+			subhandler,
+			check,
+			apiKeyAuth,
+			clientCertAuth,
+			authorizer,
+			loginThrottle,
+			realm,
+			auditLog,
+		)
 		basicHandler.ServeHTTP(recorder, req)
 	})
 	Context("Success", func() {
@@ -56,4 +78,189 @@ var _ = Describe("AuthBasicHandler", func() {
 			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(Equal("myuser"))
 		})
 	})
+	Context("valid api key", func() {
+		BeforeEach(func() {
+			apiKeyAuth.AuthenticateReturns(&pkg.ApiKey{Name: "ci"}, true, nil)
+		})
+		It("does not call check", func() {
+			Expect(check.CheckCallCount()).To(Equal(0))
+		})
+		It("calls subhandler with key name", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := subhandler.ServeHTTPArgsForCall(0)
+			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(Equal("ci"))
+		})
+	})
+	Context("invalid api key", func() {
+		BeforeEach(func() {
+			apiKeyAuth.AuthenticateReturns(nil, true, pkg.ErrApiKeyNotFound)
+		})
+		It("returns unauthorized", func() {
+			Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("forbidden api key", func() {
+		BeforeEach(func() {
+			apiKeyAuth.AuthenticateReturns(nil, true, pkg.ErrApiKeyForbidden)
+		})
+		It("returns forbidden", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("sufficient client certificate", func() {
+		BeforeEach(func() {
+			clientCertAuth.AuthenticateReturns(&pkg.ClientCertUser{Name: "certuser"}, true, nil)
+			clientCertAuth.SufficientReturns(true)
+		})
+		It("does not call check", func() {
+			Expect(check.CheckCallCount()).To(Equal(0))
+		})
+		It("calls subhandler with certificate user", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := subhandler.ServeHTTPArgsForCall(0)
+			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(Equal("certuser"))
+		})
+	})
+	Context("additional client certificate", func() {
+		BeforeEach(func() {
+			clientCertAuth.AuthenticateReturns(&pkg.ClientCertUser{
+				Name:   "myuser",
+				Groups: pkg.GroupNames{"ops"},
+			}, true, nil)
+			clientCertAuth.SufficientReturns(false)
+			req.SetBasicAuth("myuser", "mypass")
+		})
+		It("calls check", func() {
+			Expect(check.CheckCallCount()).To(Equal(1))
+		})
+		It("calls subhandler with password user", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := subhandler.ServeHTTPArgsForCall(0)
+			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(Equal("myuser"))
+		})
+		It("passes certificate user to authorizer", func() {
+			argRequest, _ := authorizer.AuthorizeArgsForCall(0)
+			certUser, ok := pkg.ClientCertUserFromContext(argRequest.Context())
+			Expect(ok).To(BeTrue())
+			Expect(certUser.Groups).To(Equal(pkg.GroupNames{"ops"}))
+		})
+		Context("of other user", func() {
+			BeforeEach(func() {
+				clientCertAuth.AuthenticateReturns(&pkg.ClientCertUser{Name: "certuser"}, true, nil)
+			})
+			It("returns forbidden", func() {
+				Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			})
+			It("does not call subhandler", func() {
+				Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+			})
+		})
+	})
+	Context("client certificate without required groups", func() {
+		BeforeEach(func() {
+			clientCertAuth.AuthenticateReturns(nil, true, errors.New("banana"))
+		})
+		It("returns forbidden", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("not authorized", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("myuser", "mypass")
+			authorizer.AuthorizeReturns(false, nil)
+		})
+		It("returns forbidden", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+		It("authorizes password user", func() {
+			Expect(authorizer.AuthorizeCallCount()).To(Equal(1))
+			_, identity := authorizer.AuthorizeArgsForCall(0)
+			Expect(identity.Name).To(Equal(pkg.UserName("myuser")))
+			Expect(identity.Source).To(Equal(pkg.IdentitySourcePassword))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("invalid password", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("myuser", "wrong")
+			req.RemoteAddr = "1.2.3.4:1234"
+			check.CheckReturns(false, nil)
+		})
+		It("returns unauthorized", func() {
+			Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		})
+		It("records failure", func() {
+			Expect(loginThrottle.FailureCallCount()).To(Equal(1))
+			argUser, argIP := loginThrottle.FailureArgsForCall(0)
+			Expect(argUser).To(Equal(pkg.UserName("myuser")))
+			Expect(argIP.String()).To(Equal("1.2.3.4"))
+		})
+		It("audits login failure", func() {
+			Expect(auditLog.LogCallCount()).To(Equal(1))
+			_, event := auditLog.LogArgsForCall(0)
+			Expect(event.Type).To(Equal(pkg.AuditLoginFailure))
+			Expect(event.User).To(Equal(pkg.UserName("myuser")))
+			Expect(event.ClientIP).To(Equal("1.2.3.4"))
+			Expect(event.AuthMethod).To(Equal(pkg.AuthMethodBasic))
+			Expect(event.Reason).To(Equal(pkg.AuditReasonInvalidCredentials))
+		})
+		Context("locking user", func() {
+			BeforeEach(func() {
+				loginThrottle.RetryAfterReturnsOnCall(1, time.Minute)
+			})
+			It("audits lockout", func() {
+				Expect(auditLog.LogCallCount()).To(Equal(2))
+				_, event := auditLog.LogArgsForCall(1)
+				Expect(event.Type).To(Equal(pkg.AuditLockout))
+				Expect(event.User).To(Equal(pkg.UserName("myuser")))
+			})
+		})
+	})
+	Context("locked user", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("myuser", "mypass")
+			loginThrottle.RetryAfterReturns(1500 * time.Millisecond)
+		})
+		It("returns too many requests", func() {
+			Expect(recorder.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(recorder.Header().Get("Retry-After")).To(Equal("2"))
+		})
+		It("does not call check", func() {
+			Expect(check.CheckCallCount()).To(Equal(0))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+		It("audits login failure", func() {
+			Expect(auditLog.LogCallCount()).To(Equal(1))
+			_, event := auditLog.LogArgsForCall(0)
+			Expect(event.Type).To(Equal(pkg.AuditLoginFailure))
+			Expect(event.Reason).To(Equal(pkg.AuditReasonLocked))
+		})
+	})
+	Context("public request", func() {
+		BeforeEach(func() {
+			authorizer.PublicReturns(true)
+			req.Header.Set(pkg.ForwardForUserHeader, "spoofed")
+		})
+		It("does not call check", func() {
+			Expect(check.CheckCallCount()).To(Equal(0))
+		})
+		It("calls subhandler without user", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := subhandler.ServeHTTPArgsForCall(0)
+			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(BeEmpty())
+		})
+	})
 })
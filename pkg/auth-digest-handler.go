@@ -0,0 +1,197 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/golang/glog"
+)
+
+// NewAuthDigestHandler authenticates requests with RFC 7616 digest auth (qop=auth).
+// Users with a valid response are checked by the userVerifier with an empty password,
+// so required groups and attributes apply as with the other kinds.
+func NewAuthDigestHandler(
+	subhandler http.Handler,
+	ha1Store DigestHa1Store,
+	nonceStore DigestNonceStore,
+	userVerifier Verifier,
+	apiKeyAuth ApiKeyAuth,
+	clientCertAuth ClientCertAuth,
+	authorizer Authorizer,
+	loginThrottle LoginThrottle,
+	realm string,
+	auditLog AuditLog,
+) http.Handler {
+	h := new(authDigestHandler)
+	h.handler = subhandler
+	h.ha1Store = ha1Store
+	h.nonceStore = nonceStore
+	h.userVerifier = userVerifier
+	h.apiKeyAuth = apiKeyAuth
+	h.clientCertAuth = clientCertAuth
+	h.authorizer = authorizer
+	h.loginThrottle = loginThrottle
+	h.realm = realm
+	h.auditLog = auditLog
+	h.opaque = createDigestOpaque()
+	return h
+}
+
+type authDigestHandler struct {
+	handler        http.Handler
+	ha1Store       DigestHa1Store
+	nonceStore     DigestNonceStore
+	userVerifier   Verifier
+	apiKeyAuth     ApiKeyAuth
+	clientCertAuth ClientCertAuth
+	authorizer     Authorizer
+	loginThrottle  LoginThrottle
+	realm          string
+	auditLog       AuditLog
+	opaque         string
+}
+
+func (a *authDigestHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	infof(request.Context(), 4, "check digest auth")
+	if servePublic(a.authorizer, a.handler, responseWriter, request) {
+		return
+	}
+	request, served := serveClientCert(a.clientCertAuth, a.authorizer, a.handler, responseWriter, request)
+	if served {
+		return
+	}
+	if serveApiKey(a.apiKeyAuth, a.authorizer, a.handler, responseWriter, request) {
+		return
+	}
+	stale, err := a.serveHTTP(responseWriter, request)
+	if err != nil {
+		infof(request.Context(), 2, "digest auth failed: %v", err)
+		nonce, err := a.nonceStore.Create()
+		if err != nil {
+			warningf(request.Context(), "create digest nonce failed: %v", err)
+			http.Error(responseWriter, "create nonce failed", http.StatusInternalServerError)
+			return
+		}
+		for _, algorithm := range DigestAlgorithms {
+			responseWriter.Header().Add(
+				"WWW-Authenticate",
+				CreateDigestChallenge(a.realm, nonce, a.opaque, algorithm, stale),
+			)
+		}
+		responseWriter.WriteHeader(http.StatusUnauthorized)
+	}
+}
+
+// serveHTTP returns stale=true if the credentials are valid but the nonce needs to be renewed.
+func (a *authDigestHandler) serveHTTP(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) (bool, error) {
+	credentials, err := ParseDigestAuthorizationHeader(request.Header.Get("Authorization"))
+	if err != nil {
+		return false, err
+	}
+	if credentials.Realm != a.realm {
+		return false, fmt.Errorf("realm %s invalid", credentials.Realm)
+	}
+	if credentials.Opaque != a.opaque {
+		return false, fmt.Errorf("opaque invalid")
+	}
+	if credentials.Qop != "auth" {
+		return false, fmt.Errorf("qop %s not supported", credentials.Qop)
+	}
+	if !credentials.Algorithm.Valid() {
+		return false, fmt.Errorf("algorithm %s not supported", credentials.Algorithm)
+	}
+	if credentials.Userhash {
+		return false, fmt.Errorf("userhash not supported")
+	}
+	if credentials.URI != request.URL.RequestURI() {
+		return false, fmt.Errorf("uri %s does not match request", credentials.URI)
+	}
+	nc, err := strconv.ParseUint(credentials.Nc, 16, 64)
+	if err != nil {
+		return false, fmt.Errorf("nc %s invalid", credentials.Nc)
+	}
+	user := UserName(credentials.Username)
+	ip := ClientIP(request)
+	if retryAfter := a.loginThrottle.RetryAfter(user, ip); retryAfter > 0 {
+		infof(request.Context(), 2, "user %v from %v is locked for %v", user, ip, retryAfter)
+		a.auditFailure(request, user, AuditReasonLocked)
+		serveTooManyRequests(responseWriter, retryAfter)
+		return false, nil
+	}
+	ha1, found, err := a.ha1Store.Ha1(
+		request.Context(),
+		user,
+		a.realm,
+		credentials.Algorithm,
+	)
+	if err != nil {
+		warningf(request.Context(), "get ha1 for user %v failed: %v", user, err)
+		a.auditFailure(request, user, AuditReasonBackendError)
+		return false, err
+	}
+	expected := credentials.ExpectedResponse(ha1, request.Method)
+	if !found ||
+		subtle.ConstantTimeCompare([]byte(expected), []byte(credentials.Response)) != 1 {
+		auditLoginFailure(
+			request,
+			a.auditLog,
+			a.loginThrottle,
+			user,
+			AuthMethodDigest,
+			AuditReasonInvalidCredentials,
+		)
+		return false, fmt.Errorf("auth invalid for user %v", user)
+	}
+	valid, stale := a.nonceStore.Use(credentials.Nonce, nc)
+	if !valid {
+		return stale, fmt.Errorf("nonce invalid for user %v", user)
+	}
+	valid, err = a.userVerifier.Verify(request.Context(), user, "")
+	if err != nil {
+		warningf(request.Context(), "verify user %v failed: %v", user, err)
+		a.auditFailure(request, user, AuditReasonBackendError)
+		return false, err
+	}
+	if !valid {
+		auditLoginFailure(
+			request,
+			a.auditLog,
+			a.loginThrottle,
+			user,
+			AuthMethodDigest,
+			AuditReasonInvalidCredentials,
+		)
+		return false, fmt.Errorf("user %v has not required groups or attributes", user)
+	}
+	a.loginThrottle.Success(user, ip)
+	serveIdentity(a.authorizer, a.handler, responseWriter, request, &Identity{
+		Name:   user,
+		Source: IdentitySourceDigest,
+		Method: AuthMethodDigest,
+	})
+	return false, nil
+}
+
+// auditFailure audits a failed login not counted by the login throttle.
+func (a *authDigestHandler) auditFailure(request *http.Request, user UserName, reason AuditReason) {
+	auditLogin(request, a.auditLog, AuditLoginFailure, user, AuthMethodDigest, reason)
+}
+
+func createDigestOpaque() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		glog.Warningf("create digest opaque failed: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
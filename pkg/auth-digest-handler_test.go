@@ -0,0 +1,273 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("AuthDigestHandler", func() {
+	var ctx context.Context
+	var now time.Time
+	var realm string
+	var digestHandler http.Handler
+	var subhandler *mocks.HttpHandler
+	var apiKeyAuth *mocks.ApiKeyAuth
+	var clientCertAuth *mocks.ClientCertAuth
+	var nonceStore pkg.DigestNonceStore
+	var userVerifier *mocks.Verifier
+	var auditLog *mocks.AuditLog
+	var serve func(authorization string) *httptest.ResponseRecorder
+	var challenge func(algorithm pkg.DigestAlgorithm) *pkg.DigestCredentials
+	var authorization func(
+		credentials *pkg.DigestCredentials,
+		password string,
+		nc int,
+	) string
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Unix(1700000000, 0)
+		realm = "realm"
+		subhandler = &mocks.HttpHandler{}
+		apiKeyAuth = &mocks.ApiKeyAuth{}
+		clientCertAuth = &mocks.ClientCertAuth{}
+		userVerifier = &mocks.Verifier{}
+		userVerifier.VerifyReturns(true, nil)
+		auditLog = &mocks.AuditLog{}
+		nonceStore = pkg.NewDigestNonceStore(
+			pkg.DigestNonceTTL(time.Minute),
+			func() time.Time { return now },
+		)
+		digestFile := filepath.Join(GinkgoT().TempDir(), "htdigest")
+		Expect(os.WriteFile(digestFile, []byte(strings.Join([]string{
+			fmt.Sprintf(
+				"myuser:%s:%s",
+				realm,
+				pkg.DigestHa1(pkg.DigestAlgorithmMD5, "myuser", realm, "mypass"),
+			),
+			fmt.Sprintf(
+				"myuser:%s:%s",
+				realm,
+				pkg.DigestHa1(pkg.DigestAlgorithmSHA256, "myuser", realm, "mypass"),
+			),
+		}, "\n")), 0600)).To(Succeed())
+
+		digestHandler = pkg.NewAuthDigestHandler(
+			subhandler,
+			pkg.NewDigestHtdigestStore(pkg.DigestFile(digestFile)),
+			nonceStore,
+			userVerifier,
+			apiKeyAuth,
+			clientCertAuth,
+			pkg.AuthorizerDisabled,
+			pkg.NewLoginThrottle(
+				3,
+				10,
+				pkg.LoginLockout(time.Minute),
+				pkg.LoginLockoutMax(time.Hour),
+				100,
+				func() time.Time { return now },
+			),
+			realm,
+			auditLog,
+		)
+		serve = func(authorization string) *httptest.ResponseRecorder {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/path?q=1", nil)
+			Expect(err).To(BeNil())
+			if len(authorization) > 0 {
+				req.Header.Set("Authorization", authorization)
+			}
+			recorder := httptest.NewRecorder()
+			digestHandler.ServeHTTP(recorder, req)
+			return recorder
+		}
+		challenge = func(algorithm pkg.DigestAlgorithm) *pkg.DigestCredentials {
+			recorder := serve("")
+			Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+			for _, value := range recorder.Header().Values("WWW-Authenticate") {
+				if !strings.Contains(value, "algorithm="+algorithm.String()+",") {
+					continue
+				}
+				result := &pkg.DigestCredentials{Algorithm: algorithm}
+				for _, part := range strings.Split(strings.TrimPrefix(value, "Digest "), ", ") {
+					kv := strings.SplitN(part, "=", 2)
+					switch kv[0] {
+					case "nonce":
+						result.Nonce = strings.Trim(kv[1], `"`)
+					case "opaque":
+						result.Opaque = strings.Trim(kv[1], `"`)
+					}
+				}
+				return result
+			}
+			Fail("challenge for algorithm " + algorithm.String() + " missing")
+			return nil
+		}
+		authorization = func(
+			credentials *pkg.DigestCredentials,
+			password string,
+			nc int,
+		) string {
+			credentials.Username = "myuser"
+			credentials.Realm = realm
+			credentials.URI = "/path?q=1"
+			credentials.Cnonce = "0a4f113b"
+			credentials.Qop = "auth"
+			credentials.Nc = fmt.Sprintf("%08x", nc)
+			response := credentials.ExpectedResponse(
+				pkg.DigestHa1(credentials.Algorithm, "myuser", realm, pkg.Password(password)),
+				http.MethodGet,
+			)
+			return fmt.Sprintf(
+				`Digest username="%s", realm="%s", nonce="%s", uri="%s", algorithm=%s, `+
+					`response="%s", opaque="%s", qop=auth, nc=%s, cnonce="%s"`,
+				credentials.Username,
+				credentials.Realm,
+				credentials.Nonce,
+				credentials.URI,
+				credentials.Algorithm,
+				response,
+				credentials.Opaque,
+				credentials.Nc,
+				credentials.Cnonce,
+			)
+		}
+	})
+	It("challenges with SHA-256 and MD5", func() {
+		recorder := serve("")
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		values := recorder.Header().Values("WWW-Authenticate")
+		Expect(values).To(HaveLen(2))
+		Expect(values[0]).To(ContainSubstring("algorithm=SHA-256"))
+		Expect(values[1]).To(ContainSubstring("algorithm=MD5"))
+		Expect(values[0]).To(ContainSubstring(`qop="auth"`))
+		Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+	})
+	DescribeTable("accepts valid response",
+		func(algorithm pkg.DigestAlgorithm) {
+			recorder := serve(authorization(challenge(algorithm), "mypass", 1))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := subhandler.ServeHTTPArgsForCall(0)
+			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(Equal("myuser"))
+		},
+		Entry("SHA-256", pkg.DigestAlgorithmSHA256),
+		Entry("MD5", pkg.DigestAlgorithmMD5),
+	)
+	It("rejects wrong password", func() {
+		recorder := serve(authorization(challenge(pkg.DigestAlgorithmSHA256), "wrong", 1))
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		Expect(recorder.Header().Get("WWW-Authenticate")).To(ContainSubstring("stale=false"))
+		Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+	})
+	It("audits wrong password", func() {
+		serve(authorization(challenge(pkg.DigestAlgorithmSHA256), "wrong", 1))
+		Expect(auditLog.LogCallCount()).To(Equal(1))
+		_, event := auditLog.LogArgsForCall(0)
+		Expect(event.Type).To(Equal(pkg.AuditLoginFailure))
+		Expect(event.User).To(Equal(pkg.UserName("myuser")))
+		Expect(event.AuthMethod).To(Equal(pkg.AuthMethodDigest))
+		Expect(event.Reason).To(Equal(pkg.AuditReasonInvalidCredentials))
+	})
+	It("returns too many requests after max failures", func() {
+		for i := 0; i < 3; i++ {
+			Expect(serve(authorization(challenge(pkg.DigestAlgorithmSHA256), "wrong", 1)).Code).
+				To(Equal(http.StatusUnauthorized))
+		}
+		recorder := serve(authorization(challenge(pkg.DigestAlgorithmSHA256), "mypass", 1))
+		Expect(recorder.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(recorder.Header().Get("Retry-After")).To(Equal("60"))
+		Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+	})
+	It("verifies groups of user", func() {
+		Expect(serve(authorization(challenge(pkg.DigestAlgorithmSHA256), "mypass", 1)).Code).
+			To(Equal(http.StatusOK))
+		Expect(userVerifier.VerifyCallCount()).To(Equal(1))
+		_, argUser, argPassword := userVerifier.VerifyArgsForCall(0)
+		Expect(argUser).To(Equal(pkg.UserName("myuser")))
+		Expect(argPassword).To(BeEmpty())
+	})
+	It("rejects user without required groups", func() {
+		userVerifier.VerifyReturns(false, nil)
+		recorder := serve(authorization(challenge(pkg.DigestAlgorithmSHA256), "mypass", 1))
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+	})
+	It("does not verify groups of wrong password", func() {
+		serve(authorization(challenge(pkg.DigestAlgorithmSHA256), "wrong", 1))
+		Expect(userVerifier.VerifyCallCount()).To(Equal(0))
+	})
+	It("accepts increasing nonce count", func() {
+		credentials := challenge(pkg.DigestAlgorithmSHA256)
+		Expect(serve(authorization(credentials, "mypass", 1)).Code).To(Equal(http.StatusOK))
+		Expect(serve(authorization(credentials, "mypass", 2)).Code).To(Equal(http.StatusOK))
+		Expect(subhandler.ServeHTTPCallCount()).To(Equal(2))
+	})
+	It("rejects replayed nonce count", func() {
+		header := authorization(challenge(pkg.DigestAlgorithmSHA256), "mypass", 1)
+		Expect(serve(header).Code).To(Equal(http.StatusOK))
+		recorder := serve(header)
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		Expect(recorder.Header().Get("WWW-Authenticate")).To(ContainSubstring("stale=false"))
+		Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+	})
+	It("marks expired nonce as stale", func() {
+		credentials := challenge(pkg.DigestAlgorithmSHA256)
+		now = now.Add(2 * time.Minute)
+		recorder := serve(authorization(credentials, "mypass", 1))
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		Expect(recorder.Header().Get("WWW-Authenticate")).To(ContainSubstring("stale=true"))
+		Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+	})
+	It("rejects other uri", func() {
+		credentials := challenge(pkg.DigestAlgorithmSHA256)
+		header := strings.Replace(
+			authorization(credentials, "mypass", 1),
+			`uri="/path?q=1"`,
+			`uri="/other"`,
+			1,
+		)
+		Expect(serve(header).Code).To(Equal(http.StatusUnauthorized))
+		Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+	})
+	Context("additional client certificate", func() {
+		var credentials *pkg.DigestCredentials
+		BeforeEach(func() {
+			credentials = challenge(pkg.DigestAlgorithmSHA256)
+			clientCertAuth.SufficientReturns(false)
+		})
+		It("accepts certificate of user", func() {
+			clientCertAuth.AuthenticateReturns(&pkg.ClientCertUser{Name: "myuser"}, true, nil)
+			Expect(serve(authorization(credentials, "mypass", 1)).Code).To(Equal(http.StatusOK))
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+		})
+		It("rejects certificate of other user", func() {
+			clientCertAuth.AuthenticateReturns(&pkg.ClientCertUser{Name: "alice"}, true, nil)
+			Expect(serve(authorization(credentials, "mypass", 1)).Code).
+				To(Equal(http.StatusForbidden))
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	It("calls subhandler for valid api key", func() {
+		apiKeyAuth.AuthenticateReturns(&pkg.ApiKey{Name: "ci"}, true, nil)
+		Expect(serve("").Code).To(Equal(http.StatusOK))
+		Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+		_, argRequest := subhandler.ServeHTTPArgsForCall(0)
+		Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(Equal("ci"))
+	})
+})
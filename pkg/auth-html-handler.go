@@ -5,42 +5,78 @@
 package pkg
 
 import (
+	"context"
+	"fmt"
 	"html/template"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
-
-	"github.com/golang/glog"
 )
 
 const (
 	fieldNameLogin    = "login"
 	fieldNamePassword = "password"
+	fieldNameTotp     = "totp"
 	cookieName        = "auth-http-proxy-token"
+	totpCookieName    = "auth-http-proxy-totp"
 	loginDuration     = 24 * time.Hour
+	totpDuration      = 5 * time.Minute
 )
 
 func NewAuthHtmlHandler(
 	subhandler http.Handler,
 	check Check,
+	apiKeyAuth ApiKeyAuth,
+	clientCertAuth ClientCertAuth,
+	authorizer Authorizer,
+	loginThrottle LoginThrottle,
+	totpVerifier TotpVerifier,
 	crypter Crypter,
+	sessionStore SessionStore,
+	auditLog AuditLog,
 ) http.Handler {
 	h := new(authHtmlHandler)
 	h.subhandler = subhandler
 	h.check = check
+	h.apiKeyAuth = apiKeyAuth
+	h.clientCertAuth = clientCertAuth
+	h.authorizer = authorizer
+	h.loginThrottle = loginThrottle
+	h.totpVerifier = totpVerifier
 	h.crypter = crypter
+	h.sessionStore = sessionStore
+	h.auditLog = auditLog
 	return h
 }
 
 type authHtmlHandler struct {
-	subhandler http.Handler
-	check      Check
-	crypter    Crypter
+	subhandler     http.Handler
+	check          Check
+	apiKeyAuth     ApiKeyAuth
+	clientCertAuth ClientCertAuth
+	authorizer     Authorizer
+	loginThrottle  LoginThrottle
+	totpVerifier   TotpVerifier
+	crypter        Crypter
+	sessionStore   SessionStore
+	auditLog       AuditLog
 }
 
 func (h *authHtmlHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
-	glog.V(4).Infof("check html auth")
+	infof(request.Context(), 4, "check html auth")
+	if servePublic(h.authorizer, h.subhandler, responseWriter, request) {
+		return
+	}
+	request, served := serveClientCert(h.clientCertAuth, h.authorizer, h.subhandler, responseWriter, request)
+	if served {
+		return
+	}
+	if serveApiKey(h.apiKeyAuth, h.authorizer, h.subhandler, responseWriter, request) {
+		return
+	}
 	if err := h.serveHTTP(responseWriter, request); err != nil {
-		glog.Warningf("check html auth failed: %v", err)
+		warningf(request.Context(), "check html auth failed: %v", err)
 		responseWriter.WriteHeader(http.StatusInternalServerError)
 	}
 }
@@ -49,106 +85,279 @@ func (h *authHtmlHandler) serveHTTP(
 	responseWriter http.ResponseWriter,
 	request *http.Request,
 ) error {
-	glog.V(4).Infof("check html auth")
-	valid, err := h.validateLogin(request)
+	infof(request.Context(), 4, "check html auth")
+	if h.serveLockedBasic(responseWriter, request) {
+		return nil
+	}
+	user, method, valid, err := h.validateLogin(request)
 	if err != nil {
-		glog.V(2).Infof("validate login failed: %v", err)
+		infof(request.Context(), 2, "validate login failed: %v", err)
 		return err
 	}
 	if valid {
-		glog.V(4).Infof("login is valid, forward request")
-		h.subhandler.ServeHTTP(responseWriter, request)
+		infof(request.Context(), 4, "login is valid, forward request")
+		serveIdentity(h.authorizer, h.subhandler, responseWriter, request, &Identity{
+			Name:   UserName(user),
+			Source: IdentitySourcePassword,
+			Method: method,
+		})
 		return nil
 	}
 	return h.validateLoginParams(responseWriter, request)
 }
 
-func (h *authHtmlHandler) validateLogin(request *http.Request) (bool, error) {
-	if valid, _ := h.validateLoginBasic(request); valid {
-		return true, nil
+// serveLockedBasic answers basic auth of a locked user with 429 like authBasicHandler
+// and returns true if the request was served.
+func (h *authHtmlHandler) serveLockedBasic(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) bool {
+	user, _, err := ParseAuthorizationBasisHttpRequest(request)
+	if err != nil {
+		return false
+	}
+	ip := ClientIP(request)
+	retryAfter := h.loginThrottle.RetryAfter(UserName(user), ip)
+	if retryAfter <= 0 {
+		return false
+	}
+	infof(request.Context(), 2, "user %v from %v is locked for %v", user, ip, retryAfter)
+	h.auditFailure(request, user, AuthMethodBasic, AuditReasonLocked)
+	serveTooManyRequests(responseWriter, retryAfter)
+	return true
+}
+
+// validateLogin returns the user and the method of valid basic auth or cookie credentials.
+func (h *authHtmlHandler) validateLogin(request *http.Request) (string, AuthMethod, bool, error) {
+	if user, valid, _ := h.validateLoginBasic(request); valid {
+		return user, AuthMethodBasic, true, nil
 	}
-	valid, err := h.validateLoginCookie(request)
+	user, valid, err := h.validateLoginCookie(request)
 	if err != nil {
-		return false, err
+		return "", "", false, err
 	}
-	return valid, nil
+	return user, AuthMethodCookie, valid, nil
 }
 
-func (h *authHtmlHandler) validateLoginBasic(request *http.Request) (bool, error) {
-	glog.V(4).Infof("validate login via basic")
+func (h *authHtmlHandler) validateLoginBasic(request *http.Request) (string, bool, error) {
+	infof(request.Context(), 4, "validate login via basic")
 	user, pass, err := ParseAuthorizationBasisHttpRequest(request)
 	if err != nil {
-		glog.V(2).Infof("parse basic authorization header failed: %v", err)
-		return false, err
+		infof(request.Context(), 2, "parse basic authorization header failed: %v", err)
+		return "", false, err
+	}
+	result, err := h.check.Check(request.Context(), user, pass)
+	if err != nil {
+		warningf(request.Context(), "check auth for user %v failed: %v", user, err)
+		h.auditFailure(request, user, AuthMethodBasic, AuditReasonBackendError)
+		return "", false, err
 	}
-	result, err := h.check.Check(user, pass)
+	if !result {
+		auditLoginFailure(
+			request,
+			h.auditLog,
+			h.loginThrottle,
+			UserName(user),
+			AuthMethodBasic,
+			AuditReasonInvalidCredentials,
+		)
+		return "", false, nil
+	}
+	totpEnabled, err := h.totpVerifier.Enabled(request.Context(), UserName(user))
 	if err != nil {
-		glog.Warningf("check auth for user %v failed: %v", user, err)
-		return false, err
+		warningf(request.Context(), "check totp for user %v failed: %v", user, err)
+		return "", false, err
+	}
+	if totpEnabled {
+		infof(request.Context(), 2, "user %v requires totp => basic auth not allowed", user)
+		return "", false, nil
 	}
-	request.Header.Set(ForwardForUserHeader, user)
-	glog.V(4).Infof("validate login via basic => %v", result)
-	return result, nil
+	infof(request.Context(), 4, "validate login via basic => %v", result)
+	return user, result, nil
 }
 
-func (h *authHtmlHandler) validateLoginCookie(request *http.Request) (bool, error) {
-	glog.V(4).Infof("validate login via cookie")
+func (h *authHtmlHandler) validateLoginCookie(request *http.Request) (string, bool, error) {
+	infof(request.Context(), 4, "validate login via cookie")
 	cookie, err := request.Cookie(cookieName)
 	if err != nil {
-		glog.V(2).Infof("get cookie %v failed: %v", cookieName, err)
-		return false, nil
+		infof(request.Context(), 2, "get cookie %v failed: %v", cookieName, err)
+		return "", false, nil
 	}
 	data, err := h.crypter.Decrypt(cookie.Value)
 	if err != nil {
-		glog.V(2).Infof("decrypt cookie value failed: %v", err)
-		return false, nil
+		infof(request.Context(), 2, "decrypt cookie value failed: %v", err)
+		return "", false, nil
 	}
-	user, pass, err := ParseAuthorizationToken(data)
+	session, pass, err := parseSessionCookie(data)
 	if err != nil {
-		glog.V(2).Infof("parse cookie failed: %v", err)
-		return false, nil
+		infof(request.Context(), 2, "parse cookie failed: %v", err)
+		return "", false, nil
+	}
+	user := session.User.String()
+	if !h.sessionStore.Valid(*session, ClientIP(request)) {
+		infof(request.Context(), 2, "session of user %v is not valid", user)
+		return "", false, nil
 	}
-	result, err := h.check.Check(user, pass)
+	result, err := h.check.Check(request.Context(), user, pass)
 	if err != nil {
-		glog.Warningf("check auth for user %v failed: %v", user, err)
-		return false, err
+		warningf(request.Context(), "check auth for user %v failed: %v", user, err)
+		return "", false, err
 	}
-	request.Header.Set(ForwardForUserHeader, user)
-	glog.V(4).Infof("validate login via cookie => %v", result)
-	return result, nil
+	infof(request.Context(), 4, "validate login via cookie => %v", result)
+	return user, result, nil
 }
 
 func (h *authHtmlHandler) validateLoginParams(
 	responseWriter http.ResponseWriter,
 	request *http.Request,
 ) error {
-	glog.V(4).Infof("validate login via params")
+	infof(request.Context(), 4, "validate login via params")
 	request.Body = http.MaxBytesReader(responseWriter, request.Body, 1<<20)
+	if totpCode := request.FormValue(fieldNameTotp); len(totpCode) > 0 {
+		return h.validateLoginTotp(responseWriter, request, totpCode)
+	}
 	login := request.FormValue(fieldNameLogin)
 	password := request.FormValue(fieldNamePassword)
 	if len(login) == 0 || len(password) == 0 {
-		glog.V(4).Infof("login or password empty => skip")
-		return h.loginForm(responseWriter)
+		infof(request.Context(), 4, "login or password empty => skip")
+		return h.loginForm(request.Context(), responseWriter)
+	}
+	ip := ClientIP(request)
+	if retryAfter := h.loginThrottle.RetryAfter(UserName(login), ip); retryAfter > 0 {
+		infof(request.Context(), 2, "user %v from %v is locked for %v", login, ip, retryAfter)
+		loginsTotal.WithLabelValues(OutcomeLocked).Inc()
+		h.auditFailure(request, login, AuthMethodForm, AuditReasonLocked)
+		serveTooManyRequests(responseWriter, retryAfter)
+		return nil
+	}
+	valid, err := h.check.Check(request.Context(), login, password)
+	if err != nil {
+		infof(request.Context(), 2, "check login failed: %v", err)
+		loginsTotal.WithLabelValues(OutcomeError).Inc()
+		h.auditFailure(request, login, AuthMethodForm, AuditReasonBackendError)
+		return err
+	}
+	if !valid {
+		infof(request.Context(), 4, "login failed, show login form")
+		loginsTotal.WithLabelValues(OutcomeInvalid).Inc()
+		auditLoginFailure(
+			request,
+			h.auditLog,
+			h.loginThrottle,
+			UserName(login),
+			AuthMethodForm,
+			AuditReasonInvalidCredentials,
+		)
+		return h.loginForm(request.Context(), responseWriter)
+	}
+	totpEnabled, err := h.totpVerifier.Enabled(request.Context(), UserName(login))
+	if err != nil {
+		infof(request.Context(), 2, "check totp failed: %v", err)
+		return err
+	}
+	if totpEnabled {
+		infof(request.Context(), 4, "password valid, ask for totp code")
+		if err := h.setTotpCookie(responseWriter, request, login, password); err != nil {
+			return err
+		}
+		return h.totpForm(request.Context(), responseWriter)
+	}
+	return h.loginSuccess(responseWriter, request, login, password)
+}
+
+// validateLoginTotp completes a login started with a valid password.
+func (h *authHtmlHandler) validateLoginTotp(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	totpCode string,
+) error {
+	infof(request.Context(), 4, "validate login via totp")
+	login, password, err := h.parseTotpCookie(request)
+	if err != nil {
+		infof(request.Context(), 2, "parse totp cookie failed: %v", err)
+		return h.loginForm(request.Context(), responseWriter)
+	}
+	ip := ClientIP(request)
+	if retryAfter := h.loginThrottle.RetryAfter(UserName(login), ip); retryAfter > 0 {
+		infof(request.Context(), 2, "user %v from %v is locked for %v", login, ip, retryAfter)
+		loginsTotal.WithLabelValues(OutcomeLocked).Inc()
+		h.auditFailure(request, login, AuthMethodForm, AuditReasonLocked)
+		serveTooManyRequests(responseWriter, retryAfter)
+		return nil
 	}
-	valid, err := h.check.Check(login, password)
+	valid, err := h.check.Check(request.Context(), login, password)
 	if err != nil {
-		glog.V(2).Infof("check login failed: %v", err)
+		infof(request.Context(), 2, "check login failed: %v", err)
+		h.auditFailure(request, login, AuthMethodForm, AuditReasonBackendError)
 		return err
 	}
 	if !valid {
-		glog.V(4).Infof("login failed, show login form")
-		return h.loginForm(responseWriter)
+		infof(request.Context(), 4, "login failed, show login form")
+		h.auditFailure(request, login, AuthMethodForm, AuditReasonInvalidCredentials)
+		return h.loginForm(request.Context(), responseWriter)
 	}
-	glog.V(4).Infof("login success, set cookie")
-	data, err := h.crypter.Encrypt(CreateAuthorizationToken(login, password))
+	valid, err = h.totpVerifier.Verify(request.Context(), UserName(login), totpCode)
 	if err != nil {
-		glog.V(2).Infof("encrypt failed: %v", err)
+		infof(request.Context(), 2, "verify totp failed: %v", err)
+		loginsTotal.WithLabelValues(OutcomeError).Inc()
+		h.auditFailure(request, login, AuthMethodForm, AuditReasonBackendError)
+		return err
+	}
+	if !valid {
+		infof(request.Context(), 4, "totp invalid, show totp form")
+		loginsTotal.WithLabelValues(OutcomeInvalid).Inc()
+		auditLoginFailure(
+			request,
+			h.auditLog,
+			h.loginThrottle,
+			UserName(login),
+			AuthMethodForm,
+			AuditReasonTotpInvalid,
+		)
+		return h.totpForm(request.Context(), responseWriter)
+	}
+	http.SetCookie(responseWriter, &http.Cookie{ // #nosec G124
+		Name:     totpCookieName,
+		Value:    "",
+		MaxAge:   -1,
+		Path:     "/",
+		Domain:   request.URL.Host,
+		HttpOnly: true,
+		Secure:   isSecureRequest(request),
+		SameSite: http.SameSiteStrictMode,
+	})
+	return h.loginSuccess(responseWriter, request, login, password)
+}
+
+func (h *authHtmlHandler) loginSuccess(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	login string,
+	password string,
+) error {
+	if !clientCertUserMatches(request, UserName(login)) {
+		responseWriter.WriteHeader(http.StatusForbidden)
+		return nil
+	}
+	infof(request.Context(), 4, "login success, set cookie")
+	loginsTotal.WithLabelValues(OutcomeSuccess).Inc()
+	recordAuth(request.Context(), UserName(login), AuthMethodForm)
+	auditLogin(request, h.auditLog, AuditLoginSuccess, UserName(login), AuthMethodForm, "")
+	h.loginThrottle.Success(UserName(login), ClientIP(request))
+	session, err := h.sessionStore.Create(UserName(login), ClientIP(request), createExpires())
+	if err != nil {
+		infof(request.Context(), 2, "create session failed: %v", err)
+		return err
+	}
+	data, err := h.crypter.Encrypt(createSessionCookie(session, password))
+	if err != nil {
+		infof(request.Context(), 2, "encrypt failed: %v", err)
 		return err
 	}
 	http.SetCookie(responseWriter, &http.Cookie{ // #nosec G124
 		Name:     cookieName,
 		Value:    data,
-		Expires:  createExpires(),
+		Expires:  session.Expires,
 		Path:     "/",
 		Domain:   request.URL.Host,
 		HttpOnly: true,
@@ -156,8 +365,102 @@ func (h *authHtmlHandler) validateLoginParams(
 		SameSite: http.SameSiteLaxMode,
 	})
 	target := request.RequestURI
-	glog.V(4).Infof("login success, redirect to %v", target)
-	return h.redirect(responseWriter, target)
+	infof(request.Context(), 4, "login success, redirect to %v", target)
+	return h.redirect(request.Context(), responseWriter, target)
+}
+
+// setTotpCookie stores the verified credentials until the totp code is entered.
+func (h *authHtmlHandler) setTotpCookie(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	login string,
+	password string,
+) error {
+	expires := time.Now().Add(totpDuration)
+	data, err := h.crypter.Encrypt(
+		fmt.Sprintf("%d:%s", expires.Unix(), CreateAuthorizationToken(login, password)),
+	)
+	if err != nil {
+		infof(request.Context(), 2, "encrypt failed: %v", err)
+		return err
+	}
+	http.SetCookie(responseWriter, &http.Cookie{ // #nosec G124
+		Name:     totpCookieName,
+		Value:    data,
+		Expires:  expires,
+		Path:     "/",
+		Domain:   request.URL.Host,
+		HttpOnly: true,
+		Secure:   isSecureRequest(request),
+		SameSite: http.SameSiteStrictMode,
+	})
+	return nil
+}
+
+func (h *authHtmlHandler) parseTotpCookie(request *http.Request) (string, string, error) {
+	cookie, err := request.Cookie(totpCookieName)
+	if err != nil {
+		return "", "", err
+	}
+	data, err := h.crypter.Decrypt(cookie.Value)
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.SplitN(data, ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid totp cookie")
+	}
+	expires, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return "", "", err
+	}
+	if time.Now().Unix() > expires {
+		return "", "", fmt.Errorf("totp cookie expired")
+	}
+	return ParseAuthorizationToken(parts[1])
+}
+
+// auditFailure audits a failed login not counted by the login throttle.
+func (h *authHtmlHandler) auditFailure(
+	request *http.Request,
+	user string,
+	method AuthMethod,
+	reason AuditReason,
+) {
+	auditLogin(request, h.auditLog, AuditLoginFailure, UserName(user), method, reason)
+}
+
+// createSessionCookie returns the cookie value with session id, creation time and credentials.
+func createSessionCookie(session *Session, password string) string {
+	return fmt.Sprintf(
+		"%s:%d:%s",
+		session.ID,
+		session.Created.UnixNano(),
+		CreateAuthorizationToken(session.User.String(), password),
+	)
+}
+
+// parseSessionCookie parses cookies of createSessionCookie. Cookies created before
+// sessions only contain the credentials and are rejected, so the user has to login again.
+func parseSessionCookie(data string) (*Session, string, error) {
+	var session Session
+	parts := strings.SplitN(data, ":", 3)
+	if len(parts) != 3 || len(parts[0]) == 0 {
+		return nil, "", fmt.Errorf("cookie without session id")
+	}
+	created, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, "", err
+	}
+	session.ID = parts[0]
+	session.Created = time.Unix(0, created)
+	session.Expires = session.Created.Add(loginDuration)
+	user, pass, err := ParseAuthorizationToken(parts[2])
+	if err != nil {
+		return nil, "", err
+	}
+	session.User = UserName(user)
+	return &session, pass, nil
 }
 
 func isSecureRequest(request *http.Request) bool {
@@ -168,8 +471,11 @@ func createExpires() time.Time {
 	return time.Now().Add(loginDuration)
 }
 
-func (h *authHtmlHandler) loginForm(responseWriter http.ResponseWriter) error {
-	glog.V(4).Infof("login form")
+func (h *authHtmlHandler) loginForm(
+	ctx context.Context,
+	responseWriter http.ResponseWriter,
+) error {
+	infof(ctx, 4, "login form")
 	var t = template.Must(template.New("loginForm").Parse(HTML_LOGIN_FORM))
 	data := struct {
 		FieldNameLogin    string
@@ -183,8 +489,28 @@ func (h *authHtmlHandler) loginForm(responseWriter http.ResponseWriter) error {
 	return t.Execute(responseWriter, data)
 }
 
-func (h *authHtmlHandler) redirect(responseWriter http.ResponseWriter, target string) error {
-	glog.V(4).Infof("login form")
+func (h *authHtmlHandler) totpForm(
+	ctx context.Context,
+	responseWriter http.ResponseWriter,
+) error {
+	infof(ctx, 4, "totp form")
+	var t = template.Must(template.New("totpForm").Parse(HTML_TOTP_FORM))
+	data := struct {
+		FieldNameTotp string
+	}{
+		FieldNameTotp: fieldNameTotp,
+	}
+	responseWriter.Header().Add("Content-Type", "text/html")
+	responseWriter.WriteHeader(http.StatusUnauthorized)
+	return t.Execute(responseWriter, data)
+}
+
+func (h *authHtmlHandler) redirect(
+	ctx context.Context,
+	responseWriter http.ResponseWriter,
+	target string,
+) error {
+	infof(ctx, 4, "redirect to %v", target)
 	var t = template.Must(template.New("loginForm").Parse(HTML_REDIRECT))
 	data := struct {
 		Target string
@@ -290,3 +616,53 @@ body {
 </div>
 </body>
 </html>`
+
+const HTML_TOTP_FORM = `<!DOCTYPE html>
+<html>
+<title>Login Form</title>
+<meta http-equiv="X-UA-Compatible" content="IE=edge">
+<meta http-equiv="Content-Language" content="en">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="author" content="Benjamin Borbe">
+<meta name="description" content="Login Form">
+<link rel="icon" href="data:;base64,=">
+<link rel="stylesheet" type="text/css" href="https://maxcdn.bootstrapcdn.com/bootstrap/3.3.5/css/bootstrap.min.css">
+<link rel="stylesheet" type="text/css" href="https://maxcdn.bootstrapcdn.com/bootstrap/3.3.5/css/bootstrap-theme.min.css">
+<style>
+html {
+	position: relative;
+	min-height: 100%;
+}
+body {
+	margin-top: 60px;
+}
+</style>
+</script>
+</head>
+<body>
+<div class="view-container">
+	<div class="container">
+		<div class="starter-template">
+			<form name="totpForm" class="form-horizontal" action="" method="post">
+				<fieldset>
+					<legend>Verification code required</legend>
+
+					<div class="form-group">
+						<label class="col-md-3 control-label" for="{{.FieldNameTotp}}">Code</label>
+						<div class="col-md-3">
+							<input type="text" id="{{.FieldNameTotp}}" name="{{.FieldNameTotp}}" inputmode="numeric" autocomplete="one-time-code" pattern="[0-9]*" required="" autofocus="" placeholder="123456" class="form-control input-md">
+						</div>
+					</div>
+					<div class="form-group">
+						<label class="col-md-3 control-label" for="singlebutton"></label>
+						<div class="col-md-3">
+							<input type="submit" id="singlebutton" name="singlebutton" class="btn btn-primary" value="verify">
+						</div>
+					</div>
+				</fieldset>
+			</form>
+		</div>
+	</div>
+</div>
+</body>
+</html>`
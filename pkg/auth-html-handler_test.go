@@ -6,8 +6,12 @@ package pkg_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -24,7 +28,14 @@ var _ = Describe("AuthHtmlHandler", func() {
 	var req *http.Request
 	var subhandler *mocks.HttpHandler
 	var check *mocks.Check
+	var apiKeyAuth *mocks.ApiKeyAuth
+	var clientCertAuth *mocks.ClientCertAuth
+	var authorizer *mocks.Authorizer
+	var loginThrottle *mocks.LoginThrottle
+	var totpVerifier *mocks.TotpVerifier
 	var crypter *mocks.Crypter
+	var sessionStore *mocks.SessionStore
+	var auditLog *mocks.AuditLog
 	BeforeEach(func() {
 		ctx = context.Background()
 
@@ -32,15 +43,37 @@ var _ = Describe("AuthHtmlHandler", func() {
 
 		check = &mocks.Check{}
 		check.CheckReturns(true, nil)
+		apiKeyAuth = &mocks.ApiKeyAuth{}
+		clientCertAuth = &mocks.ClientCertAuth{}
+		authorizer = &mocks.Authorizer{}
+		authorizer.AuthorizeReturns(true, nil)
+		loginThrottle = &mocks.LoginThrottle{}
+		totpVerifier = &mocks.TotpVerifier{}
 
 		crypter = &mocks.Crypter{}
+		crypter.EncryptReturns("encrypted", nil)
+		sessionStore = &mocks.SessionStore{}
+		sessionStore.CreateReturns(&pkg.Session{ID: "abc", User: "myuser"}, nil)
+		sessionStore.ValidReturns(true)
+		auditLog = &mocks.AuditLog{}
 
 		req, err = http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
 		Expect(err).To(BeNil())
 		recorder = httptest.NewRecorder()
 	})
 	JustBeforeEach(func() {
-		basicHandler = pkg.NewAuthHtmlHandler(subhandler, check, crypter)
+		basicHandler = pkg.NewAuthHtmlHandler(
+			subhandler,
+			check,
+			apiKeyAuth,
+			clientCertAuth,
+			authorizer,
+			loginThrottle,
+			totpVerifier,
+			crypter,
+			sessionStore,
+			auditLog,
+		)
 		basicHandler.ServeHTTP(recorder, req)
 	})
 	Context("Success", func() {
@@ -58,4 +91,365 @@ var _ = Describe("AuthHtmlHandler", func() {
 			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(Equal("myuser"))
 		})
 	})
+	Context("not authorized", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("myuser", "mypass")
+			authorizer.AuthorizeReturns(false, nil)
+		})
+		It("returns forbidden", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("locked basic auth user", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("myuser", "mypass")
+			loginThrottle.RetryAfterReturns(time.Minute)
+		})
+		It("returns too many requests", func() {
+			Expect(recorder.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(recorder.Header().Get("Retry-After")).To(Equal("60"))
+		})
+		It("does not call check", func() {
+			Expect(check.CheckCallCount()).To(Equal(0))
+		})
+		It("audits locked user", func() {
+			Expect(auditLog.LogCallCount()).To(Equal(1))
+			_, event := auditLog.LogArgsForCall(0)
+			Expect(event.AuthMethod).To(Equal(pkg.AuthMethodBasic))
+			Expect(event.Reason).To(Equal(pkg.AuditReasonLocked))
+		})
+	})
+	Context("valid api key", func() {
+		BeforeEach(func() {
+			apiKeyAuth.AuthenticateReturns(&pkg.ApiKey{Name: "ci"}, true, nil)
+		})
+		It("does not call check", func() {
+			Expect(check.CheckCallCount()).To(Equal(0))
+		})
+		It("calls subhandler with key name", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := subhandler.ServeHTTPArgsForCall(0)
+			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(Equal("ci"))
+		})
+	})
+	Context("invalid api key", func() {
+		BeforeEach(func() {
+			apiKeyAuth.AuthenticateReturns(nil, true, pkg.ErrApiKeyNotFound)
+		})
+		It("returns unauthorized", func() {
+			Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("forbidden api key", func() {
+		BeforeEach(func() {
+			apiKeyAuth.AuthenticateReturns(nil, true, pkg.ErrApiKeyForbidden)
+		})
+		It("returns forbidden", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("sufficient client certificate", func() {
+		BeforeEach(func() {
+			clientCertAuth.AuthenticateReturns(&pkg.ClientCertUser{Name: "certuser"}, true, nil)
+			clientCertAuth.SufficientReturns(true)
+		})
+		It("does not call check", func() {
+			Expect(check.CheckCallCount()).To(Equal(0))
+		})
+		It("calls subhandler with certificate user", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := subhandler.ServeHTTPArgsForCall(0)
+			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(Equal("certuser"))
+		})
+	})
+	Context("additional client certificate", func() {
+		BeforeEach(func() {
+			clientCertAuth.AuthenticateReturns(&pkg.ClientCertUser{
+				Name:   "myuser",
+				Groups: pkg.GroupNames{"ops"},
+			}, true, nil)
+			clientCertAuth.SufficientReturns(false)
+			req.SetBasicAuth("myuser", "mypass")
+		})
+		It("calls check", func() {
+			Expect(check.CheckCallCount()).To(Equal(1))
+		})
+		It("calls subhandler with password user", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := subhandler.ServeHTTPArgsForCall(0)
+			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(Equal("myuser"))
+		})
+		It("passes certificate user to authorizer", func() {
+			argRequest, _ := authorizer.AuthorizeArgsForCall(0)
+			certUser, ok := pkg.ClientCertUserFromContext(argRequest.Context())
+			Expect(ok).To(BeTrue())
+			Expect(certUser.Groups).To(Equal(pkg.GroupNames{"ops"}))
+		})
+		Context("of other user", func() {
+			BeforeEach(func() {
+				clientCertAuth.AuthenticateReturns(&pkg.ClientCertUser{Name: "certuser"}, true, nil)
+			})
+			It("returns forbidden", func() {
+				Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			})
+			It("does not call subhandler", func() {
+				Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+			})
+		})
+	})
+	Context("client certificate without required groups", func() {
+		BeforeEach(func() {
+			clientCertAuth.AuthenticateReturns(nil, true, errors.New("banana"))
+		})
+		It("returns forbidden", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("password form", func() {
+		BeforeEach(func() {
+			req, err = http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				"/",
+				strings.NewReader("login=myuser&password=mypass"),
+			)
+			Expect(err).To(BeNil())
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		})
+		It("resets failures", func() {
+			Expect(loginThrottle.SuccessCallCount()).To(Equal(1))
+		})
+		It("creates session", func() {
+			Expect(sessionStore.CreateCallCount()).To(Equal(1))
+			argUser, _, _ := sessionStore.CreateArgsForCall(0)
+			Expect(argUser).To(Equal(pkg.UserName("myuser")))
+			Expect(crypter.EncryptCallCount()).To(Equal(1))
+			Expect(crypter.EncryptArgsForCall(0)).To(HavePrefix("abc:"))
+		})
+		It("audits login success", func() {
+			Expect(auditLog.LogCallCount()).To(Equal(1))
+			_, event := auditLog.LogArgsForCall(0)
+			Expect(event.Type).To(Equal(pkg.AuditLoginSuccess))
+			Expect(event.User).To(Equal(pkg.UserName("myuser")))
+			Expect(event.AuthMethod).To(Equal(pkg.AuthMethodForm))
+		})
+		Context("invalid password", func() {
+			BeforeEach(func() {
+				check.CheckReturns(false, nil)
+			})
+			It("shows login form", func() {
+				Expect(recorder.Body.String()).To(ContainSubstring(`name="password"`))
+			})
+			It("records failure", func() {
+				Expect(loginThrottle.FailureCallCount()).To(Equal(1))
+			})
+			It("audits login failure", func() {
+				Expect(auditLog.LogCallCount()).To(Equal(1))
+				_, event := auditLog.LogArgsForCall(0)
+				Expect(event.Type).To(Equal(pkg.AuditLoginFailure))
+				Expect(event.AuthMethod).To(Equal(pkg.AuthMethodForm))
+				Expect(event.Reason).To(Equal(pkg.AuditReasonInvalidCredentials))
+			})
+		})
+		Context("locked user", func() {
+			BeforeEach(func() {
+				loginThrottle.RetryAfterReturns(time.Minute)
+			})
+			It("returns too many requests", func() {
+				Expect(recorder.Code).To(Equal(http.StatusTooManyRequests))
+				Expect(recorder.Header().Get("Retry-After")).To(Equal("60"))
+			})
+			It("does not call check", func() {
+				Expect(check.CheckCallCount()).To(Equal(0))
+			})
+		})
+		Context("client certificate of other user", func() {
+			BeforeEach(func() {
+				clientCertAuth.AuthenticateReturns(&pkg.ClientCertUser{Name: "certuser"}, true, nil)
+			})
+			It("returns forbidden", func() {
+				Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			})
+			It("creates no session", func() {
+				Expect(sessionStore.CreateCallCount()).To(Equal(0))
+			})
+		})
+	})
+	Context("login cookie", func() {
+		var created time.Time
+		BeforeEach(func() {
+			created = time.Now().Add(-time.Hour)
+			req.AddCookie(&http.Cookie{Name: "auth-http-proxy-token", Value: "encrypted"})
+			crypter.DecryptReturns(fmt.Sprintf(
+				"abc:%d:%s",
+				created.UnixNano(),
+				pkg.CreateAuthorizationToken("myuser", "mypass"),
+			), nil)
+		})
+		It("validates session", func() {
+			Expect(sessionStore.ValidCallCount()).To(Equal(1))
+			argSession, _ := sessionStore.ValidArgsForCall(0)
+			Expect(argSession.ID).To(Equal("abc"))
+			Expect(argSession.User).To(Equal(pkg.UserName("myuser")))
+			Expect(argSession.Created.Equal(created)).To(BeTrue())
+		})
+		It("calls subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+		})
+		Context("revoked session", func() {
+			BeforeEach(func() {
+				sessionStore.ValidReturns(false)
+			})
+			It("does not call check", func() {
+				Expect(check.CheckCallCount()).To(Equal(0))
+			})
+			It("shows login form", func() {
+				Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+				Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+			})
+		})
+		Context("cookie without session", func() {
+			BeforeEach(func() {
+				crypter.DecryptReturns(pkg.CreateAuthorizationToken("myuser", "mypass"), nil)
+			})
+			It("does not call check", func() {
+				Expect(sessionStore.ValidCallCount()).To(Equal(0))
+				Expect(check.CheckCallCount()).To(Equal(0))
+			})
+			It("shows login form", func() {
+				Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+				Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+			})
+		})
+	})
+	Context("totp", func() {
+		BeforeEach(func() {
+			totpVerifier.EnabledReturns(true, nil)
+		})
+		Context("basic auth", func() {
+			BeforeEach(func() {
+				req.SetBasicAuth("myuser", "mypass")
+			})
+			It("does not call subhandler", func() {
+				Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+			})
+		})
+		Context("password form", func() {
+			BeforeEach(func() {
+				req, err = http.NewRequestWithContext(
+					ctx,
+					http.MethodPost,
+					"/",
+					strings.NewReader("login=myuser&password=mypass"),
+				)
+				Expect(err).To(BeNil())
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			})
+			It("shows totp form", func() {
+				Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+				Expect(recorder.Body.String()).To(ContainSubstring(`name="totp"`))
+			})
+			It("sets only totp cookie", func() {
+				cookies := recorder.Result().Cookies()
+				Expect(cookies).To(HaveLen(1))
+				Expect(cookies[0].Name).To(Equal("auth-http-proxy-totp"))
+			})
+			It("does not call subhandler", func() {
+				Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+			})
+		})
+		Context("totp form", func() {
+			BeforeEach(func() {
+				req, err = http.NewRequestWithContext(
+					ctx,
+					http.MethodPost,
+					"/",
+					strings.NewReader("totp=123456"),
+				)
+				Expect(err).To(BeNil())
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				req.AddCookie(&http.Cookie{Name: "auth-http-proxy-totp", Value: "pending"})
+				crypter.DecryptReturns(fmt.Sprintf(
+					"%d:%s",
+					time.Now().Add(time.Minute).Unix(),
+					pkg.CreateAuthorizationToken("myuser", "mypass"),
+				), nil)
+			})
+			Context("valid code", func() {
+				BeforeEach(func() {
+					totpVerifier.VerifyReturns(true, nil)
+				})
+				It("verifies code", func() {
+					Expect(totpVerifier.VerifyCallCount()).To(Equal(1))
+					_, argUser, argCode := totpVerifier.VerifyArgsForCall(0)
+					Expect(argUser).To(Equal(pkg.UserName("myuser")))
+					Expect(argCode).To(Equal("123456"))
+				})
+				It("sets login cookie", func() {
+					var names []string
+					for _, cookie := range recorder.Result().Cookies() {
+						names = append(names, cookie.Name)
+					}
+					Expect(names).To(ContainElement("auth-http-proxy-token"))
+				})
+			})
+			Context("invalid code", func() {
+				BeforeEach(func() {
+					totpVerifier.VerifyReturns(false, nil)
+				})
+				It("shows totp form", func() {
+					Expect(recorder.Body.String()).To(ContainSubstring(`name="totp"`))
+				})
+				It("sets no cookie", func() {
+					Expect(recorder.Result().Cookies()).To(BeEmpty())
+				})
+				It("records failure", func() {
+					Expect(loginThrottle.FailureCallCount()).To(Equal(1))
+				})
+				It("audits invalid totp", func() {
+					Expect(auditLog.LogCallCount()).To(Equal(1))
+					_, event := auditLog.LogArgsForCall(0)
+					Expect(event.Type).To(Equal(pkg.AuditLoginFailure))
+					Expect(event.Reason).To(Equal(pkg.AuditReasonTotpInvalid))
+				})
+			})
+			Context("locked user", func() {
+				BeforeEach(func() {
+					loginThrottle.RetryAfterReturns(time.Minute)
+				})
+				It("returns too many requests", func() {
+					Expect(recorder.Code).To(Equal(http.StatusTooManyRequests))
+				})
+				It("does not call check", func() {
+					Expect(check.CheckCallCount()).To(Equal(0))
+					Expect(totpVerifier.VerifyCallCount()).To(Equal(0))
+				})
+			})
+			Context("expired pending login", func() {
+				BeforeEach(func() {
+					crypter.DecryptReturns(fmt.Sprintf(
+						"%d:%s",
+						time.Now().Add(-time.Minute).Unix(),
+						pkg.CreateAuthorizationToken("myuser", "mypass"),
+					), nil)
+				})
+				It("shows login form", func() {
+					Expect(recorder.Body.String()).To(ContainSubstring(`name="password"`))
+					Expect(totpVerifier.VerifyCallCount()).To(Equal(0))
+				})
+			})
+		})
+	})
 })
@@ -0,0 +1,72 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NewAuthJwtHandler authenticates requests with "Authorization: Bearer <jwt>".
+// Requests without a JWT are passed to the fallback handler (basic or html auth).
+func NewAuthJwtHandler(
+	subhandler http.Handler,
+	fallback http.Handler,
+	jwtVerifier JwtVerifier,
+	authorizer Authorizer,
+	requiredGroups []GroupName,
+) http.Handler {
+	h := new(authJwtHandler)
+	h.subhandler = subhandler
+	h.fallback = fallback
+	h.jwtVerifier = jwtVerifier
+	h.authorizer = authorizer
+	h.requiredGroups = requiredGroups
+	return h
+}
+
+type authJwtHandler struct {
+	subhandler     http.Handler
+	fallback       http.Handler
+	jwtVerifier    JwtVerifier
+	authorizer     Authorizer
+	requiredGroups []GroupName
+}
+
+func (h *authJwtHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	infof(request.Context(), 4, "check jwt auth")
+	if servePublic(h.authorizer, h.subhandler, responseWriter, request) {
+		return
+	}
+	token, err := ParseAuthorizationBearerTokenHttpRequest(request)
+	if err != nil || !isJwt(token) {
+		infof(request.Context(), 4, "no jwt found => fallback")
+		h.fallback.ServeHTTP(responseWriter, request)
+		return
+	}
+	user, err := h.jwtVerifier.VerifyToken(request.Context(), token)
+	if err != nil {
+		infof(request.Context(), 2, "verify jwt failed: %v", err)
+		responseWriter.Header().Add("WWW-Authenticate", `Bearer error="invalid_token"`)
+		responseWriter.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if missing := user.Groups.Missing(h.requiredGroups); len(missing) > 0 {
+		infof(request.Context(), 1, "user %v has not required groups %v", user.Name, missing)
+		responseWriter.Header().Add("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+		responseWriter.WriteHeader(http.StatusForbidden)
+		return
+	}
+	serveIdentity(h.authorizer, h.subhandler, responseWriter, request, &Identity{
+		Name:   user.Name,
+		Groups: user.Groups,
+		Source: IdentitySourceJwt,
+		Method: AuthMethodJwt,
+	})
+}
+
+func isJwt(token string) bool {
+	return strings.Count(token, ".") == 2
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("AuthJwtHandler", func() {
+	var ctx context.Context
+	var err error
+	var jwtHandler http.Handler
+	var recorder *httptest.ResponseRecorder
+	var req *http.Request
+	var subhandler *mocks.HttpHandler
+	var fallback *mocks.HttpHandler
+	var jwtVerifier *mocks.JwtVerifier
+	var authorizer *mocks.Authorizer
+	var requiredGroups []pkg.GroupName
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		subhandler = &mocks.HttpHandler{}
+		fallback = &mocks.HttpHandler{}
+		jwtVerifier = &mocks.JwtVerifier{}
+		jwtVerifier.VerifyTokenReturns(&pkg.JwtUser{
+			Name:   "myuser",
+			Groups: pkg.GroupNames{"admin"},
+		}, nil)
+		authorizer = &mocks.Authorizer{}
+		authorizer.AuthorizeReturns(true, nil)
+		requiredGroups = []pkg.GroupName{"admin"}
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+		Expect(err).To(BeNil())
+		recorder = httptest.NewRecorder()
+	})
+	JustBeforeEach(func() {
+		jwtHandler = pkg.NewAuthJwtHandler(
+			subhandler,
+			fallback,
+			jwtVerifier,
+			authorizer,
+			requiredGroups,
+		)
+		jwtHandler.ServeHTTP(recorder, req)
+	})
+	Context("valid token", func() {
+		BeforeEach(func() {
+			req.Header.Set("Authorization", "Bearer aaa.bbb.ccc")
+			req.Header.Set(pkg.ForwardForUserHeader, "spoofed")
+		})
+		It("verifies the raw token", func() {
+			Expect(jwtVerifier.VerifyTokenCallCount()).To(Equal(1))
+			_, token := jwtVerifier.VerifyTokenArgsForCall(0)
+			Expect(token).To(Equal("aaa.bbb.ccc"))
+		})
+		It("calls subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := subhandler.ServeHTTPArgsForCall(0)
+			Expect(argRequest.Header.Values(pkg.ForwardForUserHeader)).To(Equal([]string{"myuser"}))
+		})
+		It("does not call fallback", func() {
+			Expect(fallback.ServeHTTPCallCount()).To(Equal(0))
+		})
+		It("stores identity with groups in context", func() {
+			_, argRequest := subhandler.ServeHTTPArgsForCall(0)
+			identity, ok := pkg.IdentityFromContext(argRequest.Context())
+			Expect(ok).To(BeTrue())
+			Expect(identity.Groups).To(Equal(pkg.GroupNames{"admin"}))
+			Expect(identity.Source).To(Equal(pkg.IdentitySourceJwt))
+		})
+	})
+	Context("valid token not authorized", func() {
+		BeforeEach(func() {
+			req.Header.Set("Authorization", "Bearer aaa.bbb.ccc")
+			authorizer.AuthorizeReturns(false, nil)
+		})
+		It("returns forbidden", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("invalid token", func() {
+		BeforeEach(func() {
+			req.Header.Set("Authorization", "Bearer aaa.bbb.ccc")
+			jwtVerifier.VerifyTokenReturns(nil, errors.New("banana"))
+		})
+		It("returns unauthorized", func() {
+			Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+			Expect(recorder.Header().Get("WWW-Authenticate")).To(ContainSubstring("invalid_token"))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("required group missing", func() {
+		BeforeEach(func() {
+			req.Header.Set("Authorization", "Bearer aaa.bbb.ccc")
+			requiredGroups = []pkg.GroupName{"admin", "ops"}
+		})
+		It("returns forbidden", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+		It("does not call subhandler", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("basic auth", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("myuser", "mypass")
+		})
+		It("calls fallback", func() {
+			Expect(fallback.ServeHTTPCallCount()).To(Equal(1))
+			Expect(jwtVerifier.VerifyTokenCallCount()).To(Equal(0))
+		})
+	})
+	Context("bearer token without jwt format", func() {
+		BeforeEach(func() {
+			req.Header.Set("Authorization", pkg.CreateAuthorizationBearerHeader("myuser", "mypass"))
+		})
+		It("calls fallback", func() {
+			Expect(fallback.ServeHTTPCallCount()).To(Equal(1))
+			Expect(jwtVerifier.VerifyTokenCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,234 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/bborbe/errors"
+)
+
+type AuthorizationFile string
+
+func (a AuthorizationFile) String() string {
+	return string(a)
+}
+
+// AuthorizationRule matches requests by host, path and method.
+// Empty match fields match every request.
+type AuthorizationRule struct {
+	Host       string     `json:"host,omitempty"`
+	PathPrefix string     `json:"path-prefix,omitempty"`
+	PathRegex  string     `json:"path-regex,omitempty"`
+	Methods    []string   `json:"methods,omitempty"`
+	Groups     GroupNames `json:"groups,omitempty"`
+	Users      []UserName `json:"users,omitempty"`
+	Public     bool       `json:"public,omitempty"`
+
+	pathRegex *regexp.Regexp
+}
+
+// Matches returns true if the rule applies to the request.
+func (a *AuthorizationRule) Matches(request *http.Request) bool {
+	if len(a.Host) > 0 && !strings.EqualFold(a.Host, requestHost(request)) {
+		return false
+	}
+	if len(a.PathPrefix) > 0 && !strings.HasPrefix(request.URL.Path, a.PathPrefix) {
+		return false
+	}
+	if a.pathRegex != nil && !a.pathRegex.MatchString(request.URL.Path) {
+		return false
+	}
+	if len(a.Methods) > 0 {
+		for _, method := range a.Methods {
+			if strings.EqualFold(method, request.Method) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Allows returns true if the user is listed or has all groups of the rule.
+// A rule without users and groups allows every authenticated user.
+func (a *AuthorizationRule) Allows(username UserName, groups GroupNames) bool {
+	if a.Public || (len(a.Users) == 0 && len(a.Groups) == 0) {
+		return true
+	}
+	for _, user := range a.Users {
+		if user == username {
+			return true
+		}
+	}
+	return len(a.Groups) > 0 && len(groups.Missing(a.Groups)) == 0
+}
+
+// AuthorizationRules are evaluated in order, the first matching rule decides.
+type AuthorizationRules []AuthorizationRule
+
+// Match returns the first rule matching the request or nil.
+func (a AuthorizationRules) Match(request *http.Request) *AuthorizationRule {
+	for i := range a {
+		if a[i].Matches(request) {
+			return &a[i]
+		}
+	}
+	return nil
+}
+
+// ParseAuthorizationRules parses a json list of rules and compiles the path regex.
+func ParseAuthorizationRules(ctx context.Context, content []byte) (AuthorizationRules, error) {
+	var rules AuthorizationRules
+	if err := json.Unmarshal(content, &rules); err != nil {
+		return nil, errors.Wrapf(ctx, err, "unmarshal authorization rules failed")
+	}
+	for i := range rules {
+		if len(rules[i].PathRegex) == 0 {
+			continue
+		}
+		pathRegex, err := regexp.Compile(rules[i].PathRegex)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "compile path-regex of rule %d failed", i)
+		}
+		rules[i].pathRegex = pathRegex
+	}
+	return rules, nil
+}
+
+// ReadAuthorizationFile reads the rules from a json file.
+func ReadAuthorizationFile(
+	ctx context.Context,
+	authorizationFile AuthorizationFile,
+) (AuthorizationRules, error) {
+	content, err := os.ReadFile(authorizationFile.String())
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "read authorization file %s failed", authorizationFile)
+	}
+	return ParseAuthorizationRules(ctx, content)
+}
+
+//counterfeiter:generate -o ../mocks/authorizer.go --fake-name Authorizer . Authorizer
+type Authorizer interface {
+	// Public returns true if the request is allowed without authentication.
+	Public(request *http.Request) bool
+	// Authorize returns true if the authenticated identity is allowed to access the request.
+	Authorize(request *http.Request, identity *Identity) (bool, error)
+}
+
+// AuthorizerDisabled allows every authenticated request.
+var AuthorizerDisabled Authorizer = authorizerDisabled{}
+
+type authorizerDisabled struct{}
+
+func (a authorizerDisabled) Public(request *http.Request) bool {
+	return false
+}
+
+func (a authorizerDisabled) Authorize(request *http.Request, identity *Identity) (bool, error) {
+	return true, nil
+}
+
+// NewAuthorizer evaluates the given rules.
+// Requests not matching any rule are allowed for every authenticated user.
+func NewAuthorizer(rules AuthorizationRules, groupResolver GroupResolver) Authorizer {
+	return &authorizer{
+		rules:         rules,
+		groupResolver: groupResolver,
+	}
+}
+
+type authorizer struct {
+	rules         AuthorizationRules
+	groupResolver GroupResolver
+}
+
+func (a *authorizer) Public(request *http.Request) bool {
+	rule := a.rules.Match(request)
+	return rule != nil && rule.Public
+}
+
+func (a *authorizer) Authorize(request *http.Request, identity *Identity) (bool, error) {
+	rule := a.rules.Match(request)
+	if rule == nil {
+		return true, nil
+	}
+	if len(rule.Groups) > 0 && identity.Groups == nil && identity.GroupsFromDirectory() {
+		ctx := request.Context()
+		groups, err := a.groupResolver.GroupsOfUser(ctx, identity.Name)
+		if err != nil {
+			return false, errors.Wrapf(ctx, err, "resolve groups of user %s failed", identity.Name)
+		}
+		identity.Groups = groups
+	}
+	return rule.Allows(identity.Name, identity.Groups), nil
+}
+
+// servePublic forwards requests allowed without authentication to the subhandler.
+func servePublic(
+	authorizer Authorizer,
+	subhandler http.Handler,
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) bool {
+	if !authorizer.Public(request) {
+		return false
+	}
+	infof(request.Context(), 4, "public request %s %s", request.Method, request.URL.Path)
+	request.Header.Del(ForwardForUserHeader)
+	subhandler.ServeHTTP(responseWriter, request)
+	return true
+}
+
+// serveIdentity forwards the request of an authenticated identity if it is authorized
+// and responds with 403 otherwise.
+func serveIdentity(
+	authorizer Authorizer,
+	subhandler http.Handler,
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	identity *Identity,
+) {
+	if identity.GroupsFromDirectory() && !clientCertUserMatches(request, identity.Name) {
+		responseWriter.WriteHeader(http.StatusForbidden)
+		return
+	}
+	recordAuth(request.Context(), identity.Name, identity.Method)
+	allowed, err := authorizer.Authorize(request, identity)
+	if err != nil {
+		warningf(request.Context(), "authorize user %v failed: %v", identity.Name, err)
+		responseWriter.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if !allowed {
+		infof(
+			request.Context(),
+			1,
+			"user %v not allowed to %s %s",
+			identity.Name,
+			request.Method,
+			request.URL.Path,
+		)
+		responseWriter.WriteHeader(http.StatusForbidden)
+		return
+	}
+	request.Header.Set(ForwardForUserHeader, identity.Name.String())
+	ctx := WithIdentity(request.Context(), identity)
+	subhandler.ServeHTTP(responseWriter, request.WithContext(ctx))
+}
+
+func requestHost(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.Host)
+	if err != nil {
+		return request.Host
+	}
+	return host
+}
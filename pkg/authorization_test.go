@@ -0,0 +1,100 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("Authorizer", func() {
+	var ctx context.Context
+	var err error
+	var rules pkg.AuthorizationRules
+	var groupResolver *mocks.GroupResolver
+	var authorizer pkg.Authorizer
+	var newRequest func(method string, target string) *http.Request
+	BeforeEach(func() {
+		ctx = context.Background()
+		rules, err = pkg.ParseAuthorizationRules(ctx, []byte(`[
+			{"path-prefix": "/health", "public": true},
+			{"host": "admin.example.com", "groups": ["admin"]},
+			{"path-prefix": "/admin", "groups": ["admin"], "users": ["root"]},
+			{"path-regex": "^/api/[^/]+/read$", "methods": ["GET", "HEAD"]},
+			{"path-prefix": "/api", "groups": ["writer"]}
+		]`))
+		Expect(err).To(BeNil())
+		groupResolver = &mocks.GroupResolver{}
+		groupResolver.GroupsOfUserReturns(pkg.GroupNames{"admin"}, nil)
+		authorizer = pkg.NewAuthorizer(rules, groupResolver)
+		newRequest = func(method string, target string) *http.Request {
+			req, err := http.NewRequestWithContext(ctx, method, target, nil)
+			Expect(err).To(BeNil())
+			return req
+		}
+	})
+	It("returns error for invalid regex", func() {
+		_, err := pkg.ParseAuthorizationRules(ctx, []byte(`[{"path-regex": "("}]`))
+		Expect(err).NotTo(BeNil())
+	})
+	It("marks matching public rule", func() {
+		Expect(authorizer.Public(newRequest(http.MethodGet, "/healthz"))).To(BeTrue())
+		Expect(authorizer.Public(newRequest(http.MethodGet, "/admin"))).To(BeFalse())
+	})
+	DescribeTable("Authorize",
+		func(method string, target string, identity pkg.Identity, expected bool) {
+			allowed, err := authorizer.Authorize(newRequest(method, target), &identity)
+			Expect(err).To(BeNil())
+			Expect(allowed).To(Equal(expected))
+		},
+		Entry("no rule", http.MethodGet, "/other",
+			pkg.Identity{Name: "bob", Groups: pkg.GroupNames{}}, true),
+		Entry("admin with group", http.MethodGet, "/admin/users",
+			pkg.Identity{Name: "bob", Groups: pkg.GroupNames{"admin"}}, true),
+		Entry("admin without group", http.MethodGet, "/admin/users",
+			pkg.Identity{Name: "bob", Groups: pkg.GroupNames{"dev"}}, false),
+		Entry("admin allowed user", http.MethodGet, "/admin/users",
+			pkg.Identity{Name: "root", Groups: pkg.GroupNames{}}, true),
+		Entry("host rule", http.MethodGet, "http://admin.example.com:8080/",
+			pkg.Identity{Name: "bob", Groups: pkg.GroupNames{}}, false),
+		Entry("read with get", http.MethodGet, "/api/items/read",
+			pkg.Identity{Name: "bob", Groups: pkg.GroupNames{}}, true),
+		Entry("read with post", http.MethodPost, "/api/items/read",
+			pkg.Identity{Name: "bob", Groups: pkg.GroupNames{}}, false),
+		Entry("write with group", http.MethodPost, "/api/items",
+			pkg.Identity{Name: "bob", Groups: pkg.GroupNames{"writer"}}, true),
+	)
+	Context("password identity", func() {
+		var identity *pkg.Identity
+		BeforeEach(func() {
+			identity = &pkg.Identity{Name: "bob", Source: pkg.IdentitySourcePassword}
+		})
+		It("resolves groups only for group rules", func() {
+			allowed, err := authorizer.Authorize(newRequest(http.MethodGet, "/other"), identity)
+			Expect(err).To(BeNil())
+			Expect(allowed).To(BeTrue())
+			Expect(groupResolver.GroupsOfUserCallCount()).To(Equal(0))
+		})
+		It("resolves groups from directory", func() {
+			allowed, err := authorizer.Authorize(newRequest(http.MethodGet, "/admin"), identity)
+			Expect(err).To(BeNil())
+			Expect(allowed).To(BeTrue())
+			Expect(groupResolver.GroupsOfUserCallCount()).To(Equal(1))
+			Expect(identity.Groups).To(Equal(pkg.GroupNames{"admin"}))
+		})
+		It("returns error if groups can not be resolved", func() {
+			groupResolver.GroupsOfUserReturns(nil, errors.New("banana"))
+			_, err := authorizer.Authorize(newRequest(http.MethodGet, "/admin"), identity)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -5,10 +5,20 @@
 package pkg
 
 import (
+	"container/list"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
-	"github.com/wunderlist/ttlcache"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 )
 
 type CacheTTL time.Duration
@@ -21,36 +31,282 @@ func (c CacheTTL) Duration() time.Duration {
 	return time.Duration(c)
 }
 
+// CacheNegativeTTL is how long rejected credentials are cached. Zero disables it.
+type CacheNegativeTTL time.Duration
+
+func (c CacheNegativeTTL) Duration() time.Duration {
+	return time.Duration(c)
+}
+
+// CacheStaleTTL is how long accepted credentials are still accepted after the cache ttl
+// if the verifier fails with an error. Zero disables it.
+type CacheStaleTTL time.Duration
+
+func (c CacheStaleTTL) Duration() time.Duration {
+	return time.Duration(c)
+}
+
+// CacheMaxEntries limits the number of cached credentials.
+// The least recently used entry is evicted first.
+type CacheMaxEntries int
+
+func (c CacheMaxEntries) Int() int {
+	return int(c)
+}
+
+//counterfeiter:generate -o ../mocks/cache-auth.go --fake-name CacheAuth . CacheAuth
+type CacheAuth interface {
+	Verifier
+	// Entries returns the cached verifications without credentials.
+	Entries() []CacheAuthEntry
+	// Flush removes all cached verifications of the user and returns how many were removed.
+	Flush(username UserName) int
+	// FlushAll removes all cached verifications and returns how many were removed.
+	FlushAll() int
+}
+
+// CacheAuthEntry describes a cached verification.
+type CacheAuthEntry struct {
+	User       UserName  `json:"user"`
+	Valid      bool      `json:"valid"`
+	Expires    time.Time `json:"expires"`
+	StaleUntil time.Time `json:"stale-until"`
+}
+
+type cacheEntry struct {
+	key      string
+	username UserName
+	valid    bool
+	expires  time.Time
+	// staleUntil is the time the entry can be used if the verifier fails.
+	staleUntil time.Time
+}
+
 type cacheAuth struct {
-	verifier Verifier
-	cache    *ttlcache.Cache
+	verifier    Verifier
+	ttl         CacheTTL
+	negativeTTL CacheNegativeTTL
+	staleTTL    CacheStaleTTL
+	maxEntries  CacheMaxEntries
+	auditLog    AuditLog
+	now         func() time.Time
+	hashKey     []byte
+	inflight    singleflight.Group
+
+	mux     sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
 }
 
+// NewCacheAuth caches results of the verifier per user and password hash.
+// Successful verifications are kept for ttl, rejections for negativeTTL.
+// Concurrent verifications of the same credentials call the verifier once.
+// If the verifier fails, successful verifications are accepted for staleTTL after ttl.
+// Verifications not answered from the cache are written to the audit log.
 func NewCacheAuth(
 	verifier Verifier,
 	ttl CacheTTL,
-) Verifier {
+	negativeTTL CacheNegativeTTL,
+	staleTTL CacheStaleTTL,
+	maxEntries CacheMaxEntries,
+	auditLog AuditLog,
+	now func() time.Time,
+) CacheAuth {
+	hashKey := make([]byte, 32)
+	if _, err := rand.Read(hashKey); err != nil {
+		glog.Warningf("create cache hash key failed: %v", err)
+	}
 	return &cacheAuth{
-		verifier: verifier,
-		cache:    ttlcache.NewCache(ttl.Duration()),
+		verifier:    verifier,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		staleTTL:    staleTTL,
+		maxEntries:  maxEntries,
+		auditLog:    auditLog,
+		now:         now,
+		hashKey:     hashKey,
+		entries:     map[string]*list.Element{},
+		lru:         list.New(),
 	}
 }
 
-func (c *cacheAuth) Verify(username UserName, password Password) (bool, error) {
-	glog.V(2).Infof("verify user %s with password-length %d", username, len(password))
-	value, found := c.cache.Get(username.String())
-	if found && value == password.String() {
-		glog.V(2).Infof("cache hit for user %v", username)
-		return true, nil
+func (c *cacheAuth) Verify(
+	ctx context.Context,
+	username UserName,
+	password Password,
+) (bool, error) {
+	infof(ctx, 2, "verify user %s with password-length %d", username, len(password))
+	ctx, span := tracer().Start(ctx, "verify cache", trace.WithAttributes(
+		semconv.EnduserID(username.String()),
+	))
+	defer span.End()
+	key := c.key(username, password)
+	if valid, found := c.get(key); found {
+		infof(ctx, 2, "cache hit for user %v => %v", username, valid)
+		cacheRequestsTotal.WithLabelValues(cacheResultHit).Inc()
+		span.SetAttributes(attributeCacheResult.String(cacheResultHit))
+		return valid, nil
+	}
+	cacheRequestsTotal.WithLabelValues(cacheResultMiss).Inc()
+	span.SetAttributes(attributeCacheResult.String(cacheResultMiss))
+	result, err, shared := c.inflight.Do(key, func() (interface{}, error) {
+		result, reason, err := verifyWithReason(ctx, c.verifier, username, password)
+		if err != nil {
+			if c.stale(key) {
+				warningf(ctx, "verify user %v failed, use stale cache entry: %v", username, err)
+				cacheRequestsTotal.WithLabelValues(cacheResultStale).Inc()
+				span.SetAttributes(attributeCacheResult.String(cacheResultStale))
+				c.audit(ctx, AuditVerifyStale, username, reason)
+				return true, nil
+			}
+			c.audit(ctx, AuditVerifyFailure, username, reason)
+			return false, err
+		}
+		if result {
+			c.audit(ctx, AuditVerifySuccess, username, "")
+		} else {
+			c.audit(ctx, AuditVerifyFailure, username, reason)
+		}
+		c.set(ctx, key, username, result)
+		return result, nil
+	})
+	if shared {
+		infof(ctx, 2, "shared verification of user %v", username)
 	}
-	result, err := c.verifier.Verify(username, password)
 	if err != nil {
-		glog.Warningf("verify user %v failed: %v", username, err)
+		warningf(ctx, "verify user %v failed: %v", username, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "verify failed")
 		return false, err
 	}
-	if result {
-		glog.V(2).Infof("add user %v to cache", username)
-		c.cache.Set(username.String(), password.String())
+	return result.(bool), nil
+}
+
+func (c *cacheAuth) audit(
+	ctx context.Context,
+	eventType AuditEventType,
+	username UserName,
+	reason AuditReason,
+) {
+	c.auditLog.Log(ctx, AuditEvent{
+		Type:   eventType,
+		User:   username,
+		Reason: reason,
+	})
+}
+
+// key identifies the credentials without keeping the password in memory.
+func (c *cacheAuth) key(username UserName, password Password) string {
+	mac := hmac.New(sha256.New, c.hashKey)
+	mac.Write([]byte(username))
+	mac.Write([]byte{0})
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *cacheAuth) get(key string) (bool, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return false, false
+	}
+	entry := element.Value.(*cacheEntry)
+	now := c.now()
+	if !entry.expires.After(now) {
+		if !entry.staleUntil.After(now) {
+			c.remove(element)
+		}
+		return false, false
+	}
+	c.lru.MoveToFront(element)
+	return entry.valid, true
+}
+
+// stale returns true if the credentials were accepted within the stale ttl.
+func (c *cacheAuth) stale(key string) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	entry := element.Value.(*cacheEntry)
+	return entry.valid && entry.staleUntil.After(c.now())
+}
+
+func (c *cacheAuth) set(ctx context.Context, key string, username UserName, valid bool) {
+	ttl := c.ttl.Duration()
+	if !valid {
+		ttl = c.negativeTTL.Duration()
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	if ttl <= 0 {
+		return
+	}
+	infof(ctx, 2, "add user %v to cache => %v", username, valid)
+	expires := c.now().Add(ttl)
+	entry := &cacheEntry{
+		key:        key,
+		username:   username,
+		valid:      valid,
+		expires:    expires,
+		staleUntil: expires,
 	}
-	return result, nil
+	if valid {
+		entry.staleUntil = expires.Add(c.staleTTL.Duration())
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries.Int() {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *cacheAuth) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).key)
+}
+
+func (c *cacheAuth) Entries() []CacheAuthEntry {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	result := make([]CacheAuthEntry, 0, c.lru.Len())
+	for element := c.lru.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*cacheEntry)
+		result = append(result, CacheAuthEntry{
+			User:       entry.username,
+			Valid:      entry.valid,
+			Expires:    entry.expires,
+			StaleUntil: entry.staleUntil,
+		})
+	}
+	return result
+}
+
+func (c *cacheAuth) Flush(username UserName) int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	var count int
+	for element := c.lru.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*cacheEntry).username == username {
+			c.remove(element)
+			count++
+		}
+		element = next
+	}
+	return count
+}
+
+func (c *cacheAuth) FlushAll() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	count := c.lru.Len()
+	c.entries = map[string]*list.Element{}
+	c.lru.Init()
+	return count
 }
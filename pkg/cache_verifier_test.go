@@ -0,0 +1,201 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("CacheAuth", func() {
+	var now time.Time
+	var verifier *mocks.Verifier
+	var maxEntries pkg.CacheMaxEntries
+	var staleTTL pkg.CacheStaleTTL
+	var auditLog *mocks.AuditLog
+	var cacheAuth pkg.CacheAuth
+	var ctx context.Context
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		verifier = &mocks.Verifier{}
+		verifier.VerifyReturns(true, nil)
+		auditLog = &mocks.AuditLog{}
+		maxEntries = 100
+		staleTTL = 0
+	})
+	JustBeforeEach(func() {
+		cacheAuth = pkg.NewCacheAuth(
+			verifier,
+			pkg.CacheTTL(time.Minute),
+			pkg.CacheNegativeTTL(10*time.Second),
+			staleTTL,
+			maxEntries,
+			auditLog,
+			func() time.Time { return now },
+		)
+	})
+	It("caches success", func() {
+		Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+		Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+		Expect(verifier.VerifyCallCount()).To(Equal(1))
+	})
+	It("verifies other password", func() {
+		Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+		verifier.VerifyReturns(false, nil)
+		Expect(cacheAuth.Verify(ctx, "alice", "wrong")).To(BeFalse())
+		Expect(verifier.VerifyCallCount()).To(Equal(2))
+	})
+	It("expires success after ttl", func() {
+		Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+		now = now.Add(time.Minute)
+		Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+		Expect(verifier.VerifyCallCount()).To(Equal(2))
+	})
+	It("caches failure for negative ttl", func() {
+		verifier.VerifyReturns(false, nil)
+		Expect(cacheAuth.Verify(ctx, "alice", "wrong")).To(BeFalse())
+		Expect(cacheAuth.Verify(ctx, "alice", "wrong")).To(BeFalse())
+		Expect(verifier.VerifyCallCount()).To(Equal(1))
+		now = now.Add(10 * time.Second)
+		Expect(cacheAuth.Verify(ctx, "alice", "wrong")).To(BeFalse())
+		Expect(verifier.VerifyCallCount()).To(Equal(2))
+	})
+	It("does not cache errors", func() {
+		verifier.VerifyReturns(false, errors.New("banana"))
+		_, err := cacheAuth.Verify(ctx, "alice", "secret")
+		Expect(err).NotTo(BeNil())
+		_, err = cacheAuth.Verify(ctx, "alice", "secret")
+		Expect(err).NotTo(BeNil())
+		Expect(verifier.VerifyCallCount()).To(Equal(2))
+	})
+	It("audits verifications", func() {
+		Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+		Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+		verifier.VerifyReturns(false, nil)
+		Expect(cacheAuth.Verify(ctx, "alice", "wrong")).To(BeFalse())
+		Expect(auditLog.LogCallCount()).To(Equal(2))
+		_, event := auditLog.LogArgsForCall(0)
+		Expect(event.Type).To(Equal(pkg.AuditVerifySuccess))
+		Expect(event.User).To(Equal(pkg.UserName("alice")))
+		_, event = auditLog.LogArgsForCall(1)
+		Expect(event.Type).To(Equal(pkg.AuditVerifyFailure))
+		Expect(event.Reason).To(Equal(pkg.AuditReasonInvalidCredentials))
+	})
+	It("audits backend errors", func() {
+		verifier.VerifyReturns(false, errors.New("banana"))
+		_, _ = cacheAuth.Verify(ctx, "alice", "secret")
+		Expect(auditLog.LogCallCount()).To(Equal(1))
+		_, event := auditLog.LogArgsForCall(0)
+		Expect(event.Type).To(Equal(pkg.AuditVerifyFailure))
+		Expect(event.Reason).To(Equal(pkg.AuditReasonBackendError))
+	})
+	Context("stale ttl", func() {
+		BeforeEach(func() {
+			staleTTL = pkg.CacheStaleTTL(time.Hour)
+		})
+		JustBeforeEach(func() {
+			Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+			now = now.Add(30 * time.Minute)
+		})
+		It("accepts stale entry on backend error", func() {
+			verifier.VerifyReturns(false, errors.New("ldap down"))
+			Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+			Expect(verifier.VerifyCallCount()).To(Equal(2))
+			_, event := auditLog.LogArgsForCall(auditLog.LogCallCount() - 1)
+			Expect(event.Type).To(Equal(pkg.AuditVerifyStale))
+		})
+		It("rejects other password on backend error", func() {
+			verifier.VerifyReturns(false, errors.New("ldap down"))
+			_, err := cacheAuth.Verify(ctx, "alice", "wrong")
+			Expect(err).NotTo(BeNil())
+		})
+		It("rejects stale entry after stale ttl", func() {
+			now = now.Add(31 * time.Minute)
+			verifier.VerifyReturns(false, errors.New("ldap down"))
+			_, err := cacheAuth.Verify(ctx, "alice", "secret")
+			Expect(err).NotTo(BeNil())
+		})
+		It("drops stale entry if backend rejects", func() {
+			verifier.VerifyReturns(false, nil)
+			Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeFalse())
+			now = now.Add(10 * time.Second)
+			verifier.VerifyReturns(false, errors.New("ldap down"))
+			_, err := cacheAuth.Verify(ctx, "alice", "secret")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("max entries", func() {
+		BeforeEach(func() {
+			maxEntries = 2
+		})
+		It("evicts least recently used", func() {
+			Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+			Expect(cacheAuth.Verify(ctx, "bob", "secret")).To(BeTrue())
+			Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+			Expect(cacheAuth.Verify(ctx, "carol", "secret")).To(BeTrue())
+			Expect(verifier.VerifyCallCount()).To(Equal(3))
+			Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+			Expect(verifier.VerifyCallCount()).To(Equal(3))
+			Expect(cacheAuth.Verify(ctx, "bob", "secret")).To(BeTrue())
+			Expect(verifier.VerifyCallCount()).To(Equal(4))
+		})
+	})
+	It("coalesces concurrent verifications", func() {
+		release := make(chan struct{})
+		verifier.VerifyStub = func(context.Context, pkg.UserName, pkg.Password) (bool, error) {
+			<-release
+			return true, nil
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+			}()
+		}
+		Eventually(verifier.VerifyCallCount).Should(Equal(1))
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		Expect(verifier.VerifyCallCount()).To(Equal(1))
+	})
+	It("lists entries without credentials", func() {
+		Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+		Expect(cacheAuth.Entries()).To(Equal([]pkg.CacheAuthEntry{{
+			User:       "alice",
+			Valid:      true,
+			Expires:    now.Add(time.Minute),
+			StaleUntil: now.Add(time.Minute),
+		}}))
+	})
+	It("flushes entries of user", func() {
+		Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+		Expect(cacheAuth.Verify(ctx, "alice", "other")).To(BeTrue())
+		Expect(cacheAuth.Verify(ctx, "bob", "secret")).To(BeTrue())
+		Expect(cacheAuth.Flush("alice")).To(Equal(2))
+		Expect(cacheAuth.Entries()).To(HaveLen(1))
+		Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+		Expect(verifier.VerifyCallCount()).To(Equal(4))
+	})
+	It("flushes all entries", func() {
+		Expect(cacheAuth.Verify(ctx, "alice", "secret")).To(BeTrue())
+		Expect(cacheAuth.Verify(ctx, "bob", "secret")).To(BeTrue())
+		Expect(cacheAuth.FlushAll()).To(Equal(2))
+		Expect(cacheAuth.Entries()).To(BeEmpty())
+		Expect(cacheAuth.Verify(ctx, "bob", "secret")).To(BeTrue())
+		Expect(verifier.VerifyCallCount()).To(Equal(3))
+	})
+})
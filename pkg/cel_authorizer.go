@@ -0,0 +1,148 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/google/cel-go/cel"
+)
+
+// CelPolicy is a Common Expression Language expression returning a bool.
+type CelPolicy string
+
+func (c CelPolicy) String() string {
+	return string(c)
+}
+
+// CompileCelPolicy compiles the expression with the variables
+// user (name, groups, source), request (method, host, path, query, headers),
+// source (ip) and now (timestamp).
+func CompileCelPolicy(ctx context.Context, policy CelPolicy) (cel.Program, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("user", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("source", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("now", cel.TimestampType),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "create cel env failed")
+	}
+	ast, issues := env.Compile(policy.String())
+	if issues != nil && issues.Err() != nil {
+		return nil, errors.Wrapf(ctx, issues.Err(), "compile cel policy failed")
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, errors.Errorf(ctx, "cel policy returns %s instead of bool", ast.OutputType())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "create cel program failed")
+	}
+	return program, nil
+}
+
+// NewCelAuthorizer allows requests for which the compiled policy returns true.
+func NewCelAuthorizer(
+	program cel.Program,
+	groupResolver GroupResolver,
+	now func() time.Time,
+) Authorizer {
+	return &celAuthorizer{
+		program:       program,
+		groupResolver: groupResolver,
+		now:           now,
+	}
+}
+
+type celAuthorizer struct {
+	program       cel.Program
+	groupResolver GroupResolver
+	now           func() time.Time
+}
+
+func (c *celAuthorizer) Public(request *http.Request) bool {
+	return false
+}
+
+func (c *celAuthorizer) Authorize(request *http.Request, identity *Identity) (bool, error) {
+	ctx := request.Context()
+	if identity.Groups == nil && identity.GroupsFromDirectory() {
+		groups, err := c.groupResolver.GroupsOfUser(ctx, identity.Name)
+		if err != nil {
+			return false, errors.Wrapf(ctx, err, "resolve groups of user %s failed", identity.Name)
+		}
+		identity.Groups = groups
+	}
+	groups := make([]string, 0, len(identity.Groups))
+	for _, group := range identity.Groups {
+		groups = append(groups, group.String())
+	}
+	headers := map[string]string{}
+	for name := range request.Header {
+		headers[strings.ToLower(name)] = request.Header.Get(name)
+	}
+	sourceIP := ""
+	if ip := ClientIP(request); ip != nil {
+		sourceIP = ip.String()
+	}
+	result, _, err := c.program.ContextEval(ctx, map[string]interface{}{
+		"user": map[string]interface{}{
+			"name":   identity.Name.String(),
+			"groups": groups,
+			"source": identity.Source.String(),
+		},
+		"request": map[string]interface{}{
+			"method":  request.Method,
+			"host":    requestHost(request),
+			"path":    request.URL.Path,
+			"query":   request.URL.RawQuery,
+			"headers": headers,
+		},
+		"source": map[string]interface{}{
+			"ip": sourceIP,
+		},
+		"now": c.now(),
+	})
+	if err != nil {
+		return false, errors.Wrapf(ctx, err, "evaluate cel policy for user %s failed", identity.Name)
+	}
+	allowed, ok := result.Value().(bool)
+	if !ok {
+		return false, errors.Errorf(ctx, "cel policy returned %v instead of bool", result.Value())
+	}
+	return allowed, nil
+}
+
+// NewAuthorizerList combines authorizers. A request is public if any authorizer
+// marks it public and authorized only if all authorizers allow it.
+func NewAuthorizerList(authorizers ...Authorizer) Authorizer {
+	return authorizerList(authorizers)
+}
+
+type authorizerList []Authorizer
+
+func (a authorizerList) Public(request *http.Request) bool {
+	for _, authorizer := range a {
+		if authorizer.Public(request) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a authorizerList) Authorize(request *http.Request, identity *Identity) (bool, error) {
+	for _, authorizer := range a {
+		allowed, err := authorizer.Authorize(request, identity)
+		if err != nil || !allowed {
+			return false, err
+		}
+	}
+	return true, nil
+}
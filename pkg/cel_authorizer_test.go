@@ -0,0 +1,135 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("CelAuthorizer", func() {
+	var ctx context.Context
+	var now time.Time
+	var groupResolver *mocks.GroupResolver
+	var authorize func(
+		policy pkg.CelPolicy,
+		method string,
+		target string,
+		identity pkg.Identity,
+	) (bool, error)
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+		groupResolver = &mocks.GroupResolver{}
+		groupResolver.GroupsOfUserReturns(pkg.GroupNames{"ops"}, nil)
+		authorize = func(
+			policy pkg.CelPolicy,
+			method string,
+			target string,
+			identity pkg.Identity,
+		) (bool, error) {
+			program, err := pkg.CompileCelPolicy(ctx, policy)
+			Expect(err).To(BeNil())
+			authorizer := pkg.NewCelAuthorizer(
+				program,
+				groupResolver,
+				func() time.Time { return now },
+			)
+			req, err := http.NewRequestWithContext(ctx, method, target, nil)
+			Expect(err).To(BeNil())
+			req.RemoteAddr = "10.1.2.3:4567"
+			req.Header.Set("X-Team", "blue")
+			return authorizer.Authorize(req, &identity)
+		}
+	})
+	DescribeTable("CompileCelPolicy returns error",
+		func(policy pkg.CelPolicy) {
+			_, err := pkg.CompileCelPolicy(ctx, policy)
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("syntax", pkg.CelPolicy(`user.name ==`)),
+		Entry("unknown variable", pkg.CelPolicy(`foo == "bar"`)),
+		Entry("not bool", pkg.CelPolicy(`"banana"`)),
+	)
+	DescribeTable("Authorize",
+		func(policy pkg.CelPolicy, method string, target string, expected bool) {
+			allowed, err := authorize(policy, method, target, pkg.Identity{
+				Name:   "bob",
+				Groups: pkg.GroupNames{"dev"},
+				Source: pkg.IdentitySourceJwt,
+			})
+			Expect(err).To(BeNil())
+			Expect(allowed).To(Equal(expected))
+		},
+		Entry("group", pkg.CelPolicy(`"dev" in user.groups`), http.MethodGet, "/", true),
+		Entry("missing group", pkg.CelPolicy(`"ops" in user.groups`), http.MethodGet, "/", false),
+		Entry("public get",
+			pkg.CelPolicy(
+				`"ops" in user.groups || (request.method == "GET" && request.path.startsWith("/public"))`,
+			),
+			http.MethodGet, "/public/x", true),
+		Entry("public post",
+			pkg.CelPolicy(
+				`"ops" in user.groups || (request.method == "GET" && request.path.startsWith("/public"))`,
+			),
+			http.MethodPost, "/public/x", false),
+		Entry("user name", pkg.CelPolicy(`user.name == "bob"`), http.MethodGet, "/", true),
+		Entry("source", pkg.CelPolicy(`user.source == "jwt"`), http.MethodGet, "/", true),
+		Entry("source ip", pkg.CelPolicy(`source.ip.startsWith("10.")`), http.MethodGet, "/", true),
+		Entry("header", pkg.CelPolicy(`request.headers["x-team"] == "blue"`), http.MethodGet, "/", true),
+		Entry("query", pkg.CelPolicy(`request.query == "a=1"`), http.MethodGet, "/?a=1", true),
+		Entry("office hours",
+			pkg.CelPolicy(`now.getHours() >= 8 && now.getHours() < 18`),
+			http.MethodGet, "/", true),
+	)
+	It("resolves groups of password users", func() {
+		allowed, err := authorize(
+			pkg.CelPolicy(`"ops" in user.groups`),
+			http.MethodGet,
+			"/",
+			pkg.Identity{Name: "bob", Source: pkg.IdentitySourcePassword},
+		)
+		Expect(err).To(BeNil())
+		Expect(allowed).To(BeTrue())
+		Expect(groupResolver.GroupsOfUserCallCount()).To(Equal(1))
+	})
+	It("returns error on evaluation failure", func() {
+		_, err := authorize(
+			pkg.CelPolicy(`request.headers["missing"] == "x"`),
+			http.MethodGet,
+			"/",
+			pkg.Identity{Name: "bob", Groups: pkg.GroupNames{}},
+		)
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("AuthorizerList", func() {
+	It("requires all authorizers to allow", func() {
+		allow := &mocks.Authorizer{}
+		allow.AuthorizeReturns(true, nil)
+		deny := &mocks.Authorizer{}
+		deny.AuthorizeReturns(false, nil)
+		deny.PublicReturns(true)
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+		Expect(err).To(BeNil())
+
+		allowed, err := pkg.NewAuthorizerList(allow, deny).Authorize(req, &pkg.Identity{})
+		Expect(err).To(BeNil())
+		Expect(allowed).To(BeFalse())
+		Expect(pkg.NewAuthorizerList(allow, deny).Public(req)).To(BeTrue())
+
+		allowed, err = pkg.NewAuthorizerList(allow, allow).Authorize(req, &pkg.Identity{})
+		Expect(err).To(BeNil())
+		Expect(allowed).To(BeTrue())
+	})
+})
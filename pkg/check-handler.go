@@ -7,18 +7,16 @@ package pkg
 import (
 	"fmt"
 	"net/http"
-
-	"github.com/golang/glog"
 )
 
 func NewCheckHandler(check func() error) http.Handler {
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
 		if err := check(); err != nil {
-			glog.V(2).Infof("check => failed: %v", err)
+			infof(req.Context(), 2, "check => failed: %v", err)
 			http.Error(resp, fmt.Sprintf("check failed"), http.StatusInternalServerError)
 			return
 		}
-		glog.V(4).Infof("check => ok")
+		infof(req.Context(), 4, "check => ok")
 		resp.WriteHeader(http.StatusOK)
 		fmt.Fprintf(resp, "ok")
 	})
@@ -4,13 +4,15 @@
 
 package pkg
 
+import "context"
+
 //counterfeiter:generate -o ../mocks/check.go --fake-name Check . Check
 type Check interface {
-	Check(username string, password string) (bool, error)
+	Check(ctx context.Context, username string, password string) (bool, error)
 }
 
-type CheckFunc func(username string, password string) (bool, error)
+type CheckFunc func(ctx context.Context, username string, password string) (bool, error)
 
-func (c CheckFunc) Check(username string, password string) (bool, error) {
-	return c(username, password)
+func (c CheckFunc) Check(ctx context.Context, username string, password string) (bool, error) {
+	return c(ctx, username, password)
 }
@@ -0,0 +1,246 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/bborbe/errors"
+)
+
+// ClientCertMode defines how a verified client certificate is used.
+type ClientCertMode string
+
+func (c ClientCertMode) String() string {
+	return string(c)
+}
+
+const (
+	// ClientCertModeAlternative accepts a verified certificate instead of a password.
+	ClientCertModeAlternative ClientCertMode = "alternative"
+	// ClientCertModeAdditional requires a verified certificate and a password.
+	ClientCertModeAdditional ClientCertMode = "additional"
+)
+
+// ClientCertUsernameTemplate is a text/template executed with the *x509.Certificate,
+// e.g. "{{.Subject.CommonName}}" or "{{index .EmailAddresses 0}}".
+type ClientCertUsernameTemplate string
+
+func (c ClientCertUsernameTemplate) String() string {
+	return string(c)
+}
+
+// Parse returns the compiled template.
+func (c ClientCertUsernameTemplate) Parse() (*template.Template, error) {
+	return template.New("username").Option("missingkey=error").Parse(c.String())
+}
+
+type ClientCertGroupFile string
+
+func (c ClientCertGroupFile) String() string {
+	return string(c)
+}
+
+// ClientCertGroupMapping assigns groups to certificate usernames.
+type ClientCertGroupMapping map[UserName]GroupNames
+
+// ReadClientCertGroupFile parses a json object of username to list of groups.
+func ReadClientCertGroupFile(
+	ctx context.Context,
+	groupFile ClientCertGroupFile,
+) (ClientCertGroupMapping, error) {
+	content, err := os.ReadFile(groupFile.String())
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "read group file %s failed", groupFile)
+	}
+	result := ClientCertGroupMapping{}
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, errors.Wrapf(ctx, err, "unmarshal group file %s failed", groupFile)
+	}
+	return result, nil
+}
+
+type ClientCertUser struct {
+	Name   UserName
+	Groups GroupNames
+}
+
+type clientCertUserContextKey struct{}
+
+// WithClientCertUser returns a copy of ctx carrying the user of the client certificate.
+func WithClientCertUser(ctx context.Context, user *ClientCertUser) context.Context {
+	return context.WithValue(ctx, clientCertUserContextKey{}, user)
+}
+
+// ClientCertUserFromContext returns the certificate user of a request continuing with
+// password auth.
+func ClientCertUserFromContext(ctx context.Context) (*ClientCertUser, bool) {
+	user, ok := ctx.Value(clientCertUserContextKey{}).(*ClientCertUser)
+	return user, ok
+}
+
+//counterfeiter:generate -o ../mocks/client-cert-auth.go --fake-name ClientCertAuth . ClientCertAuth
+type ClientCertAuth interface {
+	// Authenticate returns the user of the verified client certificate.
+	// found is false if the connection has no verified client certificate.
+	Authenticate(request *http.Request) (user *ClientCertUser, found bool, err error)
+	// Sufficient returns true if a valid certificate authenticates a request without password.
+	Sufficient() bool
+}
+
+// ClientCertAuthDisabled is used if no client ca is configured.
+var ClientCertAuthDisabled ClientCertAuth = clientCertAuthDisabled{}
+
+type clientCertAuthDisabled struct{}
+
+func (c clientCertAuthDisabled) Authenticate(request *http.Request) (*ClientCertUser, bool, error) {
+	return nil, false, nil
+}
+
+func (c clientCertAuthDisabled) Sufficient() bool {
+	return false
+}
+
+func NewClientCertAuth(
+	mode ClientCertMode,
+	usernameTemplate *template.Template,
+	groupMapping ClientCertGroupMapping,
+	requiredGroups []GroupName,
+) ClientCertAuth {
+	return &clientCertAuth{
+		mode:             mode,
+		usernameTemplate: usernameTemplate,
+		groupMapping:     groupMapping,
+		requiredGroups:   requiredGroups,
+	}
+}
+
+type clientCertAuth struct {
+	mode             ClientCertMode
+	usernameTemplate *template.Template
+	groupMapping     ClientCertGroupMapping
+	requiredGroups   []GroupName
+}
+
+func (c *clientCertAuth) Authenticate(request *http.Request) (*ClientCertUser, bool, error) {
+	ctx := request.Context()
+	if request.TLS == nil || len(request.TLS.VerifiedChains) == 0 ||
+		len(request.TLS.VerifiedChains[0]) == 0 {
+		return nil, false, nil
+	}
+	certificate := request.TLS.VerifiedChains[0][0]
+	buf := &bytes.Buffer{}
+	if err := c.usernameTemplate.Execute(buf, certificate); err != nil {
+		return nil, true, errors.Wrapf(
+			ctx,
+			err,
+			"create username for certificate %s failed",
+			certificate.Subject,
+		)
+	}
+	username := strings.TrimSpace(buf.String())
+	if len(username) == 0 {
+		return nil, true, errors.Errorf(ctx, "empty username for certificate %s", certificate.Subject)
+	}
+	user := &ClientCertUser{
+		Name: UserName(username),
+	}
+	for _, ou := range certificate.Subject.OrganizationalUnit {
+		user.Groups = append(user.Groups, GroupName(ou))
+	}
+	user.Groups = append(user.Groups, c.groupMapping[user.Name]...)
+	if missing := user.Groups.Missing(c.requiredGroups); len(missing) > 0 {
+		return nil, true, errors.Errorf(
+			ctx,
+			"certificate user %s has not required groups %v",
+			user.Name,
+			missing,
+		)
+	}
+	infof(
+		request.Context(),
+		2,
+		"client certificate of user %s with groups %v is valid",
+		user.Name,
+		user.Groups,
+	)
+	return user, true, nil
+}
+
+func (c *clientCertAuth) Sufficient() bool {
+	return c.mode == ClientCertModeAlternative
+}
+
+// serveClientCert forwards requests with a sufficient client certificate to the subhandler
+// and rejects certificates without required groups.
+// It returns false if password auth should continue, with the certificate user stored
+// in the context of the returned request.
+func serveClientCert(
+	clientCertAuth ClientCertAuth,
+	authorizer Authorizer,
+	subhandler http.Handler,
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) (*http.Request, bool) {
+	user, found, err := clientCertAuth.Authenticate(request)
+	if !found {
+		return request, false
+	}
+	if err != nil {
+		infof(request.Context(), 1, "client certificate auth failed: %v", err)
+		responseWriter.WriteHeader(http.StatusForbidden)
+		return request, true
+	}
+	if !clientCertAuth.Sufficient() {
+		infof(request.Context(), 4, "client certificate of %s valid, continue with password", user.Name)
+		return request.WithContext(WithClientCertUser(request.Context(), user)), false
+	}
+	serveIdentity(authorizer, subhandler, responseWriter, request, &Identity{
+		Name:   user.Name,
+		Groups: user.Groups,
+		Source: IdentitySourceClientCert,
+		Method: AuthMethodClientCert,
+	})
+	return request, true
+}
+
+// clientCertUserMatches returns false if the request carries the client certificate of
+// another user than the user authenticated with a password.
+func clientCertUserMatches(request *http.Request, user UserName) bool {
+	certUser, ok := ClientCertUserFromContext(request.Context())
+	if !ok || certUser.Name == user {
+		return true
+	}
+	warningf(
+		request.Context(),
+		"user %v does not match user %v of client certificate",
+		user,
+		certUser.Name,
+	)
+	return false
+}
+
+// NewClientCertGroupResolver adds the groups of the client certificate to the groups of
+// a user authenticated with a password and an additional certificate.
+func NewClientCertGroupResolver(groupResolver GroupResolver) GroupResolver {
+	return GroupResolverFunc(
+		func(ctx context.Context, username UserName) (GroupNames, error) {
+			groups, err := groupResolver.GroupsOfUser(ctx, username)
+			if err != nil {
+				return nil, err
+			}
+			if certUser, ok := ClientCertUserFromContext(ctx); ok && certUser.Name == username {
+				groups = append(append(GroupNames{}, groups...), certUser.Groups...)
+			}
+			return groups, nil
+		},
+	)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("ClientCertAuth", func() {
+	var ctx context.Context
+	var err error
+	var certificate *x509.Certificate
+	var usernameTemplate pkg.ClientCertUsernameTemplate
+	var groupMapping pkg.ClientCertGroupMapping
+	var requiredGroups []pkg.GroupName
+	var req *http.Request
+	var user *pkg.ClientCertUser
+	var found bool
+	BeforeEach(func() {
+		ctx = context.Background()
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).To(BeNil())
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject: pkix.Name{
+				CommonName:         "myuser",
+				OrganizationalUnit: []string{"ops"},
+			},
+			EmailAddresses: []string{"myuser@example.com"},
+			NotBefore:      time.Now().Add(-time.Hour),
+			NotAfter:       time.Now().Add(time.Hour),
+			ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).To(BeNil())
+		certificate, err = x509.ParseCertificate(der)
+		Expect(err).To(BeNil())
+
+		usernameTemplate = "{{.Subject.CommonName}}"
+		groupMapping = pkg.ClientCertGroupMapping{}
+		requiredGroups = nil
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+		Expect(err).To(BeNil())
+		req.TLS = &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{certificate}},
+		}
+	})
+	JustBeforeEach(func() {
+		tmpl, parseErr := usernameTemplate.Parse()
+		Expect(parseErr).To(BeNil())
+		clientCertAuth := pkg.NewClientCertAuth(
+			pkg.ClientCertModeAlternative,
+			tmpl,
+			groupMapping,
+			requiredGroups,
+		)
+		user, found, err = clientCertAuth.Authenticate(req)
+	})
+	Context("common name", func() {
+		It("returns user", func() {
+			Expect(err).To(BeNil())
+			Expect(found).To(BeTrue())
+			Expect(user.Name).To(Equal(pkg.UserName("myuser")))
+			Expect(user.Groups).To(Equal(pkg.GroupNames{"ops"}))
+		})
+	})
+	Context("email san", func() {
+		BeforeEach(func() {
+			usernameTemplate = "{{index .EmailAddresses 0}}"
+		})
+		It("returns user", func() {
+			Expect(err).To(BeNil())
+			Expect(user.Name).To(Equal(pkg.UserName("myuser@example.com")))
+		})
+	})
+	Context("group mapping", func() {
+		BeforeEach(func() {
+			groupMapping = pkg.ClientCertGroupMapping{"myuser": {"admin"}}
+			requiredGroups = []pkg.GroupName{"admin", "ops"}
+		})
+		It("returns user with groups", func() {
+			Expect(err).To(BeNil())
+			Expect(user.Groups).To(Equal(pkg.GroupNames{"ops", "admin"}))
+		})
+	})
+	Context("required group missing", func() {
+		BeforeEach(func() {
+			requiredGroups = []pkg.GroupName{"admin"}
+		})
+		It("returns error", func() {
+			Expect(found).To(BeTrue())
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("no verified certificate", func() {
+		BeforeEach(func() {
+			req.TLS = &tls.ConnectionState{}
+		})
+		It("returns not found", func() {
+			Expect(found).To(BeFalse())
+			Expect(err).To(BeNil())
+		})
+	})
+	Context("plain http", func() {
+		BeforeEach(func() {
+			req.TLS = nil
+		})
+		It("returns not found", func() {
+			Expect(found).To(BeFalse())
+			Expect(err).To(BeNil())
+		})
+	})
+})
+
+var _ = Describe("ClientCertGroupResolver", func() {
+	var ctx context.Context
+	var groupResolver pkg.GroupResolver
+	BeforeEach(func() {
+		ctx = pkg.WithClientCertUser(context.Background(), &pkg.ClientCertUser{
+			Name:   "alice",
+			Groups: pkg.GroupNames{"ops"},
+		})
+		groupResolver = pkg.NewClientCertGroupResolver(pkg.GroupResolverFunc(
+			func(ctx context.Context, username pkg.UserName) (pkg.GroupNames, error) {
+				return pkg.GroupNames{"dev"}, nil
+			},
+		))
+	})
+	It("adds groups of certificate", func() {
+		Expect(groupResolver.GroupsOfUser(ctx, "alice")).To(Equal(pkg.GroupNames{"dev", "ops"}))
+	})
+	It("ignores certificate of other user", func() {
+		Expect(groupResolver.GroupsOfUser(ctx, "bob")).To(Equal(pkg.GroupNames{"dev"}))
+	})
+})
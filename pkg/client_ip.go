@@ -0,0 +1,105 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/bborbe/errors"
+)
+
+// Networks is a list of CIDR ranges.
+type Networks []*net.IPNet
+
+// ParseNetworks parses CIDRs like "10.0.0.0/8". Single ips are treated as /32 or /128.
+func ParseNetworks(ctx context.Context, cidrs []string) (Networks, error) {
+	var result Networks
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if len(cidr) == 0 {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, errors.Errorf(ctx, "ip %s invalid", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "parse cidr %s failed", cidr)
+		}
+		result = append(result, network)
+	}
+	return result, nil
+}
+
+// Contains returns true if any network contains the ip.
+func (n Networks) Contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range n {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveClientIP returns the ip of the client. X-Forwarded-For hops are only
+// followed from right to left as long as the previous hop is a trusted proxy.
+func ResolveClientIP(request *http.Request, trustedProxies Networks) net.IP {
+	ip := remoteAddrIP(request)
+	if len(trustedProxies) == 0 {
+		return ip
+	}
+	var hops []string
+	for _, value := range request.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	for i := len(hops) - 1; i >= 0 && trustedProxies.Contains(ip); i-- {
+		next := net.ParseIP(hops[i])
+		if next == nil {
+			break
+		}
+		ip = next
+	}
+	return ip
+}
+
+type clientIPContextKey struct{}
+
+// WithClientIP returns a copy of ctx carrying the resolved client ip.
+func WithClientIP(ctx context.Context, ip net.IP) context.Context {
+	return context.WithValue(ctx, clientIPContextKey{}, ip)
+}
+
+// ClientIP returns the client ip resolved by the ip filter or the remote address.
+func ClientIP(request *http.Request) net.IP {
+	if ip, ok := request.Context().Value(clientIPContextKey{}).(net.IP); ok {
+		return ip
+	}
+	return remoteAddrIP(request)
+}
+
+func remoteAddrIP(request *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
@@ -5,7 +5,8 @@
 package pkg
 
 import (
-	"github.com/golang/glog"
+	"context"
+
 	"go.jona.me/crowd"
 )
 
@@ -21,8 +22,12 @@ func NewCrowdAuth(crowdAuthenticate crowdAuthenticate) Verifier {
 	}
 }
 
-func (a *crowdAuth) Verify(username UserName, password Password) (bool, error) {
-	glog.V(2).Infof("verify user %s with password-length %d", username, len(password))
+func (a *crowdAuth) Verify(
+	ctx context.Context,
+	username UserName,
+	password Password,
+) (bool, error) {
+	infof(ctx, 2, "verify user %s with password-length %d", username, len(password))
 	_, err := a.crowdAuthenticate(username.String(), password.String())
 	if err != nil {
 		return false, err
@@ -7,8 +7,6 @@ package pkg
 import (
 	"net/http"
 	"time"
-
-	"github.com/golang/glog"
 )
 
 type debugHandler struct {
@@ -23,10 +21,16 @@ func NewDebugHandler(subhandler http.Handler) *debugHandler {
 
 func (h *debugHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
 	start := time.Now()
-	defer glog.V(4).
-		Infof("%s %s takes %dms", request.Method, request.URL.Path, time.Now().Sub(start)/time.Millisecond)
+	defer infof(
+		request.Context(),
+		4,
+		"%s %s takes %dms",
+		request.Method,
+		request.URL.Path,
+		time.Now().Sub(start)/time.Millisecond,
+	)
 
-	glog.V(4).Infof("request %v: ", request)
+	infof(request.Context(), 4, "request %v: ", request)
 	h.subhandler.ServeHTTP(responseWriter, request)
-	glog.V(4).Infof("response %v: ", responseWriter)
+	infof(request.Context(), 4, "response %v: ", responseWriter)
 }
@@ -0,0 +1,183 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"crypto/md5" // #nosec G501 -- required by RFC 7616 for legacy clients
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// DigestAlgorithm is the hash algorithm of RFC 7616 digest auth.
+type DigestAlgorithm string
+
+func (d DigestAlgorithm) String() string {
+	return string(d)
+}
+
+const (
+	DigestAlgorithmMD5    DigestAlgorithm = "MD5"
+	DigestAlgorithmSHA256 DigestAlgorithm = "SHA-256"
+)
+
+// DigestAlgorithms in order of preference for challenges.
+var DigestAlgorithms = []DigestAlgorithm{DigestAlgorithmSHA256, DigestAlgorithmMD5}
+
+// Valid returns true for supported algorithms.
+func (d DigestAlgorithm) Valid() bool {
+	return d == DigestAlgorithmMD5 || d == DigestAlgorithmSHA256
+}
+
+// HexLength returns the length of a hex encoded hash of the algorithm.
+func (d DigestAlgorithm) HexLength() int {
+	if d == DigestAlgorithmSHA256 {
+		return sha256.Size * 2
+	}
+	return md5.Size * 2
+}
+
+// Hash returns the hex encoded hash of the given parts joined by colon.
+func (d DigestAlgorithm) Hash(parts ...string) string {
+	var h hash.Hash
+	if d == DigestAlgorithmSHA256 {
+		h = sha256.New()
+	} else {
+		h = md5.New() // #nosec G401 -- required by RFC 7616 for legacy clients
+	}
+	h.Write([]byte(strings.Join(parts, ":")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DigestHa1 returns H(username:realm:password).
+func DigestHa1(
+	algorithm DigestAlgorithm,
+	username UserName,
+	realm string,
+	password Password,
+) string {
+	return algorithm.Hash(username.String(), realm, password.String())
+}
+
+// DigestCredentials are the parameters of a "Authorization: Digest ..." header.
+type DigestCredentials struct {
+	Username  string
+	Realm     string
+	Nonce     string
+	URI       string
+	Response  string
+	Algorithm DigestAlgorithm
+	Cnonce    string
+	Opaque    string
+	Qop       string
+	Nc        string
+	Userhash  bool
+}
+
+// ExpectedResponse returns the response a client with the given HA1 has to send.
+func (d DigestCredentials) ExpectedResponse(ha1 string, method string) string {
+	ha2 := d.Algorithm.Hash(method, d.URI)
+	return d.Algorithm.Hash(ha1, d.Nonce, d.Nc, d.Cnonce, d.Qop, ha2)
+}
+
+// ParseDigestAuthorizationHeader parses the header value of RFC 7616 digest credentials.
+func ParseDigestAuthorizationHeader(header string) (*DigestCredentials, error) {
+	if !strings.HasPrefix(header, "Digest ") {
+		return nil, fmt.Errorf("header Authorization invalid")
+	}
+	params, err := parseDigestParams(strings.TrimPrefix(header, "Digest "))
+	if err != nil {
+		return nil, err
+	}
+	result := &DigestCredentials{
+		Username:  params["username"],
+		Realm:     params["realm"],
+		Nonce:     params["nonce"],
+		URI:       params["uri"],
+		Response:  strings.ToLower(params["response"]),
+		Algorithm: DigestAlgorithm(params["algorithm"]),
+		Cnonce:    params["cnonce"],
+		Opaque:    params["opaque"],
+		Qop:       params["qop"],
+		Nc:        params["nc"],
+		Userhash:  params["userhash"] == "true",
+	}
+	if len(result.Algorithm) == 0 {
+		result.Algorithm = DigestAlgorithmMD5
+	}
+	for name, value := range map[string]string{
+		"username": result.Username,
+		"nonce":    result.Nonce,
+		"uri":      result.URI,
+		"response": result.Response,
+		"cnonce":   result.Cnonce,
+		"nc":       result.Nc,
+	} {
+		if len(value) == 0 {
+			return nil, fmt.Errorf("parameter %s missing", name)
+		}
+	}
+	return result, nil
+}
+
+// parseDigestParams parses a comma separated list of key=value or key="quoted value".
+func parseDigestParams(value string) (map[string]string, error) {
+	result := map[string]string{}
+	for len(value) > 0 {
+		value = strings.TrimLeft(value, " \t,")
+		if len(value) == 0 {
+			break
+		}
+		pos := strings.Index(value, "=")
+		if pos <= 0 {
+			return nil, fmt.Errorf("parse digest params failed")
+		}
+		key := strings.ToLower(strings.TrimSpace(value[:pos]))
+		value = strings.TrimLeft(value[pos+1:], " \t")
+		if strings.HasPrefix(value, `"`) {
+			var sb strings.Builder
+			i := 1
+			for ; i < len(value) && value[i] != '"'; i++ {
+				if value[i] == '\\' && i+1 < len(value) {
+					i++
+				}
+				sb.WriteByte(value[i])
+			}
+			if i >= len(value) {
+				return nil, fmt.Errorf("unterminated quoted value of %s", key)
+			}
+			result[key] = sb.String()
+			value = value[i+1:]
+		} else {
+			end := strings.Index(value, ",")
+			if end < 0 {
+				end = len(value)
+			}
+			result[key] = strings.TrimSpace(value[:end])
+			value = value[end:]
+		}
+	}
+	return result, nil
+}
+
+// CreateDigestChallenge returns the value of a WWW-Authenticate header.
+func CreateDigestChallenge(
+	realm string,
+	nonce string,
+	opaque string,
+	algorithm DigestAlgorithm,
+	stale bool,
+) string {
+	return fmt.Sprintf(
+		`Digest realm="%s", qop="auth", algorithm=%s, nonce="%s", opaque="%s", stale=%t`,
+		realm,
+		algorithm,
+		nonce,
+		opaque,
+		stale,
+	)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/bborbe/errors"
+)
+
+type DigestFile string
+
+func (d DigestFile) String() string {
+	return string(d)
+}
+
+//counterfeiter:generate -o ../mocks/digest-ha1-store.go --fake-name DigestHa1Store . DigestHa1Store
+type DigestHa1Store interface {
+	// Ha1 returns H(username:realm:password) of the user for the given algorithm.
+	// found is false if the user is unknown or no HA1 exists for the algorithm.
+	Ha1(
+		ctx context.Context,
+		username UserName,
+		realm string,
+		algorithm DigestAlgorithm,
+	) (ha1 string, found bool, err error)
+}
+
+// NewDigestHtdigestStore reads a htdigest file with lines of "username:realm:ha1".
+// The HA1 length selects the algorithm (32 hex for MD5, 64 hex for SHA-256).
+func NewDigestHtdigestStore(digestFile DigestFile) DigestHa1Store {
+	return &digestHtdigestStore{
+		digestFile: digestFile,
+	}
+}
+
+type digestHtdigestStore struct {
+	digestFile DigestFile
+}
+
+func (d *digestHtdigestStore) Ha1(
+	ctx context.Context,
+	username UserName,
+	realm string,
+	algorithm DigestAlgorithm,
+) (string, bool, error) {
+	content, err := os.ReadFile(d.digestFile.String())
+	if err != nil {
+		return "", false, errors.Wrapf(ctx, err, "read digest file %s failed", d.digestFile)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 3)
+		if len(parts) != 3 || parts[0] != username.String() || parts[1] != realm {
+			continue
+		}
+		if len(parts[2]) == algorithm.HexLength() {
+			return strings.ToLower(parts[2]), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// NewDigestUserFileStore computes HA1 values from the plain passwords of the file verifier.
+func NewDigestUserFileStore(userFile UserFile) DigestHa1Store {
+	return &digestUserFileStore{
+		userFile: userFile,
+	}
+}
+
+type digestUserFileStore struct {
+	userFile UserFile
+}
+
+func (d *digestUserFileStore) Ha1(
+	ctx context.Context,
+	username UserName,
+	realm string,
+	algorithm DigestAlgorithm,
+) (string, bool, error) {
+	content, err := os.ReadFile(d.userFile.String())
+	if err != nil {
+		return "", false, errors.Wrapf(ctx, err, "read user file %s failed", d.userFile)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(parts) == 2 && parts[0] == username.String() {
+			return DigestHa1(algorithm, username, realm, Password(parts[1])), true, nil
+		}
+	}
+	return "", false, nil
+}
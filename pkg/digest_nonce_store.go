@@ -0,0 +1,139 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// digestNoncePayloadSize is the size of the creation time and random part of a nonce.
+	digestNoncePayloadSize = 8 + 16
+	// digestNoncePurgeInterval limits how often used nonces are purged.
+	digestNoncePurgeInterval = time.Minute
+)
+
+type DigestNonceTTL time.Duration
+
+func (d DigestNonceTTL) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+//counterfeiter:generate -o ../mocks/digest-nonce-store.go --fake-name DigestNonceStore . DigestNonceStore
+type DigestNonceStore interface {
+	// Create returns a new server nonce.
+	Create() (string, error)
+	// Use accepts the nonce count only if it is higher than all counts used before.
+	// stale is true if the nonce is unknown or expired.
+	Use(nonce string, nc uint64) (valid bool, stale bool)
+}
+
+// NewDigestNonceStore issues nonces signed with a random key containing their creation
+// time, so issuing a nonce keeps no state. Only nonces used with valid credentials are
+// kept in memory to reject replayed nonce counts until they expire.
+func NewDigestNonceStore(ttl DigestNonceTTL, now func() time.Time) DigestNonceStore {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		glog.Warningf("create digest nonce key failed: %v", err)
+	}
+	return &digestNonceStore{
+		ttl:  ttl,
+		now:  now,
+		key:  key,
+		used: map[string]*digestNonce{},
+	}
+}
+
+type digestNonce struct {
+	created time.Time
+	lastNc  uint64
+}
+
+type digestNonceStore struct {
+	ttl DigestNonceTTL
+	now func() time.Time
+	key []byte
+
+	mux       sync.Mutex
+	used      map[string]*digestNonce
+	lastPurge time.Time
+}
+
+func (d *digestNonceStore) Create() (string, error) {
+	payload := make([]byte, digestNoncePayloadSize)
+	binary.BigEndian.PutUint64(payload, uint64(d.now().UnixNano()))
+	if _, err := rand.Read(payload[8:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append(payload, d.sign(payload)...)), nil
+}
+
+func (d *digestNonceStore) Use(nonce string, nc uint64) (bool, bool) {
+	created, ok := d.verify(nonce)
+	if !ok {
+		glog.V(2).Infof("digest nonce unknown")
+		return false, true
+	}
+	now := d.now()
+	if now.Sub(created) > d.ttl.Duration() {
+		glog.V(2).Infof("digest nonce expired")
+		return false, true
+	}
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.purge(now)
+	value, ok := d.used[nonce]
+	if !ok {
+		value = &digestNonce{created: created}
+		d.used[nonce] = value
+	}
+	if nc <= value.lastNc {
+		glog.V(1).Infof("digest nonce count %d already used", nc)
+		return false, false
+	}
+	value.lastNc = nc
+	return true, false
+}
+
+// verify returns the creation time of the nonce if it was issued with the key.
+func (d *digestNonceStore) verify(nonce string) (time.Time, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil || len(data) != digestNoncePayloadSize+sha256.Size {
+		return time.Time{}, false
+	}
+	payload := data[:digestNoncePayloadSize]
+	if !hmac.Equal(data[digestNoncePayloadSize:], d.sign(payload)) {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(payload))), true
+}
+
+func (d *digestNonceStore) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, d.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// purge removes expired nonces at most once per digestNoncePurgeInterval.
+func (d *digestNonceStore) purge(now time.Time) {
+	if now.Sub(d.lastPurge) < digestNoncePurgeInterval {
+		return
+	}
+	d.lastPurge = now
+	for key, value := range d.used {
+		if now.Sub(value.created) > d.ttl.Duration() {
+			delete(d.used, key)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("DigestNonceStore", func() {
+	var now time.Time
+	var nonceStore pkg.DigestNonceStore
+	var nonce string
+	BeforeEach(func() {
+		now = time.Unix(1700000000, 0)
+		nonceStore = pkg.NewDigestNonceStore(
+			pkg.DigestNonceTTL(time.Minute),
+			func() time.Time { return now },
+		)
+		var err error
+		nonce, err = nonceStore.Create()
+		Expect(err).To(BeNil())
+	})
+	use := func(nonce string, nc uint64) (bool, bool) {
+		return nonceStore.Use(nonce, nc)
+	}
+	It("accepts increasing nonce count", func() {
+		Expect(use(nonce, 1)).To(BeTrue())
+		Expect(use(nonce, 2)).To(BeTrue())
+	})
+	It("rejects used nonce count", func() {
+		Expect(use(nonce, 2)).To(BeTrue())
+		valid, stale := use(nonce, 2)
+		Expect(valid).To(BeFalse())
+		Expect(stale).To(BeFalse())
+	})
+	It("rejects expired nonce as stale", func() {
+		now = now.Add(2 * time.Minute)
+		valid, stale := use(nonce, 1)
+		Expect(valid).To(BeFalse())
+		Expect(stale).To(BeTrue())
+	})
+	It("rejects modified nonce as stale", func() {
+		modified := []byte(nonce)
+		modified[0] ^= 1
+		valid, stale := use(string(modified), 1)
+		Expect(valid).To(BeFalse())
+		Expect(stale).To(BeTrue())
+	})
+	It("rejects nonce of other store as stale", func() {
+		other := pkg.NewDigestNonceStore(
+			pkg.DigestNonceTTL(time.Minute),
+			func() time.Time { return now },
+		)
+		valid, stale := other.Use(nonce, 1)
+		Expect(valid).To(BeFalse())
+		Expect(stale).To(BeTrue())
+	})
+	It("issues different nonces", func() {
+		Expect(nonceStore.Create()).NotTo(Equal(nonce))
+	})
+})
@@ -6,11 +6,10 @@ package pkg
 
 import (
 	"bufio"
+	"context"
 	"io"
 	"os"
 	"strings"
-
-	"github.com/golang/glog"
 )
 
 type UserFile string
@@ -29,11 +28,15 @@ func NewFileAuth(userFile UserFile) Verifier {
 	}
 }
 
-func (a *fileAuth) Verify(username UserName, password Password) (bool, error) {
-	glog.V(2).Infof("verify user %s with password-length %d", username, len(password))
+func (a *fileAuth) Verify(
+	ctx context.Context,
+	username UserName,
+	password Password,
+) (bool, error) {
+	infof(ctx, 2, "verify user %s with password-length %d", username, len(password))
 	file, err := os.Open(a.userFile.String())
 	if err != nil {
-		glog.Warningf("open user file %v failed: %v", a.userFile.String(), err)
+		warningf(ctx, "open user file %v failed: %v", a.userFile.String(), err)
 		return false, err
 	}
 	reader := bufio.NewReader(file)
@@ -41,21 +44,21 @@ func (a *fileAuth) Verify(username UserName, password Password) (bool, error) {
 		line, err := reader.ReadString('\n')
 		line = strings.TrimSpace(line)
 		if err != nil && err != io.EOF {
-			glog.Warningf("read line of file %v failed: %v", a.userFile.String(), err)
+			warningf(ctx, "read line of file %v failed: %v", a.userFile.String(), err)
 			return false, err
 		}
 		parts := strings.SplitN(line, ":", 2)
 		if len(parts) == 2 && parts[0] == username.String() {
 			if parts[1] == password.String() {
-				glog.V(2).Infof("found user and password is valid")
+				infof(ctx, 2, "found user and password is valid")
 				return true, nil
 			} else {
-				glog.V(1).Infof("found user and password is invalid")
+				infof(ctx, 1, "found user and password is invalid")
 				return false, nil
 			}
 		}
 		if err == io.EOF {
-			glog.V(1).Infof("reach eof, user %v not found", username)
+			infof(ctx, 1, "reach eof, user %v not found", username)
 			return false, nil
 		}
 	}
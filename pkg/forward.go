@@ -8,8 +8,14 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
-	"github.com/golang/glog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type executeRequest func(address string, req *http.Request) (resp *http.Response, err error)
@@ -27,47 +33,79 @@ func NewForwardHandler(target string, executeRequest executeRequest) http.Handle
 }
 
 func (h *forwardHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
-	glog.V(4).Infof("forward request")
+	infof(req.Context(), 4, "forward request")
 	if err := h.serveHTTP(resp, req); err != nil {
-		glog.V(2).Infof("forward request failed: %v", err)
+		infof(req.Context(), 2, "forward request failed: %v", err)
 		resp.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	glog.V(4).Infof("request forward succesful")
+	infof(req.Context(), 4, "request forward succesful")
 }
 
 func (h *forwardHandler) serveHTTP(resp http.ResponseWriter, req *http.Request) error {
-	glog.V(4).Infof("%v", req)
+	infof(req.Context(), 4, "%v", req)
 	targetURL := &url.URL{
 		Scheme:   "http",
 		Host:     h.target,
 		Path:     req.URL.Path,
 		RawQuery: req.URL.RawQuery,
 	}
-	glog.V(4).Infof("forward request %s %s", req.Method, targetURL.String())
+	infof(req.Context(), 4, "forward request %s %s", req.Method, targetURL.String())
 	subreq, err := http.NewRequest(
 		req.Method,
 		targetURL.String(),
 		req.Body,
 	) // #nosec G704 -- proxy forwards to trusted target
 	if err != nil {
-		glog.V(2).Infof("create request to %s failed: %v", targetURL, err)
+		infof(req.Context(), 2, "create request to %s failed: %v", targetURL, err)
 		return err
 	}
-	subreq.Header = req.Header
+	ctx, span := tracer().Start(
+		req.Context(),
+		req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(h.target),
+			semconv.URLPath(req.URL.Path),
+		),
+	)
+	defer span.End()
+	subreq.Header = req.Header.Clone()
+	requestID := RequestIDFromContext(req.Context())
+	if len(requestID) > 0 {
+		subreq.Header.Set(HeaderRequestID, requestID)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(subreq.Header))
+	start := time.Now()
 	subresp, err := h.executeRequest(h.target, subreq)
 	if err != nil {
-		glog.V(2).Infof("execute request to %v failed: %v", h.target, err)
+		infof(req.Context(), 2, "execute request to %v failed: %v", h.target, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "execute request failed")
+		upstreamErrorsTotal.Inc()
+		recordUpstream(req.Context(), time.Since(start))
 		return err
 	}
-	glog.V(4).Infof("write response")
+	defer subresp.Body.Close()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(subresp.StatusCode))
+	if subresp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(subresp.StatusCode))
+	}
+	duration := time.Since(start)
+	upstreamDuration.WithLabelValues(strconv.Itoa(subresp.StatusCode)).Observe(duration.Seconds())
+	recordUpstream(req.Context(), duration)
+	infof(req.Context(), 4, "write response")
 	copyHeader(resp, &subresp.Header)
+	if len(requestID) > 0 {
+		resp.Header().Set(HeaderRequestID, requestID)
+	}
 	resp.WriteHeader(subresp.StatusCode)
 	if _, err := io.Copy(resp, subresp.Body); err != nil {
-		glog.V(2).Infof("copy body failed: %v", err)
+		infof(req.Context(), 2, "copy body failed: %v", err)
 		return err
 	}
-	glog.V(4).Infof("forward request done")
+	infof(req.Context(), 4, "forward request done")
 	return nil
 }
 
@@ -0,0 +1,164 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"golang.org/x/sync/singleflight"
+)
+
+//counterfeiter:generate -o ../mocks/group-resolver.go --fake-name GroupResolver . GroupResolver
+type GroupResolver interface {
+	// GroupsOfUser returns the groups of a user authenticated with a password.
+	GroupsOfUser(ctx context.Context, username UserName) (GroupNames, error)
+}
+
+type GroupResolverFunc func(ctx context.Context, username UserName) (GroupNames, error)
+
+func (g GroupResolverFunc) GroupsOfUser(
+	ctx context.Context,
+	username UserName,
+) (GroupNames, error) {
+	return g(ctx, username)
+}
+
+// GroupResolverNone is used for verifiers without groups.
+var GroupResolverNone GroupResolver = GroupResolverFunc(
+	func(ctx context.Context, username UserName) (GroupNames, error) {
+		return GroupNames{}, nil
+	},
+)
+
+// NewLdapGroupResolver returns the ldap groups of the user.
+func NewLdapGroupResolver(ldapAuthenticator LdapAuthenticator) GroupResolver {
+	return GroupResolverFunc(
+		func(ctx context.Context, username UserName) (GroupNames, error) {
+			groups, err := ldapAuthenticator.GetGroupsOfUser(username)
+			if err != nil {
+				return nil, errors.Wrapf(ctx, err, "get groups of user %s failed", username)
+			}
+			return groups.Names(), nil
+		},
+	)
+}
+
+// NewCacheGroupResolver caches the groups of the groupResolver per user for ttl, so
+// authorization does not search the directory on every request. If the groupResolver
+// fails, cached groups are used for staleTTL after ttl.
+func NewCacheGroupResolver(
+	groupResolver GroupResolver,
+	ttl CacheTTL,
+	staleTTL CacheStaleTTL,
+	maxEntries CacheMaxEntries,
+	now func() time.Time,
+) GroupResolver {
+	return &cacheGroupResolver{
+		groupResolver: groupResolver,
+		ttl:           ttl,
+		staleTTL:      staleTTL,
+		maxEntries:    maxEntries,
+		now:           now,
+		entries:       map[UserName]*list.Element{},
+		lru:           list.New(),
+	}
+}
+
+type cacheGroupEntry struct {
+	username   UserName
+	groups     GroupNames
+	expires    time.Time
+	staleUntil time.Time
+}
+
+type cacheGroupResolver struct {
+	groupResolver GroupResolver
+	ttl           CacheTTL
+	staleTTL      CacheStaleTTL
+	maxEntries    CacheMaxEntries
+	now           func() time.Time
+	inflight      singleflight.Group
+
+	mux     sync.Mutex
+	entries map[UserName]*list.Element
+	lru     *list.List
+}
+
+func (c *cacheGroupResolver) GroupsOfUser(
+	ctx context.Context,
+	username UserName,
+) (GroupNames, error) {
+	if groups, found := c.get(username, false); found {
+		infof(ctx, 4, "cache hit for groups of user %v", username)
+		return groups, nil
+	}
+	result, err, _ := c.inflight.Do(username.String(), func() (interface{}, error) {
+		groups, err := c.groupResolver.GroupsOfUser(ctx, username)
+		if err != nil {
+			if groups, found := c.get(username, true); found {
+				warningf(ctx, "get groups of user %v failed, use stale cache entry: %v", username, err)
+				return groups, nil
+			}
+			return nil, err
+		}
+		c.set(username, groups)
+		return groups, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(GroupNames), nil
+}
+
+// get returns the cached groups of the user, including stale groups if stale is true.
+func (c *cacheGroupResolver) get(username UserName, stale bool) (GroupNames, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	element, ok := c.entries[username]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheGroupEntry)
+	now := c.now()
+	if !entry.staleUntil.After(now) {
+		c.remove(element)
+		return nil, false
+	}
+	if !stale && !entry.expires.After(now) {
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry.groups, true
+}
+
+func (c *cacheGroupResolver) set(username UserName, groups GroupNames) {
+	if c.ttl.Duration() <= 0 {
+		return
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if element, ok := c.entries[username]; ok {
+		c.remove(element)
+	}
+	expires := c.now().Add(c.ttl.Duration())
+	c.entries[username] = c.lru.PushFront(&cacheGroupEntry{
+		username:   username,
+		groups:     groups,
+		expires:    expires,
+		staleUntil: expires.Add(c.staleTTL.Duration()),
+	})
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries.Int() {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *cacheGroupResolver) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*cacheGroupEntry).username)
+}
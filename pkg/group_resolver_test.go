@@ -0,0 +1,93 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("CacheGroupResolver", func() {
+	var ctx context.Context
+	var now time.Time
+	var groupResolver *mocks.GroupResolver
+	var staleTTL pkg.CacheStaleTTL
+	var maxEntries pkg.CacheMaxEntries
+	var cacheGroupResolver pkg.GroupResolver
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		groupResolver = &mocks.GroupResolver{}
+		groupResolver.GroupsOfUserReturns(pkg.GroupNames{"staff"}, nil)
+		staleTTL = 0
+		maxEntries = 100
+	})
+	JustBeforeEach(func() {
+		cacheGroupResolver = pkg.NewCacheGroupResolver(
+			groupResolver,
+			pkg.CacheTTL(time.Minute),
+			staleTTL,
+			maxEntries,
+			func() time.Time { return now },
+		)
+	})
+	It("caches groups", func() {
+		Expect(cacheGroupResolver.GroupsOfUser(ctx, "alice")).To(Equal(pkg.GroupNames{"staff"}))
+		Expect(cacheGroupResolver.GroupsOfUser(ctx, "alice")).To(Equal(pkg.GroupNames{"staff"}))
+		Expect(groupResolver.GroupsOfUserCallCount()).To(Equal(1))
+	})
+	It("resolves groups again after ttl", func() {
+		Expect(cacheGroupResolver.GroupsOfUser(ctx, "alice")).To(Equal(pkg.GroupNames{"staff"}))
+		now = now.Add(time.Minute)
+		groupResolver.GroupsOfUserReturns(pkg.GroupNames{"admin"}, nil)
+		Expect(cacheGroupResolver.GroupsOfUser(ctx, "alice")).To(Equal(pkg.GroupNames{"admin"}))
+		Expect(groupResolver.GroupsOfUserCallCount()).To(Equal(2))
+	})
+	It("does not cache errors", func() {
+		groupResolver.GroupsOfUserReturns(nil, errors.New("banana"))
+		_, err := cacheGroupResolver.GroupsOfUser(ctx, "alice")
+		Expect(err).NotTo(BeNil())
+		_, err = cacheGroupResolver.GroupsOfUser(ctx, "alice")
+		Expect(err).NotTo(BeNil())
+		Expect(groupResolver.GroupsOfUserCallCount()).To(Equal(2))
+	})
+	Context("stale ttl", func() {
+		BeforeEach(func() {
+			staleTTL = pkg.CacheStaleTTL(time.Hour)
+		})
+		JustBeforeEach(func() {
+			Expect(cacheGroupResolver.GroupsOfUser(ctx, "alice")).To(Equal(pkg.GroupNames{"staff"}))
+			groupResolver.GroupsOfUserReturns(nil, errors.New("ldap down"))
+		})
+		It("returns stale groups on error", func() {
+			now = now.Add(30 * time.Minute)
+			Expect(cacheGroupResolver.GroupsOfUser(ctx, "alice")).To(Equal(pkg.GroupNames{"staff"}))
+			Expect(groupResolver.GroupsOfUserCallCount()).To(Equal(2))
+		})
+		It("returns error after stale ttl", func() {
+			now = now.Add(2 * time.Hour)
+			_, err := cacheGroupResolver.GroupsOfUser(ctx, "alice")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("max entries", func() {
+		BeforeEach(func() {
+			maxEntries = 1
+		})
+		It("evicts least recently used", func() {
+			Expect(cacheGroupResolver.GroupsOfUser(ctx, "alice")).To(Equal(pkg.GroupNames{"staff"}))
+			Expect(cacheGroupResolver.GroupsOfUser(ctx, "bob")).To(Equal(pkg.GroupNames{"staff"}))
+			Expect(cacheGroupResolver.GroupsOfUser(ctx, "alice")).To(Equal(pkg.GroupNames{"staff"}))
+			Expect(groupResolver.GroupsOfUserCallCount()).To(Equal(3))
+		})
+	})
+})
@@ -63,6 +63,28 @@ func ParseAuthorizationBearerHttpRequestSimple(req *http.Request) (string, error
 	return ParseAuthorizationHttpRequestSimple("Bearer", req)
 }
 
+// ParseAuthorizationBearerTokenHttpRequest returns the raw token of a
+// "Authorization: Bearer <token>" header without decoding it.
+func ParseAuthorizationBearerTokenHttpRequest(req *http.Request) (string, error) {
+	authorizations := req.Header["Authorization"]
+	if len(authorizations) != 1 {
+		return "", fmt.Errorf("header Authorization invalid")
+	}
+	return ParseAuthorizationBearerTokenHeader(authorizations[0])
+}
+
+func ParseAuthorizationBearerTokenHeader(header string) (string, error) {
+	glog.V(4).Infof("parse bearer token")
+	if strings.Index(header, "Bearer ") != 0 {
+		return "", fmt.Errorf("header Authorization invalid")
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	if len(token) == 0 {
+		return "", fmt.Errorf("parse token from header failed")
+	}
+	return token, nil
+}
+
 func ParseAuthorizationBasisHttpRequest(req *http.Request) (string, string, error) {
 	return ParseAuthorizationHttpRequest("Basic", req)
 }
@@ -0,0 +1,53 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+)
+
+// IdentitySource describes how a user was authenticated.
+type IdentitySource string
+
+func (i IdentitySource) String() string {
+	return string(i)
+}
+
+const (
+	IdentitySourcePassword   IdentitySource = "password"
+	IdentitySourceDigest     IdentitySource = "digest"
+	IdentitySourceApiKey     IdentitySource = "api-key"
+	IdentitySourceClientCert IdentitySource = "client-cert"
+	IdentitySourceJwt        IdentitySource = "jwt"
+	// IdentitySourceTrustedNetwork is used for clients skipping auth in a trusted network.
+	IdentitySourceTrustedNetwork IdentitySource = "trusted-network"
+)
+
+// Identity is the authenticated user of a request.
+type Identity struct {
+	Name   UserName
+	Groups GroupNames
+	Source IdentitySource
+	Method AuthMethod
+}
+
+// GroupsFromDirectory returns true if groups are not part of the credentials
+// and have to be resolved from the user directory.
+func (i *Identity) GroupsFromDirectory() bool {
+	return i.Source == IdentitySourcePassword || i.Source == IdentitySourceDigest
+}
+
+type identityContextKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the identity.
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// IdentityFromContext returns the identity stored by the auth handlers.
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityContextKey{}).(*Identity)
+	return identity, ok
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"net/http"
+)
+
+type TrustedNetworkUser string
+
+func (t TrustedNetworkUser) String() string {
+	return string(t)
+}
+
+// NewIpFilterHandler resolves the client ip and rejects clients in the deny list
+// or outside the allow list. Clients in trusted networks skip authentication and
+// are forwarded with the trusted network user.
+func NewIpFilterHandler(
+	authHandler http.Handler,
+	forwardHandler http.Handler,
+	trustedProxies Networks,
+	allowNetworks Networks,
+	denyNetworks Networks,
+	trustedNetworks Networks,
+	trustedNetworkUser TrustedNetworkUser,
+) http.Handler {
+	h := new(ipFilterHandler)
+	h.authHandler = authHandler
+	h.forwardHandler = forwardHandler
+	h.trustedProxies = trustedProxies
+	h.allowNetworks = allowNetworks
+	h.denyNetworks = denyNetworks
+	h.trustedNetworks = trustedNetworks
+	h.trustedNetworkUser = trustedNetworkUser
+	return h
+}
+
+type ipFilterHandler struct {
+	authHandler        http.Handler
+	forwardHandler     http.Handler
+	trustedProxies     Networks
+	allowNetworks      Networks
+	denyNetworks       Networks
+	trustedNetworks    Networks
+	trustedNetworkUser TrustedNetworkUser
+}
+
+func (h *ipFilterHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	ip := ResolveClientIP(request, h.trustedProxies)
+	request = request.WithContext(WithClientIP(request.Context(), ip))
+	recordClientIP(request.Context(), ip)
+	if h.denyNetworks.Contains(ip) {
+		infof(request.Context(), 1, "client %v is denied", ip)
+		responseWriter.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if len(h.allowNetworks) > 0 && !h.allowNetworks.Contains(ip) {
+		infof(request.Context(), 1, "client %v is not allowed", ip)
+		responseWriter.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if !h.trustedNetworks.Contains(ip) {
+		h.authHandler.ServeHTTP(responseWriter, request)
+		return
+	}
+	infof(request.Context(), 4, "client %v is trusted => skip auth", ip)
+	if len(h.trustedNetworkUser) == 0 {
+		request.Header.Del(ForwardForUserHeader)
+		h.forwardHandler.ServeHTTP(responseWriter, request)
+		return
+	}
+	request.Header.Set(ForwardForUserHeader, h.trustedNetworkUser.String())
+	recordAuth(request.Context(), UserName(h.trustedNetworkUser), AuthMethodTrustedNetwork)
+	ctx := WithIdentity(request.Context(), &Identity{
+		Name:   UserName(h.trustedNetworkUser),
+		Groups: GroupNames{},
+		Source: IdentitySourceTrustedNetwork,
+		Method: AuthMethodTrustedNetwork,
+	})
+	h.forwardHandler.ServeHTTP(responseWriter, request.WithContext(ctx))
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("ResolveClientIP", func() {
+	var ctx context.Context
+	var trustedProxies pkg.Networks
+	var req *http.Request
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		trustedProxies, err = pkg.ParseNetworks(ctx, []string{"10.0.0.0/8", "192.168.1.1"})
+		Expect(err).To(BeNil())
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+		Expect(err).To(BeNil())
+		req.RemoteAddr = "10.0.0.1:1234"
+	})
+	It("returns error for invalid cidr", func() {
+		_, err := pkg.ParseNetworks(ctx, []string{"10.0.0.0/99"})
+		Expect(err).NotTo(BeNil())
+	})
+	It("returns remote address without trusted proxies", func() {
+		req.Header.Set("X-Forwarded-For", "1.2.3.4")
+		Expect(pkg.ResolveClientIP(req, nil).String()).To(Equal("10.0.0.1"))
+	})
+	It("returns remote address of untrusted client", func() {
+		req.RemoteAddr = "8.8.8.8:1234"
+		req.Header.Set("X-Forwarded-For", "1.2.3.4")
+		Expect(pkg.ResolveClientIP(req, trustedProxies).String()).To(Equal("8.8.8.8"))
+	})
+	It("follows trusted hops from the right", func() {
+		req.Header.Add("X-Forwarded-For", "6.6.6.6, 1.2.3.4")
+		req.Header.Add("X-Forwarded-For", "192.168.1.1")
+		Expect(pkg.ResolveClientIP(req, trustedProxies).String()).To(Equal("1.2.3.4"))
+	})
+	It("stops at invalid hop", func() {
+		req.Header.Set("X-Forwarded-For", "1.2.3.4, banana")
+		Expect(pkg.ResolveClientIP(req, trustedProxies).String()).To(Equal("10.0.0.1"))
+	})
+})
+
+var _ = Describe("IpFilterHandler", func() {
+	var ctx context.Context
+	var authHandler *mocks.HttpHandler
+	var forwardHandler *mocks.HttpHandler
+	var trustedNetworkUser pkg.TrustedNetworkUser
+	var allowNetworks pkg.Networks
+	var recorder *httptest.ResponseRecorder
+	var remoteAddr string
+	var forwardedFor string
+	BeforeEach(func() {
+		ctx = context.Background()
+		authHandler = &mocks.HttpHandler{}
+		forwardHandler = &mocks.HttpHandler{}
+		trustedNetworkUser = "monitoring"
+		allowNetworks = nil
+		recorder = httptest.NewRecorder()
+		forwardedFor = ""
+	})
+	JustBeforeEach(func() {
+		networks := func(cidrs ...string) pkg.Networks {
+			result, err := pkg.ParseNetworks(ctx, cidrs)
+			Expect(err).To(BeNil())
+			return result
+		}
+		if allowNetworks == nil {
+			allowNetworks = networks("10.0.0.0/8", "172.16.0.0/12")
+		}
+		handler := pkg.NewIpFilterHandler(
+			authHandler,
+			forwardHandler,
+			networks("172.16.0.1"),
+			allowNetworks,
+			networks("10.66.0.0/16"),
+			networks("10.1.0.0/16"),
+			trustedNetworkUser,
+		)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+		Expect(err).To(BeNil())
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(pkg.ForwardForUserHeader, "spoofed")
+		if len(forwardedFor) > 0 {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		handler.ServeHTTP(recorder, req)
+	})
+	Context("allowed client", func() {
+		BeforeEach(func() {
+			remoteAddr = "10.2.0.1:1234"
+		})
+		It("calls auth handler with client ip", func() {
+			Expect(authHandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := authHandler.ServeHTTPArgsForCall(0)
+			Expect(pkg.ClientIP(argRequest).String()).To(Equal("10.2.0.1"))
+		})
+	})
+	Context("client outside allow list", func() {
+		BeforeEach(func() {
+			remoteAddr = "8.8.8.8:1234"
+		})
+		It("returns forbidden", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			Expect(authHandler.ServeHTTPCallCount()).To(Equal(0))
+			Expect(forwardHandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("client in deny list", func() {
+		BeforeEach(func() {
+			remoteAddr = "10.66.0.1:1234"
+		})
+		It("returns forbidden", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			Expect(authHandler.ServeHTTPCallCount()).To(Equal(0))
+		})
+	})
+	Context("without allow list", func() {
+		BeforeEach(func() {
+			allowNetworks = pkg.Networks{}
+			remoteAddr = "8.8.8.8:1234"
+		})
+		It("calls auth handler", func() {
+			Expect(authHandler.ServeHTTPCallCount()).To(Equal(1))
+		})
+	})
+	Context("client in trusted network", func() {
+		BeforeEach(func() {
+			remoteAddr = "10.1.0.5:1234"
+		})
+		It("skips auth with trusted network user", func() {
+			Expect(authHandler.ServeHTTPCallCount()).To(Equal(0))
+			Expect(forwardHandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := forwardHandler.ServeHTTPArgsForCall(0)
+			Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(Equal("monitoring"))
+			identity, ok := pkg.IdentityFromContext(argRequest.Context())
+			Expect(ok).To(BeTrue())
+			Expect(identity.Source).To(Equal(pkg.IdentitySourceTrustedNetwork))
+		})
+		Context("without trusted network user", func() {
+			BeforeEach(func() {
+				trustedNetworkUser = ""
+			})
+			It("removes user header", func() {
+				_, argRequest := forwardHandler.ServeHTTPArgsForCall(0)
+				Expect(argRequest.Header.Get(pkg.ForwardForUserHeader)).To(BeEmpty())
+			})
+		})
+	})
+	Context("trusted network behind trusted proxy", func() {
+		BeforeEach(func() {
+			remoteAddr = "172.16.0.1:1234"
+			forwardedFor = "10.1.0.5"
+		})
+		It("skips auth", func() {
+			Expect(forwardHandler.ServeHTTPCallCount()).To(Equal(1))
+			_, argRequest := forwardHandler.ServeHTTPArgsForCall(0)
+			Expect(pkg.ClientIP(argRequest)).To(Equal(net.ParseIP("10.1.0.5")))
+		})
+	})
+	Context("forwarded for from untrusted client", func() {
+		BeforeEach(func() {
+			remoteAddr = "10.2.0.1:1234"
+			forwardedFor = "10.1.0.5"
+		})
+		It("ignores header", func() {
+			Expect(forwardHandler.ServeHTTPCallCount()).To(Equal(0))
+			Expect(authHandler.ServeHTTPCallCount()).To(Equal(1))
+		})
+	})
+})
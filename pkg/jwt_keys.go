@@ -0,0 +1,322 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+	"golang.org/x/sync/singleflight"
+)
+
+// jwksMinRefreshInterval limits how often an unknown kid triggers a refetch of the JWKS.
+const jwksMinRefreshInterval = time.Minute
+
+// jwksFetchTimeout limits a fetch of the JWKS, which is not bound to the triggering request.
+const jwksFetchTimeout = 10 * time.Second
+
+type JwtPublicKeyFile string
+
+func (j JwtPublicKeyFile) String() string {
+	return string(j)
+}
+
+type JwtJwksURL string
+
+func (j JwtJwksURL) String() string {
+	return string(j)
+}
+
+type JwtJwksRefreshInterval time.Duration
+
+func (j JwtJwksRefreshInterval) Duration() time.Duration {
+	return time.Duration(j)
+}
+
+//counterfeiter:generate -o ../mocks/jwt-key-set.go --fake-name JwtKeySet . JwtKeySet
+type JwtKeySet interface {
+	// Keys returns all public keys matching the given key id.
+	// If kid is empty all known keys are returned.
+	Keys(ctx context.Context, kid string) ([]crypto.PublicKey, error)
+}
+
+// LoadJwtPublicKeyFiles reads PEM encoded public keys or certificates.
+func LoadJwtPublicKeyFiles(
+	ctx context.Context,
+	publicKeyFiles []JwtPublicKeyFile,
+) ([]crypto.PublicKey, error) {
+	var result []crypto.PublicKey
+	for _, publicKeyFile := range publicKeyFiles {
+		content, err := os.ReadFile(publicKeyFile.String())
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "read public key file %s failed", publicKeyFile)
+		}
+		keys, err := ParseJwtPublicKeys(ctx, content)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "parse public key file %s failed", publicKeyFile)
+		}
+		result = append(result, keys...)
+	}
+	return result, nil
+}
+
+// ParseJwtPublicKeys parses all PUBLIC KEY and CERTIFICATE blocks of the given PEM content.
+func ParseJwtPublicKeys(ctx context.Context, content []byte) ([]crypto.PublicKey, error) {
+	var result []crypto.PublicKey
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, errors.Wrapf(ctx, err, "parse public key failed")
+			}
+			result = append(result, key)
+		case "RSA PUBLIC KEY":
+			key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+			if err != nil {
+				return nil, errors.Wrapf(ctx, err, "parse rsa public key failed")
+			}
+			result = append(result, key)
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, errors.Wrapf(ctx, err, "parse certificate failed")
+			}
+			result = append(result, cert.PublicKey)
+		default:
+			glog.V(2).Infof("skip pem block of type %s", block.Type)
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.Errorf(ctx, "no public key found")
+	}
+	return result, nil
+}
+
+// NewJwtStaticKeySet returns a key set with fixed keys. Tokens are checked against all keys.
+func NewJwtStaticKeySet(keys []crypto.PublicKey) JwtKeySet {
+	return &jwtStaticKeySet{
+		keys: keys,
+	}
+}
+
+type jwtStaticKeySet struct {
+	keys []crypto.PublicKey
+}
+
+func (j *jwtStaticKeySet) Keys(ctx context.Context, kid string) ([]crypto.PublicKey, error) {
+	return j.keys, nil
+}
+
+// NewJwtJwksKeySet returns a key set loaded from a JSON Web Key Set URL.
+// The keys are refetched after refreshInterval or if a token references an unknown kid.
+func NewJwtJwksKeySet(
+	httpClient *http.Client,
+	jwksURL JwtJwksURL,
+	refreshInterval JwtJwksRefreshInterval,
+) JwtKeySet {
+	return &jwtJwksKeySet{
+		httpClient:      httpClient,
+		jwksURL:         jwksURL,
+		refreshInterval: refreshInterval,
+	}
+}
+
+type jwtJwksKeySet struct {
+	httpClient      *http.Client
+	jwksURL         JwtJwksURL
+	refreshInterval JwtJwksRefreshInterval
+	inflight        singleflight.Group
+
+	mux       sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchTime time.Time
+}
+
+func (j *jwtJwksKeySet) Keys(ctx context.Context, kid string) ([]crypto.PublicKey, error) {
+	keys, refresh := j.cachedKeys(kid)
+	if refresh {
+		done := j.inflight.DoChan("jwks", func() (interface{}, error) {
+			return j.refresh(ctx)
+		})
+		// known keys are served while the refresh continues in the background
+		if _, found := keys[kid]; len(keys) == 0 || (len(kid) > 0 && !found) {
+			select {
+			case <-ctx.Done():
+				return nil, errors.Wrapf(ctx, ctx.Err(), "wait for jwks from %s failed", j.jwksURL)
+			case result := <-done:
+				if result.Err != nil {
+					return nil, result.Err
+				}
+				keys = result.Val.(map[string]crypto.PublicKey)
+			}
+		}
+	}
+
+	if len(kid) > 0 {
+		key, ok := keys[kid]
+		if !ok {
+			return nil, errors.Errorf(ctx, "unknown kid %s", kid)
+		}
+		return []crypto.PublicKey{key}, nil
+	}
+	result := make([]crypto.PublicKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, key)
+	}
+	return result, nil
+}
+
+// cachedKeys returns the known keys and whether they should be refetched.
+func (j *jwtJwksKeySet) cachedKeys(kid string) (map[string]crypto.PublicKey, bool) {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	age := time.Since(j.fetchTime)
+	_, found := j.keys[kid]
+	return j.keys, len(j.keys) == 0 || age > j.refreshInterval.Duration() ||
+		(len(kid) > 0 && !found && age > jwksMinRefreshInterval)
+}
+
+// refresh fetches the keys detached from the cancellation of the triggering request.
+// On error the known keys are kept.
+func (j *jwtJwksKeySet) refresh(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jwksFetchTimeout)
+	defer cancel()
+
+	keys, err := j.fetch(ctx)
+
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	j.fetchTime = time.Now()
+	if err != nil {
+		if len(j.keys) == 0 {
+			return nil, errors.Wrapf(ctx, err, "fetch jwks from %s failed", j.jwksURL)
+		}
+		glog.Warningf("fetch jwks from %s failed, keep using known keys: %v", j.jwksURL, err)
+		return j.keys, nil
+	}
+	glog.V(2).Infof("fetched %d keys from %s", len(keys), j.jwksURL)
+	j.keys = keys
+	return j.keys, nil
+}
+
+func (j *jwtJwksKeySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.jwksURL.String(), nil)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "create request failed")
+	}
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "get jwks failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, errors.Errorf(ctx, "get jwks has wrong status: %s", resp.Status)
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, errors.Wrapf(ctx, err, "decode jwks failed")
+	}
+	result := make(map[string]crypto.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey(ctx)
+		if err != nil {
+			glog.V(2).Infof("skip jwk %s: %v", jwk.Kid, err)
+			continue
+		}
+		result[jwk.Kid] = key
+	}
+	if len(result) == 0 {
+		return nil, errors.Errorf(ctx, "jwks contains no usable key")
+	}
+	return result, nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (j jsonWebKey) PublicKey(ctx context.Context) (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeJwkBigInt(j.N)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "decode n failed")
+		}
+		e, err := decodeJwkBigInt(j.E)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "decode e failed")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, errors.Errorf(ctx, "unsupported curve %s", j.Crv)
+		}
+		x, err := decodeJwkBigInt(j.X)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "decode x failed")
+		}
+		y, err := decodeJwkBigInt(j.Y)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "decode y failed")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, errors.Errorf(ctx, "unsupported curve %s", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "decode x failed")
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.Errorf(ctx, "invalid ed25519 key size %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, errors.Errorf(ctx, "unsupported key type %s", j.Kty)
+	}
+}
+
+func decodeJwkBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
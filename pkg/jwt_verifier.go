@@ -0,0 +1,163 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JwtValidMethods are the signing algorithms accepted for bearer tokens.
+var JwtValidMethods = []string{"RS256", "ES256", "EdDSA"}
+
+type JwtIssuer string
+
+func (j JwtIssuer) String() string {
+	return string(j)
+}
+
+type JwtAudience string
+
+func (j JwtAudience) String() string {
+	return string(j)
+}
+
+type JwtUsernameClaim string
+
+func (j JwtUsernameClaim) String() string {
+	return string(j)
+}
+
+type JwtGroupsClaim string
+
+func (j JwtGroupsClaim) String() string {
+	return string(j)
+}
+
+type JwtLeeway time.Duration
+
+func (j JwtLeeway) Duration() time.Duration {
+	return time.Duration(j)
+}
+
+// JwtGroupMapping maps values of the groups claim to group names.
+// Values without mapping are used as group name.
+type JwtGroupMapping map[string]GroupName
+
+func (j JwtGroupMapping) GroupName(value string) GroupName {
+	if groupName, ok := j[value]; ok {
+		return groupName
+	}
+	return GroupName(value)
+}
+
+type JwtUser struct {
+	Name   UserName
+	Groups GroupNames
+}
+
+//counterfeiter:generate -o ../mocks/jwt-verifier.go --fake-name JwtVerifier . JwtVerifier
+type JwtVerifier interface {
+	// VerifyToken checks signature, expiry, issuer and audience of the token
+	// and returns the user it was issued for.
+	VerifyToken(ctx context.Context, token string) (*JwtUser, error)
+}
+
+func NewJwtVerifier(
+	keySet JwtKeySet,
+	issuer JwtIssuer,
+	audience JwtAudience,
+	usernameClaim JwtUsernameClaim,
+	groupsClaim JwtGroupsClaim,
+	groupMapping JwtGroupMapping,
+	leeway JwtLeeway,
+) JwtVerifier {
+	return &jwtVerifier{
+		keySet:        keySet,
+		issuer:        issuer,
+		audience:      audience,
+		usernameClaim: usernameClaim,
+		groupsClaim:   groupsClaim,
+		groupMapping:  groupMapping,
+		leeway:        leeway,
+	}
+}
+
+type jwtVerifier struct {
+	keySet        JwtKeySet
+	issuer        JwtIssuer
+	audience      JwtAudience
+	usernameClaim JwtUsernameClaim
+	groupsClaim   JwtGroupsClaim
+	groupMapping  JwtGroupMapping
+	leeway        JwtLeeway
+}
+
+func (j *jwtVerifier) VerifyToken(ctx context.Context, tokenString string) (*JwtUser, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(JwtValidMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(j.leeway.Duration()),
+	}
+	if len(j.issuer) > 0 {
+		options = append(options, jwt.WithIssuer(j.issuer.String()))
+	}
+	if len(j.audience) > 0 {
+		options = append(options, jwt.WithAudience(j.audience.String()))
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		keys, err := j.keySet.Keys(ctx, kid)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "get keys for kid '%s' failed", kid)
+		}
+		keySet := jwt.VerificationKeySet{}
+		for _, key := range keys {
+			keySet.Keys = append(keySet.Keys, key)
+		}
+		return keySet, nil
+	}, options...)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse token failed")
+	}
+
+	username, ok := claims[j.usernameClaim.String()].(string)
+	if !ok || len(username) == 0 {
+		return nil, errors.Errorf(ctx, "claim %s missing in token", j.usernameClaim)
+	}
+	user := &JwtUser{
+		Name: UserName(username),
+	}
+	for _, value := range claimValues(claims[j.groupsClaim.String()]) {
+		user.Groups = append(user.Groups, j.groupMapping.GroupName(value))
+	}
+	infof(ctx, 2, "token of user %s with groups %v is valid", user.Name, user.Groups)
+	return user, nil
+}
+
+// claimValues accepts a list of strings or a string separated by space or comma.
+func claimValues(claim any) []string {
+	var result []string
+	switch value := claim.(type) {
+	case string:
+		for _, part := range strings.FieldsFunc(value, func(r rune) bool {
+			return r == ' ' || r == ','
+		}) {
+			result = append(result, part)
+		}
+	case []any:
+		for _, element := range value {
+			if s, ok := element.(string); ok && len(s) > 0 {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
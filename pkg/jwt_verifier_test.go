@@ -0,0 +1,345 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("JwtVerifier", func() {
+	var ctx context.Context
+	var err error
+	var rsaKey *rsa.PrivateKey
+	var ecKey *ecdsa.PrivateKey
+	var edPublicKey ed25519.PublicKey
+	var edKey ed25519.PrivateKey
+	var keySet pkg.JwtKeySet
+	var groupMapping pkg.JwtGroupMapping
+	var claims jwt.MapClaims
+	var token string
+	var user *pkg.JwtUser
+	BeforeEach(func() {
+		ctx = context.Background()
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).To(BeNil())
+		ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).To(BeNil())
+		edPublicKey, edKey, err = ed25519.GenerateKey(rand.Reader)
+		Expect(err).To(BeNil())
+		keySet = pkg.NewJwtStaticKeySet(
+			[]crypto.PublicKey{&rsaKey.PublicKey, &ecKey.PublicKey, edPublicKey},
+		)
+		groupMapping = pkg.JwtGroupMapping{}
+		claims = jwt.MapClaims{
+			"sub":    "myuser",
+			"iss":    "https://issuer.example.com",
+			"aud":    "auth-http-proxy",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"groups": []string{"admin", "ops"},
+		}
+	})
+	JustBeforeEach(func() {
+		verifier := pkg.NewJwtVerifier(
+			keySet,
+			"https://issuer.example.com",
+			"auth-http-proxy",
+			"sub",
+			"groups",
+			groupMapping,
+			0,
+		)
+		user, err = verifier.VerifyToken(ctx, token)
+	})
+	Context("RS256", func() {
+		BeforeEach(func() {
+			token, err = jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(rsaKey)
+			Expect(err).To(BeNil())
+		})
+		It("returns no error", func() {
+			Expect(err).To(BeNil())
+		})
+		It("returns user", func() {
+			Expect(user).NotTo(BeNil())
+			Expect(user.Name).To(Equal(pkg.UserName("myuser")))
+			Expect(user.Groups).To(Equal(pkg.GroupNames{"admin", "ops"}))
+		})
+	})
+	Context("ES256", func() {
+		BeforeEach(func() {
+			token, err = jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(ecKey)
+			Expect(err).To(BeNil())
+		})
+		It("returns no error", func() {
+			Expect(err).To(BeNil())
+			Expect(user.Name).To(Equal(pkg.UserName("myuser")))
+		})
+	})
+	Context("EdDSA", func() {
+		BeforeEach(func() {
+			token, err = jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(edKey)
+			Expect(err).To(BeNil())
+		})
+		It("returns no error", func() {
+			Expect(err).To(BeNil())
+			Expect(user.Name).To(Equal(pkg.UserName("myuser")))
+		})
+	})
+	Context("group mapping and string claim", func() {
+		BeforeEach(func() {
+			groupMapping = pkg.JwtGroupMapping{"role-admin": "admin"}
+			claims["groups"] = "role-admin ops"
+			token, err = jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(rsaKey)
+			Expect(err).To(BeNil())
+		})
+		It("maps groups", func() {
+			Expect(err).To(BeNil())
+			Expect(user.Groups).To(Equal(pkg.GroupNames{"admin", "ops"}))
+		})
+	})
+	Context("expired", func() {
+		BeforeEach(func() {
+			claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			token, err = jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(rsaKey)
+			Expect(err).To(BeNil())
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("without expiry", func() {
+		BeforeEach(func() {
+			delete(claims, "exp")
+			token, err = jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(rsaKey)
+			Expect(err).To(BeNil())
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("wrong issuer", func() {
+		BeforeEach(func() {
+			claims["iss"] = "https://evil.example.com"
+			token, err = jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(rsaKey)
+			Expect(err).To(BeNil())
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("wrong audience", func() {
+		BeforeEach(func() {
+			claims["aud"] = "other"
+			token, err = jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(rsaKey)
+			Expect(err).To(BeNil())
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("unknown key", func() {
+		BeforeEach(func() {
+			otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).To(BeNil())
+			token, err = jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(otherKey)
+			Expect(err).To(BeNil())
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("HS256", func() {
+		BeforeEach(func() {
+			token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).
+				SignedString([]byte("secret"))
+			Expect(err).To(BeNil())
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("jwks", func() {
+		var server *httptest.Server
+		BeforeEach(func() {
+			server = httptest.NewServer(
+				http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+					_ = json.NewEncoder(resp).Encode(map[string]any{
+						"keys": []map[string]string{
+							{
+								"kty": "RSA",
+								"kid": "rsa-1",
+								"use": "sig",
+								"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+								"e": base64.RawURLEncoding.EncodeToString(
+									big.NewInt(int64(rsaKey.E)).Bytes(),
+								),
+							},
+							{
+								"kty": "OKP",
+								"kid": "ed-1",
+								"crv": "Ed25519",
+								"x":   base64.RawURLEncoding.EncodeToString(edPublicKey),
+							},
+						},
+					})
+				}),
+			)
+			keySet = pkg.NewJwtJwksKeySet(
+				server.Client(),
+				pkg.JwtJwksURL(server.URL),
+				pkg.JwtJwksRefreshInterval(time.Hour),
+			)
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+		Context("known kid", func() {
+			BeforeEach(func() {
+				t := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+				t.Header["kid"] = "ed-1"
+				token, err = t.SignedString(edKey)
+				Expect(err).To(BeNil())
+			})
+			It("returns no error", func() {
+				Expect(err).To(BeNil())
+				Expect(user.Name).To(Equal(pkg.UserName("myuser")))
+			})
+		})
+		Context("unknown kid", func() {
+			BeforeEach(func() {
+				t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+				t.Header["kid"] = "rsa-2"
+				token, err = t.SignedString(rsaKey)
+				Expect(err).To(BeNil())
+			})
+			It("returns error", func() {
+				Expect(err).NotTo(BeNil())
+			})
+		})
+	})
+})
+
+var _ = Describe("JwtJwksKeySet", func() {
+	var edPublicKey ed25519.PublicKey
+	var requests atomic.Int32
+	var unblockedRequests int32
+	var release chan struct{}
+	var server *httptest.Server
+	var refreshInterval pkg.JwtJwksRefreshInterval
+	var keySet pkg.JwtKeySet
+	BeforeEach(func() {
+		var err error
+		edPublicKey, _, err = ed25519.GenerateKey(rand.Reader)
+		Expect(err).To(BeNil())
+		requests.Store(0)
+		unblockedRequests = 1
+		release = make(chan struct{})
+		server = httptest.NewServer(
+			http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				if requests.Add(1) > unblockedRequests {
+					<-release
+				}
+				_ = json.NewEncoder(resp).Encode(map[string]any{
+					"keys": []map[string]string{
+						{
+							"kty": "OKP",
+							"kid": "ed-1",
+							"crv": "Ed25519",
+							"x":   base64.RawURLEncoding.EncodeToString(edPublicKey),
+						},
+					},
+				})
+			}),
+		)
+		DeferCleanup(server.Close)
+		DeferCleanup(func() {
+			select {
+			case <-release:
+			default:
+				close(release)
+			}
+		})
+		refreshInterval = pkg.JwtJwksRefreshInterval(time.Hour)
+	})
+	JustBeforeEach(func() {
+		keySet = pkg.NewJwtJwksKeySet(server.Client(), pkg.JwtJwksURL(server.URL), refreshInterval)
+	})
+	Context("keys outdated", func() {
+		BeforeEach(func() {
+			refreshInterval = 0
+		})
+		JustBeforeEach(func() {
+			keys, err := keySet.Keys(context.Background(), "ed-1")
+			Expect(err).To(BeNil())
+			Expect(keys).To(HaveLen(1))
+		})
+		It("serves known keys while refresh is in flight", func() {
+			for i := 0; i < 5; i++ {
+				keys, err := keySet.Keys(context.Background(), "ed-1")
+				Expect(err).To(BeNil())
+				Expect(keys).To(HaveLen(1))
+			}
+			Eventually(requests.Load).Should(Equal(int32(2)))
+			Consistently(requests.Load, 50*time.Millisecond).Should(Equal(int32(2)))
+		})
+	})
+	Context("request canceled during first fetch", func() {
+		BeforeEach(func() {
+			unblockedRequests = 0
+		})
+		It("completes the fetch", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := keySet.Keys(ctx, "ed-1")
+			Expect(err).NotTo(BeNil())
+			close(release)
+			Eventually(func() error {
+				_, err := keySet.Keys(context.Background(), "ed-1")
+				return err
+			}).Should(BeNil())
+			Expect(requests.Load()).To(Equal(int32(1)))
+		})
+	})
+})
+
+var _ = Describe("ParseJwtPublicKeys", func() {
+	It("parses pkix public keys", func() {
+		ctx := context.Background()
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).To(BeNil())
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		Expect(err).To(BeNil())
+		keys, err := pkg.ParseJwtPublicKeys(
+			ctx,
+			pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+		)
+		Expect(err).To(BeNil())
+		Expect(keys).To(HaveLen(1))
+	})
+	It("returns error without key", func() {
+		_, err := pkg.ParseJwtPublicKeys(context.Background(), []byte("banana"))
+		Expect(err).NotTo(BeNil())
+	})
+})
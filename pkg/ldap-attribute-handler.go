@@ -0,0 +1,116 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ldapAttributeEntry struct {
+	attributes map[string]string
+	expires    time.Time
+}
+
+// NewLdapAttributeHandler sets the configured headers to the ldap attributes of the
+// authenticated user before forwarding to the subhandler. The headers are removed from
+// requests of users not authenticated with a password, so clients can not set them.
+// Attributes are cached for ttl.
+func NewLdapAttributeHandler(
+	subhandler http.Handler,
+	ldapAuthenticator LdapAuthenticator,
+	headers LdapAttributeHeaders,
+	ttl CacheTTL,
+	maxEntries CacheMaxEntries,
+	now func() time.Time,
+) http.Handler {
+	h := new(ldapAttributeHandler)
+	h.subhandler = subhandler
+	h.ldapAuthenticator = ldapAuthenticator
+	h.headers = headers
+	h.attributes = headers.Attributes()
+	h.ttl = ttl
+	h.maxEntries = maxEntries
+	h.now = now
+	h.entries = map[UserName]ldapAttributeEntry{}
+	return h
+}
+
+type ldapAttributeHandler struct {
+	subhandler        http.Handler
+	ldapAuthenticator LdapAuthenticator
+	headers           LdapAttributeHeaders
+	attributes        []string
+	ttl               CacheTTL
+	maxEntries        CacheMaxEntries
+	now               func() time.Time
+
+	mux     sync.Mutex
+	entries map[UserName]ldapAttributeEntry
+}
+
+func (h *ldapAttributeHandler) ServeHTTP(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) {
+	for _, header := range h.headers {
+		request.Header.Del(header)
+	}
+	identity, ok := IdentityFromContext(request.Context())
+	if ok && identity.GroupsFromDirectory() {
+		attributes, err := h.userAttributes(identity.Name)
+		if err != nil {
+			warningf(request.Context(), "get ldap attributes of user %v failed: %v", identity.Name, err)
+		}
+		for attribute, header := range h.headers {
+			if value := attributes[attribute]; len(value) > 0 {
+				request.Header.Set(header, headerValue(value))
+			}
+		}
+	}
+	h.subhandler.ServeHTTP(responseWriter, request)
+}
+
+func (h *ldapAttributeHandler) userAttributes(username UserName) (map[string]string, error) {
+	now := h.now()
+	h.mux.Lock()
+	entry, found := h.entries[username]
+	h.mux.Unlock()
+	if found && now.Before(entry.expires) {
+		return entry.attributes, nil
+	}
+	attributes, err := h.ldapAuthenticator.GetUserAttributes(username, h.attributes)
+	if err != nil {
+		return nil, err
+	}
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	if len(h.entries) >= h.maxEntries.Int() {
+		for name, entry := range h.entries {
+			if !now.Before(entry.expires) {
+				delete(h.entries, name)
+			}
+		}
+	}
+	if len(h.entries) < h.maxEntries.Int() {
+		h.entries[username] = ldapAttributeEntry{
+			attributes: attributes,
+			expires:    now.Add(h.ttl.Duration()),
+		}
+	}
+	return attributes, nil
+}
+
+// headerValue replaces line breaks that are not allowed in header values.
+func headerValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' {
+			return ' '
+		}
+		return r
+	}, value)
+}
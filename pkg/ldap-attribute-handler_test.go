@@ -0,0 +1,113 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/ldaptest"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("LdapAttributeHandler", func() {
+	var server *ldaptest.Server
+	var forwarded *http.Request
+	var now time.Time
+	var handler http.Handler
+	var request *http.Request
+	BeforeEach(func() {
+		entries, err := ldaptest.ReadLDIFFile(context.Background(), "../sample/ldap.ldif")
+		Expect(err).To(BeNil())
+		server = ldaptest.NewServer(entries)
+		DeferCleanup(server.Close)
+		ldapAuthenticator := pkg.NewLdapAuthenticator(
+			"dc=example,dc=com",
+			[]pkg.LdapServer{{Host: server.Host(), Port: server.Port()}},
+			"",
+			pkg.LdapTlsModePlain,
+			nil,
+			"cn=admin,dc=example,dc=com",
+			"S3CR3T",
+			"ou=users",
+			"(uid=%s)",
+			"uid",
+			"ou=groups",
+			"(member=uid=%s,ou=users,dc=example,dc=com)",
+			"cn",
+			pkg.LdapGroupNestingNone,
+			"member",
+			10,
+			2,
+			pkg.LdapTimeout(time.Second),
+			pkg.LdapIdleTimeout(time.Minute),
+			0,
+		)
+		DeferCleanup(ldapAuthenticator.Close)
+		now = time.Now()
+		forwarded = nil
+		handler = pkg.NewLdapAttributeHandler(
+			http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				forwarded = req
+			}),
+			ldapAuthenticator,
+			pkg.LdapAttributeHeaders{
+				"mail":         "X-Forwarded-Email",
+				"employeeType": "X-Forwarded-Employee-Type",
+			},
+			pkg.CacheTTL(time.Minute),
+			100,
+			func() time.Time { return now },
+		)
+		request = httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("X-Forwarded-Email", "spoofed@example.com")
+	})
+	serve := func(identity *pkg.Identity) {
+		if identity != nil {
+			request = request.WithContext(pkg.WithIdentity(request.Context(), identity))
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		Expect(forwarded).NotTo(BeNil())
+	}
+	It("sets attributes of password user", func() {
+		serve(&pkg.Identity{Name: "alice", Source: pkg.IdentitySourcePassword})
+		Expect(forwarded.Header.Get("X-Forwarded-Email")).To(Equal("alice@example.com"))
+		Expect(forwarded.Header.Get("X-Forwarded-Employee-Type")).To(Equal("staff"))
+	})
+	It("omits missing attributes", func() {
+		serve(&pkg.Identity{Name: "carol", Source: pkg.IdentitySourcePassword})
+		Expect(forwarded.Header.Get("X-Forwarded-Email")).To(Equal("carol@example.com"))
+		Expect(forwarded.Header.Values("X-Forwarded-Employee-Type")).To(BeEmpty())
+	})
+	It("removes headers without identity", func() {
+		serve(nil)
+		Expect(forwarded.Header.Values("X-Forwarded-Email")).To(BeEmpty())
+	})
+	It("removes headers of api key identity", func() {
+		serve(&pkg.Identity{Name: "alice", Source: pkg.IdentitySourceApiKey})
+		Expect(forwarded.Header.Values("X-Forwarded-Email")).To(BeEmpty())
+	})
+	It("removes headers if ldap fails", func() {
+		server.Close()
+		serve(&pkg.Identity{Name: "alice", Source: pkg.IdentitySourcePassword})
+		Expect(forwarded.Header.Values("X-Forwarded-Email")).To(BeEmpty())
+	})
+	It("uses cached attributes", func() {
+		serve(&pkg.Identity{Name: "alice", Source: pkg.IdentitySourcePassword})
+		server.Close()
+		request = httptest.NewRequest(http.MethodGet, "/", nil)
+		serve(&pkg.Identity{Name: "alice", Source: pkg.IdentitySourcePassword})
+		Expect(forwarded.Header.Get("X-Forwarded-Email")).To(Equal("alice@example.com"))
+		now = now.Add(2 * time.Minute)
+		request = httptest.NewRequest(http.MethodGet, "/", nil)
+		serve(&pkg.Identity{Name: "alice", Source: pkg.IdentitySourcePassword})
+		Expect(forwarded.Header.Values("X-Forwarded-Email")).To(BeEmpty())
+	})
+})
@@ -0,0 +1,168 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bborbe/errors"
+)
+
+// LdapAttributeHeaders maps ldap user attributes to the headers forwarded to the target.
+type LdapAttributeHeaders map[string]string
+
+// Attributes returns the sorted attribute names.
+func (l LdapAttributeHeaders) Attributes() []string {
+	result := make([]string, 0, len(l))
+	for attribute := range l {
+		result = append(result, attribute)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ParseLdapAttributeHeaders parses attribute=header pairs.
+func ParseLdapAttributeHeaders(ctx context.Context, values []string) (LdapAttributeHeaders, error) {
+	result := LdapAttributeHeaders{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, errors.Errorf(ctx, "invalid ldap attribute header '%s'", value)
+		}
+		result[parts[0]] = http.CanonicalHeaderKey(parts[1])
+	}
+	return result, nil
+}
+
+// LdapAttributeOperator compares an attribute value with the value of a rule.
+type LdapAttributeOperator string
+
+const (
+	// LdapAttributeEqual requires the value, ignoring case.
+	LdapAttributeEqual LdapAttributeOperator = "="
+	// LdapAttributeNotEqual rejects the value, ignoring case.
+	LdapAttributeNotEqual LdapAttributeOperator = "!="
+	// LdapAttributeBitsSet requires all bits of the mask in a numeric value.
+	LdapAttributeBitsSet LdapAttributeOperator = "&"
+	// LdapAttributeBitsClear requires none of the bits of the mask in a numeric value.
+	LdapAttributeBitsClear LdapAttributeOperator = "!&"
+)
+
+// ldapAttributeOperators are ordered so that no operator is matched by a prefix of another.
+var ldapAttributeOperators = []LdapAttributeOperator{
+	LdapAttributeNotEqual,
+	LdapAttributeBitsClear,
+	LdapAttributeEqual,
+	LdapAttributeBitsSet,
+}
+
+// LdapAttributeRule must be fulfilled by the attributes of a user,
+// like employeeType=staff or userAccountControl!&2 for enabled Active Directory accounts.
+type LdapAttributeRule struct {
+	Attribute string
+	Operator  LdapAttributeOperator
+	Value     string
+	mask      int64
+}
+
+func (l LdapAttributeRule) String() string {
+	return fmt.Sprintf("%s%s%s", l.Attribute, l.Operator, l.Value)
+}
+
+// Matches returns true if the attribute fulfills the rule.
+// Missing or non numeric values never match bitmask rules.
+func (l LdapAttributeRule) Matches(attributes map[string]string) bool {
+	value := attributes[l.Attribute]
+	switch l.Operator {
+	case LdapAttributeEqual:
+		return strings.EqualFold(value, l.Value)
+	case LdapAttributeNotEqual:
+		return !strings.EqualFold(value, l.Value)
+	case LdapAttributeBitsSet, LdapAttributeBitsClear:
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		if l.Operator == LdapAttributeBitsSet {
+			return number&l.mask == l.mask
+		}
+		return number&l.mask == 0
+	default:
+		return false
+	}
+}
+
+// ParseLdapAttributeRule parses rules like attribute=value, attribute!=value,
+// attribute&mask and attribute!&mask.
+func ParseLdapAttributeRule(ctx context.Context, value string) (*LdapAttributeRule, error) {
+	for _, operator := range ldapAttributeOperators {
+		pos := strings.Index(value, string(operator))
+		if pos <= 0 {
+			continue
+		}
+		rule := &LdapAttributeRule{
+			Attribute: strings.TrimSpace(value[:pos]),
+			Operator:  operator,
+			Value:     strings.TrimSpace(value[pos+len(operator):]),
+		}
+		if strings.ContainsAny(rule.Attribute, "=!&") {
+			continue
+		}
+		if operator == LdapAttributeBitsSet || operator == LdapAttributeBitsClear {
+			mask, err := strconv.ParseInt(rule.Value, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(ctx, err, "parse mask of ldap attribute rule '%s' failed", value)
+			}
+			rule.mask = mask
+		}
+		return rule, nil
+	}
+	return nil, errors.Errorf(ctx, "invalid ldap attribute rule '%s'", value)
+}
+
+// LdapAttributeRules must all be fulfilled.
+type LdapAttributeRules []LdapAttributeRule
+
+// ParseLdapAttributeRules parses every rule with ParseLdapAttributeRule.
+func ParseLdapAttributeRules(ctx context.Context, values []string) (LdapAttributeRules, error) {
+	var result LdapAttributeRules
+	for _, value := range values {
+		rule, err := ParseLdapAttributeRule(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *rule)
+	}
+	return result, nil
+}
+
+// Attributes returns the attribute names used by the rules.
+func (l LdapAttributeRules) Attributes() []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, rule := range l {
+		if !seen[rule.Attribute] {
+			seen[rule.Attribute] = true
+			result = append(result, rule.Attribute)
+		}
+	}
+	return result
+}
+
+// Failed returns all rules not fulfilled by the attributes.
+func (l LdapAttributeRules) Failed(attributes map[string]string) LdapAttributeRules {
+	var result LdapAttributeRules
+	for _, rule := range l {
+		if !rule.Matches(attributes) {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("LdapAttributeRule", func() {
+	attribute := func(name string, value string) map[string]string {
+		return map[string]string{name: value}
+	}
+	DescribeTable("matches",
+		func(rule string, attributes map[string]string, expected bool) {
+			result, err := pkg.ParseLdapAttributeRule(context.Background(), rule)
+			Expect(err).To(BeNil())
+			Expect(result.Matches(attributes)).To(Equal(expected))
+		},
+		Entry("equal", "employeeType=staff", attribute("employeeType", "staff"), true),
+		Entry("equal ignores case", "employeeType=staff", attribute("employeeType", "Staff"), true),
+		Entry("equal other", "employeeType=staff", attribute("employeeType", "contractor"), false),
+		Entry("equal missing", "employeeType=staff", map[string]string{}, false),
+		Entry("not equal", "employeeType!=contractor", attribute("employeeType", "staff"), true),
+		Entry("not equal to", "employeeType!=contractor", attribute("employeeType", "contractor"), false),
+		Entry("bits clear", "userAccountControl!&2", attribute("userAccountControl", "512"), true),
+		Entry("disabled", "userAccountControl!&2", attribute("userAccountControl", "514"), false),
+		Entry("bits clear missing", "userAccountControl!&2", map[string]string{}, false),
+		Entry("bits set", "userAccountControl&512", attribute("userAccountControl", "514"), true),
+		Entry("bits set not", "userAccountControl&512", attribute("userAccountControl", "2"), false),
+		Entry("value with operator", "description=a&b", attribute("description", "a&b"), true),
+	)
+	DescribeTable("rejects",
+		func(rule string) {
+			_, err := pkg.ParseLdapAttributeRule(context.Background(), rule)
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("no operator", "employeeType"),
+		Entry("no attribute", "=staff"),
+		Entry("mask not numeric", "userAccountControl!&disabled"),
+	)
+	It("returns failed rules", func() {
+		rules, err := pkg.ParseLdapAttributeRules(
+			context.Background(),
+			[]string{"employeeType=staff", "userAccountControl!&2"},
+		)
+		Expect(err).To(BeNil())
+		Expect(rules.Attributes()).To(Equal([]string{"employeeType", "userAccountControl"}))
+		failed := rules.Failed(map[string]string{"employeeType": "staff", "userAccountControl": "514"})
+		Expect(failed).To(HaveLen(1))
+		Expect(failed[0].String()).To(Equal("userAccountControl!&2"))
+	})
+})
+
+var _ = Describe("ParseLdapAttributeHeaders", func() {
+	It("parses attribute header pairs", func() {
+		headers, err := pkg.ParseLdapAttributeHeaders(
+			context.Background(),
+			[]string{"mail=x-forwarded-email", "displayName=X-Forwarded-Name"},
+		)
+		Expect(err).To(BeNil())
+		Expect(headers).To(Equal(pkg.LdapAttributeHeaders{
+			"mail":        "X-Forwarded-Email",
+			"displayName": "X-Forwarded-Name",
+		}))
+		Expect(headers.Attributes()).To(Equal([]string{"displayName", "mail"}))
+	})
+	It("rejects pair without header", func() {
+		_, err := pkg.ParseLdapAttributeHeaders(context.Background(), []string{"mail"})
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -5,12 +5,18 @@
 package pkg
 
 import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/golang/glog"
 	"github.com/jtblin/go-ldap-client"
+	ldapv2 "gopkg.in/ldap.v2"
 )
 
-const ldapConnectionSize = 5
-
 type LdapBaseDn string
 
 func (l LdapBaseDn) String() string {
@@ -41,6 +47,7 @@ func (l LdapUseSSL) Bool() bool {
 	return bool(l)
 }
 
+// LdapSkipTls disables StartTLS if no LdapTlsMode is given.
 type LdapSkipTls bool
 
 func (l LdapSkipTls) Bool() bool {
@@ -95,18 +102,48 @@ func (l LdapGroupField) String() string {
 	return string(l)
 }
 
+// LdapMaxConnections limits the open connections per ldap server.
+type LdapMaxConnections int
+
+func (l LdapMaxConnections) Int() int {
+	return int(l)
+}
+
+// LdapTimeout limits connecting, each ldap request and waiting for a free connection.
+type LdapTimeout time.Duration
+
+func (l LdapTimeout) Duration() time.Duration {
+	return time.Duration(l)
+}
+
+// LdapIdleTimeout is how long an unused connection is kept open.
+type LdapIdleTimeout time.Duration
+
+func (l LdapIdleTimeout) Duration() time.Duration {
+	return time.Duration(l)
+}
+
+// LdapHealthInterval is the interval of the rootDSE search on each ldap server.
+// Zero disables it.
+type LdapHealthInterval time.Duration
+
+func (l LdapHealthInterval) Duration() time.Duration {
+	return time.Duration(l)
+}
+
 type LdapAuthenticator interface {
 	Authenticate(UserName, Password) (bool, map[string]string, error)
-	GetGroupsOfUser(UserName) ([]string, error)
+	GetGroupsOfUser(UserName) (LdapGroups, error)
+	GetUserAttributes(UserName, []string) (map[string]string, error)
+	// Close stops the health checks and closes all connections.
+	Close()
 }
 
 type ldapAuth struct {
 	ldapBaseDn       LdapBaseDn
-	ldapHost         LdapHost
 	ldapServerName   LdapServerName
-	ldapPort         LdapPort
-	ldapUseSSL       LdapUseSSL
-	ldapSkipTls      LdapSkipTls
+	ldapTlsMode      LdapTlsMode
+	ldapTlsConfig    *tls.Config
 	ldapBindDN       LdapBindDN
 	ldapBindPassword LdapBindPassword
 	ldapUserFilter   LdapUserFilter
@@ -115,16 +152,28 @@ type ldapAuth struct {
 	ldapGroupDn      LdapGroupDn
 	ldapUserField    LdapUserField
 	ldapGroupField   LdapGroupField
-	ldapClients      chan *ldap.LDAPClient
+	ldapGroupNesting LdapGroupNesting
+	ldapGroupMember  LdapGroupMemberAttribute
+	ldapGroupDepth   LdapGroupMaxDepth
+	ldapTimeout      LdapTimeout
+	ldapIdleTimeout  LdapIdleTimeout
+	ldapClients      []*ldapServerClients
+	now              func() time.Time
+	done             chan struct{}
+	closeOnce        sync.Once
 }
 
+// NewLdapAuthenticator uses the given ldap servers in order and fails over
+// to the next server on connection errors. Each server has a pool of at most
+// ldapMaxConnections connections which is checked every ldapHealthInterval.
+// Nested groups are resolved according to ldapGroupNesting. Connections to ldap://
+// servers are secured according to ldapTlsMode, ldapTlsConfig is used for ldaps and StartTLS.
 func NewLdapAuthenticator(
 	ldapBaseDn LdapBaseDn,
-	ldapHost LdapHost,
+	ldapServers []LdapServer,
 	ldapServerName LdapServerName,
-	ldapPort LdapPort,
-	ldapUseSSL LdapUseSSL,
-	ldapSkipTls LdapSkipTls,
+	ldapTlsMode LdapTlsMode,
+	ldapTlsConfig *tls.Config,
 	ldapBindDN LdapBindDN,
 	ldapBindPassword LdapBindPassword,
 	ldapUserDn LdapUserDn,
@@ -133,14 +182,19 @@ func NewLdapAuthenticator(
 	ldapGroupDn LdapGroupDn,
 	ldapGroupFilter LdapGroupFilter,
 	ldapGroupField LdapGroupField,
+	ldapGroupNesting LdapGroupNesting,
+	ldapGroupMemberAttribute LdapGroupMemberAttribute,
+	ldapGroupMaxDepth LdapGroupMaxDepth,
+	ldapMaxConnections LdapMaxConnections,
+	ldapTimeout LdapTimeout,
+	ldapIdleTimeout LdapIdleTimeout,
+	ldapHealthInterval LdapHealthInterval,
 ) LdapAuthenticator {
 	a := new(ldapAuth)
 	a.ldapBaseDn = ldapBaseDn
-	a.ldapHost = ldapHost
 	a.ldapServerName = ldapServerName
-	a.ldapPort = ldapPort
-	a.ldapUseSSL = ldapUseSSL
-	a.ldapSkipTls = ldapSkipTls
+	a.ldapTlsMode = ldapTlsMode
+	a.ldapTlsConfig = ldapTlsConfig
 	a.ldapBindDN = ldapBindDN
 	a.ldapBindPassword = ldapBindPassword
 	a.ldapUserFilter = ldapUserFilter
@@ -149,41 +203,183 @@ func NewLdapAuthenticator(
 	a.ldapGroupField = ldapGroupField
 	a.ldapUserDn = ldapUserDn
 	a.ldapGroupDn = ldapGroupDn
-	a.ldapClients = make(chan *ldap.LDAPClient, ldapConnectionSize)
+	a.ldapGroupNesting = ldapGroupNesting
+	a.ldapGroupMember = ldapGroupMemberAttribute
+	a.ldapGroupDepth = ldapGroupMaxDepth
+	a.ldapTimeout = ldapTimeout
+	a.ldapIdleTimeout = ldapIdleTimeout
+	for _, server := range ldapServers {
+		a.ldapClients = append(
+			a.ldapClients,
+			newLdapServerClients(server, ldapMaxConnections.Int()),
+		)
+	}
+	a.now = time.Now
+	a.done = make(chan struct{})
+	if ldapHealthInterval > 0 && len(a.ldapClients) > 0 {
+		go a.checkHealth(ldapHealthInterval.Duration())
+	}
 	return a
 }
 
-func (a *ldapAuth) getClient() *ldap.LDAPClient {
-	select {
-	case client := <-a.ldapClients:
-		glog.V(2).Infof("got client from pool")
-		return client
-	default:
-		glog.V(2).Infof("created new client")
-		return a.createClient()
-	}
+// Close stops the health checks and closes all connections.
+// Connections in use are closed once the request is finished.
+func (a *ldapAuth) Close() {
+	a.closeOnce.Do(func() {
+		glog.V(2).Infof("close all ldap connections")
+		close(a.done)
+		for _, serverClients := range a.ldapClients {
+			serverClients.close()
+		}
+	})
 }
 
-func (a *ldapAuth) releaseClient(client *ldap.LDAPClient) {
-	glog.V(2).Infof("release client")
-	select {
-	case a.ldapClients <- client:
-		glog.V(2).Infof("returned client to pool")
-	default:
-		a.closeClient(client)
+// checkHealth closes idle connections and searches the rootDSE of each server
+// until the authenticator is closed.
+func (a *ldapAuth) checkHealth(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			for _, serverClients := range a.ldapClients {
+				if a.ldapIdleTimeout > 0 {
+					serverClients.evictIdle(a.ldapIdleTimeout.Duration(), a.now())
+				}
+				if err := a.executeOn(serverClients, searchRootDSE); err != nil {
+					glog.Warningf("health check of ldap server %s failed: %v", serverClients.server, err)
+					continue
+				}
+				glog.V(3).Infof("health check of ldap server %s success", serverClients.server)
+			}
+		}
 	}
 }
 
-func (a *ldapAuth) closeClient(client *ldap.LDAPClient) {
-	glog.V(2).Infof("closed client")
-	client.Close()
+func searchRootDSE(client *ldap.LDAPClient) error {
+	_, err := client.Conn.Search(ldapv2.NewSearchRequest(
+		"",
+		ldapv2.ScopeBaseObject, ldapv2.NeverDerefAliases, 1, 0, false,
+		"(objectClass=*)",
+		[]string{"supportedLDAPVersion"},
+		nil,
+	))
+	return err
 }
 
-func (a *ldapAuth) Close() {
-	glog.V(2).Infof("close all ldap connections")
-	for client := range a.ldapClients {
+// execute runs the operation on the preferred ldap server. On connection errors it
+// continues with the next server.
+func (a *ldapAuth) execute(name string, operation func(client *ldap.LDAPClient) error) error {
+	err := errLdapNoServer
+	for _, serverClients := range orderLdapServerClients(a.ldapClients, a.now()) {
+		err = a.executeOn(serverClients, operation)
+		if !IsLdapConnectionError(err) {
+			return err
+		}
+		glog.Warningf("%s on ldap server %s failed: %v", name, serverClients.server, err)
+	}
+	return err
+}
+
+// executeOn runs the operation with a pooled connection of the server. If a pooled
+// connection fails with a connection error it is retried once with a new connection.
+func (a *ldapAuth) executeOn(
+	serverClients *ldapServerClients,
+	operation func(client *ldap.LDAPClient) error,
+) error {
+	client, err := serverClients.acquire(
+		a.ldapTimeout.Duration(),
+		a.ldapIdleTimeout.Duration(),
+		a.now(),
+	)
+	if err != nil {
+		return err
+	}
+	pooled := client != nil
+	if !pooled {
+		client = a.createClient(serverClients.server)
+	}
+	err = a.operate(serverClients.server, client, operation)
+	if pooled && IsLdapConnectionError(err) {
+		glog.V(1).Infof("pooled connection to %s failed, retry with new connection: %v",
+			serverClients.server, err)
 		client.Close()
+		client = a.createClient(serverClients.server)
+		err = a.operate(serverClients.server, client, operation)
+	}
+	if IsLdapConnectionError(err) {
+		serverClients.release(client, false, a.now())
+		serverClients.markFailure(a.now())
+		return err
+	}
+	serverClients.markSuccess()
+	// on errors the connection might be bound as another user
+	serverClients.release(client, err == nil, a.now())
+	return err
+}
+
+func (a *ldapAuth) operate(
+	server LdapServer,
+	client *ldap.LDAPClient,
+	operation func(client *ldap.LDAPClient) error,
+) error {
+	if client.Conn == nil {
+		conn, err := a.dial(server)
+		if err != nil {
+			return err
+		}
+		// the ldap client uses the given connection instead of dialing without timeout
+		client.Conn = conn
+	}
+	return operation(client)
+}
+
+// dial connects with ldaps or upgrades ldap:// connections with StartTLS,
+// depending on the tls mode. Connecting and the tls handshake are limited by the timeout.
+func (a *ldapAuth) dial(server LdapServer) (*ldapv2.Conn, error) {
+	timeout := a.ldapTimeout.Duration()
+	dialer := &net.Dialer{Timeout: timeout}
+	netConn, err := dialer.Dial("tcp", server.String())
+	if err != nil {
+		return nil, ldapv2.NewError(ldapv2.ErrorNetwork, err)
 	}
+	if timeout > 0 {
+		_ = netConn.SetDeadline(time.Now().Add(timeout))
+	}
+	tlsConfig := a.tlsConfig(server)
+	if server.UseSSL {
+		tlsConn := tls.Client(netConn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			netConn.Close()
+			return nil, ldapv2.NewError(ldapv2.ErrorNetwork, err)
+		}
+		netConn = tlsConn
+	}
+	conn := ldapv2.NewConn(netConn, server.UseSSL)
+	conn.Start()
+	conn.SetTimeout(timeout)
+	if !server.UseSSL && a.ldapTlsMode == LdapTlsModeStartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	// requests are limited by the ldap timeout from here on
+	_ = netConn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func (a *ldapAuth) tlsConfig(server LdapServer) *tls.Config {
+	var tlsConfig *tls.Config
+	if a.ldapTlsConfig != nil {
+		tlsConfig = a.ldapTlsConfig.Clone()
+	} else {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	tlsConfig.ServerName = a.serverName(server)
+	return tlsConfig
 }
 
 func (a *ldapAuth) Authenticate(
@@ -191,39 +387,109 @@ func (a *ldapAuth) Authenticate(
 	password Password,
 ) (ok bool, data map[string]string, err error) {
 	glog.V(2).Infof("Authenticate user %s", username)
-	ldapClient := a.getClient()
-	ok, data, err = ldapClient.Authenticate(username.String(), password.String())
-	if err != nil {
-		glog.V(1).Infof("Authenticate failed, retry with new connection: %v", err)
-		a.closeClient(ldapClient)
-		ldapClient = a.createClient()
-		ok, data, err = ldapClient.Authenticate(username.String(), password.String())
-	}
-	a.releaseClient(ldapClient)
+	err = a.execute("Authenticate", func(client *ldap.LDAPClient) error {
+		var err error
+		ok, data, err = client.Authenticate(username.String(), password.String())
+		return err
+	})
 	return
 }
 
-func (a *ldapAuth) GetGroupsOfUser(username UserName) (groups []string, err error) {
+func (a *ldapAuth) GetGroupsOfUser(username UserName) (groups LdapGroups, err error) {
 	glog.V(2).Infof("GetGroupsOfUser for user %s", username)
-	ldapClient := a.getClient()
-	groups, err = ldapClient.GetGroupsOfUser(username.String())
+	err = a.execute("GetGroupsOfUser", func(client *ldap.LDAPClient) error {
+		var err error
+		groups, err = a.getGroupsOfUser(client, username)
+		return err
+	})
+	return
+}
+
+func (a *ldapAuth) GetUserAttributes(
+	username UserName,
+	attributes []string,
+) (data map[string]string, err error) {
+	glog.V(2).Infof("GetUserAttributes %v for user %s", attributes, username)
+	err = a.execute("GetUserAttributes", func(client *ldap.LDAPClient) error {
+		var err error
+		data, err = a.getUserAttributes(client, username, attributes)
+		return err
+	})
+	return
+}
+
+func (a *ldapAuth) getUserAttributes(
+	ldapClient *ldap.LDAPClient,
+	username UserName,
+	attributes []string,
+) (map[string]string, error) {
+	entry, err := a.searchUser(ldapClient, username, attributes)
 	if err != nil {
-		glog.V(1).Infof("GetGroupsOfUser failed, retry with new connection: %v", err)
-		a.closeClient(ldapClient)
-		ldapClient = a.createClient()
-		groups, err = ldapClient.GetGroupsOfUser(username.String())
+		return nil, err
 	}
-	a.releaseClient(ldapClient)
-	return
+	data := map[string]string{}
+	for _, attribute := range attributes {
+		data[attribute] = entry.GetAttributeValue(attribute)
+	}
+	return data, nil
 }
 
-func (a *ldapAuth) createClient() *ldap.LDAPClient {
-	serverName := a.ldapServerName.String()
-	if len(serverName) == 0 {
-		serverName = a.ldapHost.String()
+// bind binds as the configured read only user.
+func (a *ldapAuth) bind(ldapClient *ldap.LDAPClient) error {
+	if len(a.ldapBindDN) == 0 || len(a.ldapBindPassword) == 0 {
+		return nil
+	}
+	return ldapClient.Conn.Bind(a.ldapBindDN.String(), a.ldapBindPassword.String())
+}
+
+func (a *ldapAuth) searchUser(
+	ldapClient *ldap.LDAPClient,
+	username UserName,
+	attributes []string,
+) (*ldapv2.Entry, error) {
+	if err := ldapClient.Connect(); err != nil {
+		return nil, err
+	}
+	if err := a.bind(ldapClient); err != nil {
+		return nil, err
+	}
+	searchRequest := ldapv2.NewSearchRequest(
+		joinDn(a.ldapUserDn.String(), a.ldapBaseDn.String()),
+		ldapv2.ScopeWholeSubtree, ldapv2.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(a.ldapUserFilter.String(), ldapv2.EscapeFilter(username.String())),
+		attributes,
+		nil,
+	)
+	sr, err := ldapClient.Conn.Search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+	if len(sr.Entries) != 1 {
+		return nil, fmt.Errorf("expected one entry for user %s but got %d", username, len(sr.Entries))
 	}
-	glog.V(2).
-		Infof("create new ldap client for %s:%d with servername %s", a.ldapHost, a.ldapPort, serverName)
+	return sr.Entries[0], nil
+}
+
+func joinDn(dns ...string) string {
+	var result []string
+	for _, dn := range dns {
+		if len(dn) > 0 {
+			result = append(result, dn)
+		}
+	}
+	return strings.Join(result, ",")
+}
+
+func (a *ldapAuth) serverName(server LdapServer) string {
+	if len(a.ldapServerName) > 0 {
+		return a.ldapServerName.String()
+	}
+	return server.Host
+}
+
+func (a *ldapAuth) createClient(server LdapServer) *ldap.LDAPClient {
+	serverName := a.serverName(server)
+	glog.V(2).Infof("create new ldap client for %s with servername %s", server, serverName)
 	client := &ldap.LDAPClient{
 		Base:         a.ldapBaseDn.String(),
 		BindDN:       a.ldapBindDN.String(),
@@ -231,11 +497,11 @@ func (a *ldapAuth) createClient() *ldap.LDAPClient {
 		GroupDN:      a.ldapGroupDn.String(),
 		GroupField:   a.ldapGroupField.String(),
 		GroupFilter:  a.ldapGroupFilter.String(),
-		Host:         a.ldapHost.String(),
-		Port:         a.ldapPort.Int(),
+		Host:         server.Host,
+		Port:         server.Port,
 		ServerName:   serverName,
-		SkipTLS:      a.ldapSkipTls.Bool(),
-		UseSSL:       a.ldapUseSSL.Bool(),
+		SkipTLS:      a.ldapTlsMode != LdapTlsModeStartTLS,
+		UseSSL:       server.UseSSL,
 		UserDN:       a.ldapUserDn.String(),
 		UserField:    a.ldapUserField.String(),
 		UserFilter:   a.ldapUserFilter.String(),
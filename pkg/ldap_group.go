@@ -0,0 +1,209 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/jtblin/go-ldap-client"
+	ldapv2 "gopkg.in/ldap.v2"
+)
+
+// ldapMatchingRuleInChain is the Active Directory rule LDAP_MATCHING_RULE_IN_CHAIN
+// that matches members of nested groups.
+const ldapMatchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+// LdapGroupNesting defines how groups of groups are resolved.
+type LdapGroupNesting string
+
+const (
+	// LdapGroupNestingNone returns only groups the user is a direct member of.
+	LdapGroupNestingNone LdapGroupNesting = "none"
+	// LdapGroupNestingRecursive searches groups containing the found groups up to max depth.
+	LdapGroupNestingRecursive LdapGroupNesting = "recursive"
+	// LdapGroupNestingInChain lets Active Directory resolve nested groups.
+	LdapGroupNestingInChain LdapGroupNesting = "in-chain"
+)
+
+func (l LdapGroupNesting) String() string {
+	return string(l)
+}
+
+// LdapGroupMemberAttribute is the attribute of a group containing the dn of its members.
+type LdapGroupMemberAttribute string
+
+func (l LdapGroupMemberAttribute) String() string {
+	return string(l)
+}
+
+// LdapGroupMaxDepth limits the levels of recursive group resolution.
+type LdapGroupMaxDepth int
+
+func (l LdapGroupMaxDepth) Int() int {
+	return int(l)
+}
+
+// LdapGroup is a group found in ldap.
+type LdapGroup struct {
+	DN   string
+	Name string
+}
+
+type LdapGroups []LdapGroup
+
+// Names returns the group field values of all groups.
+func (l LdapGroups) Names() GroupNames {
+	result := GroupNames{}
+	for _, group := range l {
+		result = append(result, GroupName(group.Name))
+	}
+	return result
+}
+
+// Contains returns true if a group has the given name or dn.
+func (l LdapGroups) Contains(groupName GroupName) bool {
+	for _, group := range l {
+		if group.Name == groupName.String() {
+			return true
+		}
+		if IsGroupDN(groupName) && equalDN(group.DN, groupName.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing returns all required groups that are not part of the list.
+func (l LdapGroups) Missing(requiredGroups []GroupName) GroupNames {
+	var result GroupNames
+	for _, requiredGroup := range requiredGroups {
+		if !l.Contains(requiredGroup) {
+			result = append(result, requiredGroup)
+		}
+	}
+	return result
+}
+
+// IsGroupDN returns true if the group is given as dn like cn=admins,ou=groups,dc=example,dc=com.
+func IsGroupDN(groupName GroupName) bool {
+	return strings.Contains(groupName.String(), "=")
+}
+
+func equalDN(a, b string) bool {
+	dnA, err := ldapv2.ParseDN(a)
+	if err != nil {
+		return strings.EqualFold(a, b)
+	}
+	dnB, err := ldapv2.ParseDN(b)
+	if err != nil {
+		return strings.EqualFold(a, b)
+	}
+	return dnA.Equal(dnB)
+}
+
+func (a *ldapAuth) getGroupsOfUser(
+	ldapClient *ldap.LDAPClient,
+	username UserName,
+) (LdapGroups, error) {
+	if err := ldapClient.Connect(); err != nil {
+		return nil, err
+	}
+	if err := a.bind(ldapClient); err != nil {
+		return nil, err
+	}
+	if a.ldapGroupNesting == LdapGroupNestingInChain {
+		entry, err := a.searchUser(ldapClient, username, []string{"dn"})
+		if err != nil {
+			return nil, err
+		}
+		return a.searchGroups(ldapClient, fmt.Sprintf(
+			"(%s:%s:=%s)",
+			a.ldapGroupMember,
+			ldapMatchingRuleInChain,
+			ldapv2.EscapeFilter(entry.DN),
+		))
+	}
+	groups, err := a.searchGroups(
+		ldapClient,
+		fmt.Sprintf(a.ldapGroupFilter.String(), ldapv2.EscapeFilter(username.String())),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if a.ldapGroupNesting != LdapGroupNestingRecursive {
+		return groups, nil
+	}
+	return a.expandGroups(ldapClient, groups)
+}
+
+// expandGroups adds all groups that contain one of the groups, up to max depth.
+// Each group is searched only once, so cycles end the resolution.
+func (a *ldapAuth) expandGroups(
+	ldapClient *ldap.LDAPClient,
+	groups LdapGroups,
+) (LdapGroups, error) {
+	seen := map[string]bool{}
+	for _, group := range groups {
+		seen[strings.ToLower(group.DN)] = true
+	}
+	result := append(LdapGroups{}, groups...)
+	current := groups
+	for depth := 0; len(current) > 0; depth++ {
+		if depth >= a.ldapGroupDepth.Int() {
+			glog.Warningf("stop resolving nested groups at depth %d", depth)
+			break
+		}
+		var filters []string
+		for _, group := range current {
+			filters = append(
+				filters,
+				fmt.Sprintf("(%s=%s)", a.ldapGroupMember, ldapv2.EscapeFilter(group.DN)),
+			)
+		}
+		parents, err := a.searchGroups(ldapClient, "(|"+strings.Join(filters, "")+")")
+		if err != nil {
+			return nil, err
+		}
+		current = nil
+		for _, parent := range parents {
+			key := strings.ToLower(parent.DN)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			current = append(current, parent)
+		}
+		result = append(result, current...)
+	}
+	return result, nil
+}
+
+func (a *ldapAuth) searchGroups(ldapClient *ldap.LDAPClient, filter string) (LdapGroups, error) {
+	fieldName := a.ldapGroupField.String()
+	if len(fieldName) == 0 {
+		fieldName = "cn"
+	}
+	searchRequest := ldapv2.NewSearchRequest(
+		joinDn(a.ldapGroupDn.String(), a.ldapBaseDn.String()),
+		ldapv2.ScopeWholeSubtree, ldapv2.NeverDerefAliases, 0, 0, false,
+		filter,
+		[]string{fieldName},
+		nil,
+	)
+	sr, err := ldapClient.Conn.Search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+	groups := LdapGroups{}
+	for _, entry := range sr.Entries {
+		groups = append(groups, LdapGroup{
+			DN:   entry.DN,
+			Name: entry.GetAttributeValue(fieldName),
+		})
+	}
+	return groups, nil
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("LdapGroups", func() {
+	var groups pkg.LdapGroups
+	BeforeEach(func() {
+		groups = pkg.LdapGroups{
+			{DN: "cn=developers,ou=groups,dc=example,dc=com", Name: "developers"},
+			{DN: "cn=staff,ou=groups,dc=example,dc=com", Name: "staff"},
+		}
+	})
+	It("returns names", func() {
+		Expect(groups.Names()).To(Equal(pkg.GroupNames{"developers", "staff"}))
+	})
+	DescribeTable("Contains",
+		func(groupName string, expected bool) {
+			Expect(groups.Contains(pkg.GroupName(groupName))).To(Equal(expected))
+		},
+		Entry("name", "staff", true),
+		Entry("dn", "cn=staff,ou=groups,dc=example,dc=com", true),
+		Entry("dn with other spacing", "CN=staff, OU=groups, DC=example, DC=com", true),
+		Entry("unknown name", "admins", false),
+		Entry("unknown dn", "cn=staff,ou=other,dc=example,dc=com", false),
+	)
+	It("returns missing groups", func() {
+		Expect(groups.Missing([]pkg.GroupName{
+			"staff",
+			"cn=developers,ou=groups,dc=example,dc=com",
+			"admins",
+		})).To(Equal(pkg.GroupNames{"admins"}))
+	})
+})
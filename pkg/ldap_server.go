@@ -0,0 +1,290 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+	"github.com/jtblin/go-ldap-client"
+	ldapv2 "gopkg.in/ldap.v2"
+)
+
+// ldapServerRetryInterval is how long a failed server is only used if all other servers fail.
+const ldapServerRetryInterval = 30 * time.Second
+
+// LdapURL is the url of a ldap server like ldap://ldap.example.com:389 or ldaps://ldap.example.com.
+type LdapURL string
+
+func (l LdapURL) String() string {
+	return string(l)
+}
+
+// LdapServer is the address of a ldap server.
+type LdapServer struct {
+	Host   string
+	Port   int
+	UseSSL bool
+}
+
+func (l LdapServer) String() string {
+	return net.JoinHostPort(l.Host, strconv.Itoa(l.Port))
+}
+
+// ParseLdapURL parses ldap:// and ldaps:// urls. The port defaults to 389 or 636.
+func ParseLdapURL(ctx context.Context, ldapURL LdapURL) (LdapServer, error) {
+	u, err := url.Parse(ldapURL.String())
+	if err != nil {
+		return LdapServer{}, errors.Wrapf(ctx, err, "parse ldap url %s failed", ldapURL)
+	}
+	var server LdapServer
+	switch u.Scheme {
+	case "ldap":
+		server.Port = 389
+	case "ldaps":
+		server.UseSSL = true
+		server.Port = 636
+	default:
+		return LdapServer{}, errors.Errorf(ctx, "ldap url %s has unknown scheme", ldapURL)
+	}
+	server.Host = u.Hostname()
+	if len(server.Host) == 0 {
+		return LdapServer{}, errors.Errorf(ctx, "ldap url %s has no host", ldapURL)
+	}
+	if port := u.Port(); len(port) > 0 {
+		if server.Port, err = strconv.Atoi(port); err != nil {
+			return LdapServer{}, errors.Wrapf(ctx, err, "parse port of ldap url %s failed", ldapURL)
+		}
+	}
+	return server, nil
+}
+
+// ParseLdapURLs parses all urls.
+func ParseLdapURLs(ctx context.Context, ldapURLs []LdapURL) ([]LdapServer, error) {
+	result := make([]LdapServer, 0, len(ldapURLs))
+	for _, ldapURL := range ldapURLs {
+		server, err := ParseLdapURL(ctx, ldapURL)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, server)
+	}
+	return result, nil
+}
+
+// IsLdapConnectionError returns true if the error is caused by the connection
+// to the ldap server or the server being busy or unavailable and not by the request,
+// e.g. invalid credentials.
+func IsLdapConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if ldapv2.IsErrorWithCode(err, ldapv2.ErrorNetwork) ||
+		ldapv2.IsErrorWithCode(err, ldapv2.LDAPResultBusy) ||
+		ldapv2.IsErrorWithCode(err, ldapv2.LDAPResultUnavailable) {
+		return true
+	}
+	// ldap.v2 reports request timeouts and connections closed by the server as plain error
+	if strings.Contains(err.Error(), "ldap: connection timed out") ||
+		strings.Contains(err.Error(), "unable to read LDAP response packet") {
+		return true
+	}
+	var netErr net.Error
+	return stderrors.As(err, &netErr)
+}
+
+// ldapPooledClient is an idle connection in the pool.
+type ldapPooledClient struct {
+	client   *ldap.LDAPClient
+	lastUsed time.Time
+}
+
+// ldapServerClients is the client pool and health of one ldap server.
+type ldapServerClients struct {
+	server LdapServer
+	// slots limits the number of open connections
+	slots chan struct{}
+
+	mux         sync.Mutex
+	idle        []ldapPooledClient
+	closed      bool
+	failures    int
+	lastFailure time.Time
+}
+
+func newLdapServerClients(server LdapServer, maxConnections int) *ldapServerClients {
+	if maxConnections <= 0 {
+		maxConnections = 1
+	}
+	return &ldapServerClients{
+		server: server,
+		slots:  make(chan struct{}, maxConnections),
+	}
+}
+
+// acquire reserves a connection and returns the most recently used idle client.
+// The client is nil if no idle client is available. It fails if all connections
+// are in use for longer than timeout.
+func (l *ldapServerClients) acquire(
+	timeout time.Duration,
+	idleTimeout time.Duration,
+	now time.Time,
+) (*ldap.LDAPClient, error) {
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case l.slots <- struct{}{}:
+		case <-timer.C:
+			return nil, errLdapPoolExhausted
+		}
+	} else {
+		l.slots <- struct{}{}
+	}
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	defer l.updateMetrics()
+	if l.closed {
+		<-l.slots
+		return nil, errLdapPoolClosed
+	}
+	for len(l.idle) > 0 {
+		pooled := l.idle[len(l.idle)-1]
+		l.idle = l.idle[:len(l.idle)-1]
+		if idleTimeout > 0 && now.Sub(pooled.lastUsed) > idleTimeout {
+			glog.V(2).Infof("close idle client of %s", l.server)
+			pooled.client.Close()
+			continue
+		}
+		glog.V(2).Infof("got client for %s from pool", l.server)
+		return pooled.client, nil
+	}
+	return nil, nil
+}
+
+// release returns the client to the pool if reuse is true, otherwise it is closed.
+func (l *ldapServerClients) release(client *ldap.LDAPClient, reuse bool, now time.Time) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	defer l.updateMetrics()
+	defer func() { <-l.slots }()
+	if !reuse || l.closed || client.Conn == nil {
+		glog.V(2).Infof("close client of %s", l.server)
+		client.Close()
+		return
+	}
+	glog.V(2).Infof("return client of %s to pool", l.server)
+	l.idle = append(l.idle, ldapPooledClient{client: client, lastUsed: now})
+}
+
+// evictIdle closes all clients not used within idleTimeout.
+func (l *ldapServerClients) evictIdle(idleTimeout time.Duration, now time.Time) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	idle := l.idle[:0]
+	for _, pooled := range l.idle {
+		if now.Sub(pooled.lastUsed) > idleTimeout {
+			glog.V(2).Infof("close idle client of %s", l.server)
+			pooled.client.Close()
+			continue
+		}
+		idle = append(idle, pooled)
+	}
+	l.idle = idle
+	l.updateMetrics()
+}
+
+// updateMetrics must be called with the lock held.
+func (l *ldapServerClients) updateMetrics() {
+	server := l.server.String()
+	ldapConnections.WithLabelValues(server, "in_use").Set(float64(len(l.slots)))
+	ldapConnections.WithLabelValues(server, "idle").Set(float64(len(l.idle)))
+}
+
+// close closes all idle clients. Clients in use are closed on release.
+func (l *ldapServerClients) close() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.closed = true
+	for _, pooled := range l.idle {
+		pooled.client.Close()
+	}
+	l.idle = nil
+	l.updateMetrics()
+}
+
+// healthy returns true if the server did not fail within the retry interval.
+func (l *ldapServerClients) healthy(now time.Time) bool {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.failures == 0 || now.Sub(l.lastFailure) > ldapServerRetryInterval
+}
+
+func (l *ldapServerClients) markFailure(now time.Time) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.failures++
+	l.lastFailure = now
+	ldapErrorsTotal.WithLabelValues(l.server.String()).Inc()
+}
+
+func (l *ldapServerClients) markSuccess() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.failures > 0 {
+		glog.V(1).Infof("ldap server %s is available again", l.server)
+	}
+	l.failures = 0
+}
+
+func (l *ldapServerClients) lastFailureTime() time.Time {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.lastFailure
+}
+
+// orderLdapServerClients returns healthy servers in configured order followed by
+// failed servers, the one failed longest ago first.
+func orderLdapServerClients(
+	serverClients []*ldapServerClients,
+	now time.Time,
+) []*ldapServerClients {
+	var healthy, failed []*ldapServerClients
+	for _, s := range serverClients {
+		if s.healthy(now) {
+			healthy = append(healthy, s)
+		} else {
+			failed = append(failed, s)
+		}
+	}
+	sort.SliceStable(failed, func(i, j int) bool {
+		return failed[i].lastFailureTime().Before(failed[j].lastFailureTime())
+	})
+	return append(healthy, failed...)
+}
+
+// errLdapNoServer is returned if no ldap server is configured.
+var errLdapNoServer = stderrors.New("no ldap server configured")
+
+// errLdapPoolExhausted and errLdapPoolClosed are connection errors, so the next server is tried.
+var (
+	errLdapPoolExhausted = ldapv2.NewError(
+		ldapv2.ErrorNetwork,
+		stderrors.New("all ldap connections are in use"),
+	)
+	errLdapPoolClosed = ldapv2.NewError(
+		ldapv2.ErrorNetwork,
+		stderrors.New("ldap connection pool is closed"),
+	)
+)
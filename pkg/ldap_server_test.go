@@ -0,0 +1,195 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	ldapv2 "gopkg.in/ldap.v2"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("ParseLdapURL", func() {
+	var ctx context.Context
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+	DescribeTable("valid",
+		func(ldapURL string, expected pkg.LdapServer) {
+			server, err := pkg.ParseLdapURL(ctx, pkg.LdapURL(ldapURL))
+			Expect(err).To(BeNil())
+			Expect(server).To(Equal(expected))
+		},
+		Entry("ldap", "ldap://dc1.example.com", pkg.LdapServer{Host: "dc1.example.com", Port: 389}),
+		Entry("ldaps", "ldaps://dc1.example.com", pkg.LdapServer{
+			Host:   "dc1.example.com",
+			Port:   636,
+			UseSSL: true,
+		}),
+		Entry("port", "ldap://10.0.0.1:1389", pkg.LdapServer{Host: "10.0.0.1", Port: 1389}),
+	)
+	DescribeTable("invalid",
+		func(ldapURL string) {
+			_, err := pkg.ParseLdapURL(ctx, pkg.LdapURL(ldapURL))
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("scheme", "http://dc1.example.com"),
+		Entry("host", "ldap://"),
+		Entry("port", "ldap://dc1.example.com:banana"),
+	)
+})
+
+var _ = Describe("IsLdapConnectionError", func() {
+	It("returns true for network errors", func() {
+		err := ldapv2.NewError(ldapv2.ErrorNetwork, errors.New("connection refused"))
+		Expect(pkg.IsLdapConnectionError(err)).To(BeTrue())
+	})
+	It("returns true for busy server", func() {
+		err := ldapv2.NewError(ldapv2.LDAPResultBusy, errors.New("busy"))
+		Expect(pkg.IsLdapConnectionError(err)).To(BeTrue())
+	})
+	It("returns true for unavailable server", func() {
+		err := ldapv2.NewError(ldapv2.LDAPResultUnavailable, errors.New("unavailable"))
+		Expect(pkg.IsLdapConnectionError(err)).To(BeTrue())
+	})
+	It("returns true for connection closed by server", func() {
+		err := errors.New("unable to read LDAP response packet: unexpected EOF")
+		Expect(pkg.IsLdapConnectionError(err)).To(BeTrue())
+	})
+	It("returns false for invalid credentials", func() {
+		err := ldapv2.NewError(ldapv2.LDAPResultInvalidCredentials, errors.New("invalid"))
+		Expect(pkg.IsLdapConnectionError(err)).To(BeFalse())
+	})
+	It("returns false for nil", func() {
+		Expect(pkg.IsLdapConnectionError(nil)).To(BeFalse())
+	})
+})
+
+var _ = Describe("LdapAuthenticator", func() {
+	var ldapServers []pkg.LdapServer
+	var ldapTimeout pkg.LdapTimeout
+	var ldapMaxConnections pkg.LdapMaxConnections
+	var ldapAuthenticator pkg.LdapAuthenticator
+	BeforeEach(func() {
+		ldapServers = nil
+		ldapTimeout = pkg.LdapTimeout(time.Second)
+		ldapMaxConnections = 10
+	})
+	JustBeforeEach(func() {
+		ldapAuthenticator = pkg.NewLdapAuthenticator(
+			"dc=example,dc=com",
+			ldapServers,
+			"",
+			pkg.LdapTlsModePlain,
+			nil,
+			"cn=admin,dc=example,dc=com",
+			"secret",
+			"",
+			"(uid=%s)",
+			"uid",
+			"",
+			"(member=%s)",
+			"cn",
+			pkg.LdapGroupNestingNone,
+			"member",
+			10,
+			ldapMaxConnections,
+			ldapTimeout,
+			pkg.LdapIdleTimeout(time.Minute),
+			pkg.LdapHealthInterval(time.Hour),
+		)
+	})
+	AfterEach(func() {
+		ldapAuthenticator.Close()
+	})
+	Context("all servers down", func() {
+		BeforeEach(func() {
+			for i := 0; i < 2; i++ {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).To(BeNil())
+				port := listener.Addr().(*net.TCPAddr).Port
+				Expect(listener.Close()).To(BeNil())
+				ldapServers = append(ldapServers, pkg.LdapServer{Host: "127.0.0.1", Port: port})
+			}
+		})
+		It("returns connection error", func() {
+			_, _, err := ldapAuthenticator.Authenticate("alice", "secret")
+			Expect(pkg.IsLdapConnectionError(err)).To(BeTrue())
+
+			ldapAuth := &pkg.LdapAuth{LdapAuthenticator: ldapAuthenticator}
+			_, err = ldapAuth.Verify(context.Background(), "alice", "secret")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("server does not respond", func() {
+		var listener net.Listener
+		var conns chan net.Conn
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).To(BeNil())
+			conns = make(chan net.Conn, 10)
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+					conns <- conn
+				}
+			}()
+			port := listener.Addr().(*net.TCPAddr).Port
+			ldapServers = []pkg.LdapServer{{Host: "127.0.0.1", Port: port}}
+			ldapTimeout = pkg.LdapTimeout(100 * time.Millisecond)
+		})
+		AfterEach(func() {
+			listener.Close()
+			close(conns)
+			for conn := range conns {
+				conn.Close()
+			}
+		})
+		It("times out", func() {
+			start := time.Now()
+			_, _, err := ldapAuthenticator.Authenticate("alice", "secret")
+			Expect(pkg.IsLdapConnectionError(err)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+		Context("single connection", func() {
+			BeforeEach(func() {
+				ldapMaxConnections = 1
+				ldapTimeout = pkg.LdapTimeout(time.Second)
+			})
+			It("waits for the open connection", func() {
+				var wg sync.WaitGroup
+				for i := 0; i < 3; i++ {
+					wg.Add(1)
+					go func() {
+						defer GinkgoRecover()
+						defer wg.Done()
+						_, _, err := ldapAuthenticator.Authenticate("alice", "secret")
+						Expect(pkg.IsLdapConnectionError(err)).To(BeTrue())
+					}()
+				}
+				Eventually(func() int { return len(conns) }).Should(Equal(1))
+				Consistently(func() int { return len(conns) }, 300*time.Millisecond).Should(Equal(1))
+				wg.Wait()
+			})
+		})
+	})
+	It("closes twice", func() {
+		ldapAuthenticator.Close()
+		ldapAuthenticator.Close()
+		_, err := ldapAuthenticator.GetGroupsOfUser("alice")
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,115 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/bborbe/errors"
+)
+
+// LdapTlsMode defines how connections to ldap:// servers are secured.
+// ldaps:// servers always use tls.
+type LdapTlsMode string
+
+const (
+	// LdapTlsModeStartTLS upgrades the connection with the StartTLS extended operation.
+	LdapTlsModeStartTLS LdapTlsMode = "starttls"
+	// LdapTlsModeLdaps connects with tls.
+	LdapTlsModeLdaps LdapTlsMode = "ldaps"
+	// LdapTlsModePlain sends credentials unencrypted.
+	LdapTlsModePlain LdapTlsMode = "plain"
+)
+
+func (l LdapTlsMode) String() string {
+	return string(l)
+}
+
+// LdapTlsCaFile contains the PEM certificates trusted for ldap servers.
+// The system pool is used if empty.
+type LdapTlsCaFile string
+
+func (l LdapTlsCaFile) String() string {
+	return string(l)
+}
+
+// LdapTlsCertFile is the client certificate presented to ldap servers.
+type LdapTlsCertFile string
+
+func (l LdapTlsCertFile) String() string {
+	return string(l)
+}
+
+// LdapTlsKeyFile is the key of LdapTlsCertFile.
+type LdapTlsKeyFile string
+
+func (l LdapTlsKeyFile) String() string {
+	return string(l)
+}
+
+// LdapTlsMinVersion is the minimum tls version like 1.2.
+type LdapTlsMinVersion string
+
+func (l LdapTlsMinVersion) String() string {
+	return string(l)
+}
+
+// LdapTlsInsecureSkipVerify disables verification of the ldap server certificate.
+type LdapTlsInsecureSkipVerify bool
+
+func (l LdapTlsInsecureSkipVerify) Bool() bool {
+	return bool(l)
+}
+
+// ParseTlsVersion parses 1.0, 1.1, 1.2 and 1.3.
+func ParseTlsVersion(ctx context.Context, version string) (uint16, error) {
+	switch version {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, errors.Errorf(ctx, "unknown tls version %s", version)
+	}
+}
+
+// NewLdapTlsConfig returns the tls config for ldaps and StartTLS.
+// The server name is set per server.
+func NewLdapTlsConfig(
+	ctx context.Context,
+	caFile LdapTlsCaFile,
+	certFile LdapTlsCertFile,
+	keyFile LdapTlsKeyFile,
+	minVersion LdapTlsMinVersion,
+	insecureSkipVerify LdapTlsInsecureSkipVerify,
+) (*tls.Config, error) {
+	version, err := ParseTlsVersion(ctx, minVersion.String())
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse min tls version failed")
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:         version,
+		InsecureSkipVerify: insecureSkipVerify.Bool(), // #nosec G402 -- explicitly configured
+	}
+	if len(caFile) > 0 {
+		tlsConfig.RootCAs, err = LoadCertPool(ctx, caFile.String())
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "load ldap ca failed")
+		}
+	}
+	if len(certFile) > 0 || len(keyFile) > 0 {
+		certificate, err := tls.LoadX509KeyPair(certFile.String(), keyFile.String())
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "load ldap client certificate %s failed", certFile)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/ldaptest"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("ParseTlsVersion", func() {
+	DescribeTable("parses",
+		func(version string, expected uint16) {
+			result, err := pkg.ParseTlsVersion(context.Background(), version)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(expected))
+		},
+		Entry("1.2", "1.2", uint16(tls.VersionTLS12)),
+		Entry("1.3", "1.3", uint16(tls.VersionTLS13)),
+	)
+	It("returns error for unknown version", func() {
+		_, err := pkg.ParseTlsVersion(context.Background(), "1.4")
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("Ldap TLS", func() {
+	var ctx context.Context
+	var certificates *ldaptest.Certificates
+	var server *ldaptest.Server
+	var caFile pkg.LdapTlsCaFile
+	var certFile pkg.LdapTlsCertFile
+	var keyFile pkg.LdapTlsKeyFile
+	var minVersion pkg.LdapTlsMinVersion
+	var insecureSkipVerify pkg.LdapTlsInsecureSkipVerify
+	var tlsMode pkg.LdapTlsMode
+	var useSSL bool
+	var requireClientCert bool
+	var serverMaxVersion uint16
+	var entries []ldaptest.Entry
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		certificates, err = ldaptest.NewCertificates()
+		Expect(err).To(BeNil())
+		dir := GinkgoT().TempDir()
+		writeFile := func(name string, content []byte) string {
+			path := filepath.Join(dir, name)
+			Expect(os.WriteFile(path, content, 0600)).To(Succeed())
+			return path
+		}
+		caFile = pkg.LdapTlsCaFile(writeFile("ca.pem", certificates.CAPEM))
+		certFile = ""
+		keyFile = ""
+		minVersion = "1.2"
+		insecureSkipVerify = false
+		tlsMode = pkg.LdapTlsModeStartTLS
+		useSSL = false
+		requireClientCert = false
+		serverMaxVersion = 0
+		entries = []ldaptest.Entry{
+			{
+				DN:         "cn=admin,dc=example,dc=com",
+				Attributes: map[string][]string{"cn": {"admin"}, "userPassword": {"admin"}},
+			},
+			{
+				DN: "uid=alice,ou=users,dc=example,dc=com",
+				Attributes: map[string][]string{
+					"uid":          {"alice"},
+					"userPassword": {"secret"},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		server = ldaptest.NewUnstartedServer(entries)
+		server.TLSConfig = certificates.ServerTLSConfig(requireClientCert)
+		server.TLSConfig.MaxVersion = serverMaxVersion
+		if useSSL {
+			server.StartTLS()
+		} else {
+			server.Start()
+		}
+		DeferCleanup(server.Close)
+	})
+
+	authenticate := func() (bool, error) {
+		tlsConfig, err := pkg.NewLdapTlsConfig(
+			ctx,
+			caFile,
+			certFile,
+			keyFile,
+			minVersion,
+			insecureSkipVerify,
+		)
+		Expect(err).To(BeNil())
+		ldapAuthenticator := pkg.NewLdapAuthenticator(
+			"dc=example,dc=com",
+			[]pkg.LdapServer{{Host: server.Host(), Port: server.Port(), UseSSL: useSSL}},
+			"",
+			tlsMode,
+			tlsConfig,
+			"cn=admin,dc=example,dc=com",
+			"admin",
+			"ou=users",
+			"(uid=%s)",
+			"uid",
+			"",
+			"(member=%s)",
+			"cn",
+			pkg.LdapGroupNestingNone,
+			"member",
+			10,
+			1,
+			pkg.LdapTimeout(time.Second),
+			pkg.LdapIdleTimeout(time.Minute),
+			0,
+		)
+		defer ldapAuthenticator.Close()
+		ok, _, err := ldapAuthenticator.Authenticate("alice", "secret")
+		return ok, err
+	}
+
+	Context("starttls", func() {
+		It("authenticates with ca", func() {
+			Expect(authenticate()).To(BeTrue())
+		})
+		It("fails without ca", func() {
+			caFile = ""
+			_, err := authenticate()
+			Expect(err).NotTo(BeNil())
+		})
+		It("authenticates without ca if verification is skipped", func() {
+			caFile = ""
+			insecureSkipVerify = true
+			Expect(authenticate()).To(BeTrue())
+		})
+		Context("server supports tls 1.2 only", func() {
+			BeforeEach(func() {
+				serverMaxVersion = tls.VersionTLS12
+			})
+			It("fails with min version 1.3", func() {
+				minVersion = "1.3"
+				_, err := authenticate()
+				Expect(err).NotTo(BeNil())
+			})
+			It("authenticates with min version 1.2", func() {
+				Expect(authenticate()).To(BeTrue())
+			})
+		})
+	})
+	Context("ldaps", func() {
+		BeforeEach(func() {
+			tlsMode = pkg.LdapTlsModeLdaps
+			useSSL = true
+		})
+		It("authenticates with ca", func() {
+			Expect(authenticate()).To(BeTrue())
+		})
+		It("fails without ca", func() {
+			caFile = ""
+			_, err := authenticate()
+			Expect(err).NotTo(BeNil())
+		})
+		Context("client certificate required", func() {
+			BeforeEach(func() {
+				requireClientCert = true
+			})
+			It("fails without client certificate", func() {
+				_, err := authenticate()
+				Expect(err).NotTo(BeNil())
+			})
+			It("authenticates with client certificate", func() {
+				dir := GinkgoT().TempDir()
+				certPath := filepath.Join(dir, "client.pem")
+				keyPath := filepath.Join(dir, "client-key.pem")
+				Expect(os.WriteFile(certPath, certificates.ClientCertPEM, 0600)).To(Succeed())
+				Expect(os.WriteFile(keyPath, certificates.ClientKeyPEM, 0600)).To(Succeed())
+				certFile = pkg.LdapTlsCertFile(certPath)
+				keyFile = pkg.LdapTlsKeyFile(keyPath)
+				Expect(authenticate()).To(BeTrue())
+			})
+		})
+	})
+	Context("plain", func() {
+		BeforeEach(func() {
+			tlsMode = pkg.LdapTlsModePlain
+		})
+		It("authenticates", func() {
+			Expect(authenticate()).To(BeTrue())
+		})
+		It("rejects wrong password", func() {
+			entries[1].Attributes["userPassword"] = []string{"other"}
+			ok, err := authenticate()
+			Expect(err).NotTo(BeNil())
+			Expect(ok).To(BeFalse())
+		})
+	})
+	It("fails with missing ca file", func() {
+		_, err := pkg.NewLdapTlsConfig(ctx, "/missing/ca.pem", "", "", "1.2", false)
+		Expect(err).NotTo(BeNil())
+	})
+})
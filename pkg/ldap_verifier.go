@@ -5,7 +5,7 @@
 package pkg
 
 import (
-	"github.com/golang/glog"
+	"context"
 )
 
 type GroupName string
@@ -14,44 +14,130 @@ func (g GroupName) String() string {
 	return string(g)
 }
 
+type GroupNames []GroupName
+
+// Contains returns true if the given group is part of the list.
+func (g GroupNames) Contains(groupName GroupName) bool {
+	for _, group := range g {
+		if group == groupName {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing returns all required groups that are not part of the list.
+func (g GroupNames) Missing(requiredGroups []GroupName) GroupNames {
+	var result GroupNames
+	for _, requiredGroup := range requiredGroups {
+		if !g.Contains(requiredGroup) {
+			result = append(result, requiredGroup)
+		}
+	}
+	return result
+}
+
 type LdapAuth struct {
 	LdapAuthenticator LdapAuthenticator
 	RequiredGroups    []GroupName
+	// RequiredAttributes are checked after the groups if not empty.
+	RequiredAttributes LdapAttributeRules
+}
+
+func (l *LdapAuth) Verify(
+	ctx context.Context,
+	username UserName,
+	password Password,
+) (bool, error) {
+	ok, _, err := l.VerifyWithReason(ctx, username, password)
+	return ok, err
 }
 
-func (l *LdapAuth) Verify(username UserName, password Password) (bool, error) {
-	glog.V(2).Infof("verify user %v is valid and has groups %v", username, l.RequiredGroups)
+// VerifyWithReason returns the reason credentials are rejected.
+func (l *LdapAuth) VerifyWithReason(
+	ctx context.Context,
+	username UserName,
+	password Password,
+) (bool, AuditReason, error) {
+	infof(ctx, 2, "verify user %v is valid and has groups %v", username, l.RequiredGroups)
 
 	ok, _, err := l.LdapAuthenticator.Authenticate(username, password)
+	if IsLdapConnectionError(err) {
+		warningf(ctx, "authenticate user %v failed: %v", username, err)
+		return false, AuditReasonBackendError, err
+	}
 	if err != nil {
-		glog.V(0).Infof("authenticate user %v failed %v", username, err)
-		return false, nil
+		infof(ctx, 0, "authenticate user %v failed %v", username, err)
+		return false, AuditReasonInvalidCredentials, nil
 	}
 	if !ok {
-		glog.V(1).Infof("authenticate user %v invalid", username)
-		return false, nil
+		infof(ctx, 1, "authenticate user %v invalid", username)
+		return false, AuditReasonInvalidCredentials, nil
 	}
 
-	glog.V(2).Infof("username and password of user %v is valid", username)
-	glog.V(2).Infof("get groups of user %v", username)
-	groupNames, err := l.LdapAuthenticator.GetGroupsOfUser(username)
+	infof(ctx, 2, "username and password of user %v is valid", username)
+	return l.verifyUser(ctx, username)
+}
+
+// verifyUser checks the required groups and attributes of the user.
+func (l *LdapAuth) verifyUser(ctx context.Context, username UserName) (bool, AuditReason, error) {
+	infof(ctx, 2, "get groups of user %v", username)
+	groups, err := l.LdapAuthenticator.GetGroupsOfUser(username)
 	if err != nil {
-		glog.Warningf("get groups for user %v failed: %v", username, err)
-		return false, err
-	}
-	glog.V(2).Infof("user %v has groups: %v", username, groupNames)
-	for _, requiredGroup := range l.RequiredGroups {
-		found := false
-		for _, groupName := range groupNames {
-			if groupName == requiredGroup.String() {
-				found = true
-			}
-		}
-		if !found {
-			glog.V(1).Infof("user %v has not required group %v", username, requiredGroup)
-			return false, nil
-		}
+		warningf(ctx, "get groups for user %v failed: %v", username, err)
+		return false, AuditReasonBackendError, err
+	}
+	infof(ctx, 2, "user %v has groups: %v", username, groups.Names())
+	if missing := groups.Missing(l.RequiredGroups); len(missing) > 0 {
+		infof(ctx, 1, "user %v has not required groups %v", username, missing)
+		return false, AuditReasonMissingGroup, nil
+	}
+	infof(ctx, 2, "user %v is valid and has all required groups", username)
+	if len(l.RequiredAttributes) == 0 {
+		return true, "", nil
+	}
+	attributes, err := l.LdapAuthenticator.GetUserAttributes(
+		username,
+		l.RequiredAttributes.Attributes(),
+	)
+	if err != nil {
+		warningf(ctx, "get attributes for user %v failed: %v", username, err)
+		return false, AuditReasonBackendError, err
+	}
+	if failed := l.RequiredAttributes.Failed(attributes); len(failed) > 0 {
+		infof(ctx, 1, "user %v does not fulfill required attributes %v", username, failed)
+		return false, AuditReasonMissingAttribute, nil
 	}
-	glog.V(2).Infof("user %v is valid and has all required groups", username)
-	return true, nil
+	infof(ctx, 2, "user %v has all required attributes", username)
+	return true, "", nil
+}
+
+// NewLdapUserVerifier checks only the required groups and attributes of the ldapAuth and
+// ignores the password. It is used for users authenticated with digest auth.
+func NewLdapUserVerifier(ldapAuth *LdapAuth) ReasonVerifier {
+	return &ldapUserVerifier{
+		ldapAuth: ldapAuth,
+	}
+}
+
+type ldapUserVerifier struct {
+	ldapAuth *LdapAuth
+}
+
+func (l *ldapUserVerifier) Verify(
+	ctx context.Context,
+	username UserName,
+	password Password,
+) (bool, error) {
+	ok, _, err := l.VerifyWithReason(ctx, username, password)
+	return ok, err
+}
+
+func (l *ldapUserVerifier) VerifyWithReason(
+	ctx context.Context,
+	username UserName,
+	password Password,
+) (bool, AuditReason, error) {
+	infof(ctx, 2, "verify user %v has groups %v", username, l.ldapAuth.RequiredGroups)
+	return l.ldapAuth.verifyUser(ctx, username)
 }
@@ -0,0 +1,218 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/ldaptest"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("LdapAuth", func() {
+	var server *ldaptest.Server
+	var groupNesting pkg.LdapGroupNesting
+	var groupMaxDepth pkg.LdapGroupMaxDepth
+	var requiredGroups []pkg.GroupName
+	var requiredAttributes pkg.LdapAttributeRules
+	var ldapAuthenticator pkg.LdapAuthenticator
+	var ldapAuth *pkg.LdapAuth
+	var ctx context.Context
+	BeforeEach(func() {
+		ctx = context.Background()
+		entries, err := ldaptest.ReadLDIFFile(context.Background(), "../sample/ldap.ldif")
+		Expect(err).To(BeNil())
+		server = ldaptest.NewServer(entries)
+		DeferCleanup(server.Close)
+		groupNesting = pkg.LdapGroupNestingNone
+		groupMaxDepth = 10
+		requiredGroups = []pkg.GroupName{"staff"}
+		requiredAttributes = nil
+	})
+	JustBeforeEach(func() {
+		ldapAuthenticator = pkg.NewLdapAuthenticator(
+			"dc=example,dc=com",
+			[]pkg.LdapServer{{Host: server.Host(), Port: server.Port()}},
+			"",
+			pkg.LdapTlsModePlain,
+			nil,
+			"cn=admin,dc=example,dc=com",
+			"S3CR3T",
+			"ou=users",
+			"(uid=%s)",
+			"uid",
+			"ou=groups",
+			"(member=uid=%s,ou=users,dc=example,dc=com)",
+			"cn",
+			groupNesting,
+			"member",
+			groupMaxDepth,
+			2,
+			pkg.LdapTimeout(time.Second),
+			pkg.LdapIdleTimeout(time.Minute),
+			0,
+		)
+		DeferCleanup(ldapAuthenticator.Close)
+		ldapAuth = &pkg.LdapAuth{
+			LdapAuthenticator:  ldapAuthenticator,
+			RequiredGroups:     requiredGroups,
+			RequiredAttributes: requiredAttributes,
+		}
+	})
+	It("accepts user with required group", func() {
+		Expect(ldapAuth.Verify(ctx, "alice", "alice-secret")).To(BeTrue())
+	})
+	It("rejects wrong password", func() {
+		Expect(ldapAuth.Verify(ctx, "alice", "wrong")).To(BeFalse())
+	})
+	It("rejects unknown user", func() {
+		Expect(ldapAuth.Verify(ctx, "mallory", "alice-secret")).To(BeFalse())
+	})
+	It("rejects user without groups", func() {
+		Expect(ldapAuth.Verify(ctx, "carol", "carol-secret")).To(BeFalse())
+	})
+	It("returns reason of rejection", func() {
+		_, reason, err := ldapAuth.VerifyWithReason(ctx, "alice", "wrong")
+		Expect(err).To(BeNil())
+		Expect(reason).To(Equal(pkg.AuditReasonInvalidCredentials))
+		_, reason, err = ldapAuth.VerifyWithReason(ctx, "carol", "carol-secret")
+		Expect(err).To(BeNil())
+		Expect(reason).To(Equal(pkg.AuditReasonMissingGroup))
+	})
+	It("rejects user only in nested group", func() {
+		Expect(ldapAuth.Verify(ctx, "bob", "bob-secret")).To(BeFalse())
+	})
+	It("returns user attributes", func() {
+		attributes, err := ldapAuthenticator.GetUserAttributes("alice", []string{"mail"})
+		Expect(err).To(BeNil())
+		Expect(attributes).To(Equal(map[string]string{"mail": "alice@example.com"}))
+	})
+	Context("required group as dn", func() {
+		BeforeEach(func() {
+			requiredGroups = []pkg.GroupName{"cn=staff,ou=groups,dc=example,dc=com"}
+		})
+		It("accepts user with required group", func() {
+			Expect(ldapAuth.Verify(ctx, "alice", "alice-secret")).To(BeTrue())
+		})
+	})
+	Context("recursive", func() {
+		BeforeEach(func() {
+			groupNesting = pkg.LdapGroupNestingRecursive
+		})
+		It("accepts user in nested group", func() {
+			Expect(ldapAuth.Verify(ctx, "bob", "bob-secret")).To(BeTrue())
+		})
+		It("resolves groups with cycles", func() {
+			groups, err := ldapAuthenticator.GetGroupsOfUser("bob")
+			Expect(err).To(BeNil())
+			Expect(groups.Names()).To(ConsistOf(pkg.GroupName("developers"), pkg.GroupName("staff")))
+		})
+		Context("max depth 0", func() {
+			BeforeEach(func() {
+				groupMaxDepth = 0
+			})
+			It("rejects user in nested group", func() {
+				Expect(ldapAuth.Verify(ctx, "bob", "bob-secret")).To(BeFalse())
+			})
+		})
+	})
+	Context("in-chain", func() {
+		BeforeEach(func() {
+			groupNesting = pkg.LdapGroupNestingInChain
+		})
+		It("accepts user in nested group", func() {
+			Expect(ldapAuth.Verify(ctx, "bob", "bob-secret")).To(BeTrue())
+		})
+		It("rejects user without groups", func() {
+			Expect(ldapAuth.Verify(ctx, "carol", "carol-secret")).To(BeFalse())
+		})
+	})
+	Context("required attributes", func() {
+		BeforeEach(func() {
+			var err error
+			requiredAttributes, err = pkg.ParseLdapAttributeRules(
+				context.Background(),
+				[]string{"employeeType=staff"},
+			)
+			Expect(err).To(BeNil())
+			groupNesting = pkg.LdapGroupNestingRecursive
+		})
+		It("accepts user with attribute", func() {
+			Expect(ldapAuth.Verify(ctx, "alice", "alice-secret")).To(BeTrue())
+		})
+		It("rejects user with other attribute value", func() {
+			Expect(ldapAuth.Verify(ctx, "bob", "bob-secret")).To(BeFalse())
+		})
+		It("returns missing attribute reason", func() {
+			_, reason, err := ldapAuth.VerifyWithReason(ctx, "bob", "bob-secret")
+			Expect(err).To(BeNil())
+			Expect(reason).To(Equal(pkg.AuditReasonMissingAttribute))
+		})
+	})
+	Context("user verifier", func() {
+		var userVerifier pkg.ReasonVerifier
+		JustBeforeEach(func() {
+			userVerifier = pkg.NewLdapUserVerifier(ldapAuth)
+		})
+		It("accepts user with required group without password", func() {
+			Expect(userVerifier.Verify(ctx, "alice", "")).To(BeTrue())
+		})
+		It("rejects user without groups", func() {
+			_, reason, err := userVerifier.VerifyWithReason(ctx, "carol", "")
+			Expect(err).To(BeNil())
+			Expect(reason).To(Equal(pkg.AuditReasonMissingGroup))
+		})
+	})
+	Context("server stopped", func() {
+		JustBeforeEach(func() {
+			server.Close()
+		})
+		It("returns error", func() {
+			_, err := ldapAuth.Verify(ctx, "alice", "alice-secret")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("cached", func() {
+		var now time.Time
+		var staleTTL pkg.CacheStaleTTL
+		var cacheAuth pkg.CacheAuth
+		BeforeEach(func() {
+			now = time.Now()
+			staleTTL = pkg.CacheStaleTTL(time.Hour)
+		})
+		JustBeforeEach(func() {
+			cacheAuth = pkg.NewCacheAuth(
+				ldapAuth,
+				pkg.CacheTTL(time.Minute),
+				pkg.CacheNegativeTTL(time.Minute),
+				staleTTL,
+				10,
+				pkg.AuditLogDisabled,
+				func() time.Time { return now },
+			)
+		})
+		It("accepts stale credentials while server is stopped", func() {
+			Expect(cacheAuth.Verify(ctx, "alice", "alice-secret")).To(BeTrue())
+			server.Close()
+			now = now.Add(2 * time.Minute)
+			Expect(cacheAuth.Verify(ctx, "alice", "alice-secret")).To(BeTrue())
+		})
+		Context("without stale ttl", func() {
+			BeforeEach(func() {
+				staleTTL = 0
+			})
+			It("does not cache server stopped as rejection", func() {
+				server.Close()
+				_, err := cacheAuth.Verify(ctx, "alice", "alice-secret")
+				Expect(err).NotTo(BeNil())
+				Expect(cacheAuth.Entries()).To(BeEmpty())
+			})
+		})
+	})
+})
@@ -0,0 +1,58 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"io"
+	"os"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LogFile is the path of a log file or LogFileStdout.
+type LogFile string
+
+func (l LogFile) String() string {
+	return string(l)
+}
+
+// LogFileStdout writes the log to stdout.
+const LogFileStdout LogFile = "stdout"
+
+// LogMaxSize is the size in megabytes after which a log file is rotated.
+type LogMaxSize int
+
+func (l LogMaxSize) Int() int {
+	return int(l)
+}
+
+// LogMaxBackups is the number of rotated log files kept.
+type LogMaxBackups int
+
+func (l LogMaxBackups) Int() int {
+	return int(l)
+}
+
+// NewLogWriter returns a writer to stdout or to the file, which is rotated
+// after maxSize megabytes keeping maxBackups compressed old files.
+func NewLogWriter(logFile LogFile, maxSize LogMaxSize, maxBackups LogMaxBackups) io.WriteCloser {
+	if logFile == LogFileStdout {
+		return nopWriteCloser{Writer: os.Stdout}
+	}
+	return &lumberjack.Logger{
+		Filename:   logFile.String(),
+		MaxSize:    maxSize.Int(),
+		MaxBackups: maxBackups.Int(),
+		Compress:   true,
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
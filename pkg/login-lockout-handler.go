@@ -0,0 +1,51 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+)
+
+// newLoginLockoutHandler lists locked users and ips on GET and clears the
+// lockout of the given user and/or ip on DELETE (?user=name&ip=1.2.3.4).
+func newLoginLockoutHandler(loginThrottle LoginThrottle) http.Handler {
+	h := new(loginLockoutHandler)
+	h.loginThrottle = loginThrottle
+	return h
+}
+
+type loginLockoutHandler struct {
+	loginThrottle LoginThrottle
+}
+
+func (h *loginLockoutHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		responseWriter.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(responseWriter).Encode(h.loginThrottle.Lockouts()); err != nil {
+			warningf(request.Context(), "encode lockouts failed: %v", err)
+		}
+	case http.MethodDelete:
+		user := UserName(request.URL.Query().Get("user"))
+		var ip net.IP
+		if value := request.URL.Query().Get("ip"); len(value) > 0 {
+			if ip = net.ParseIP(value); ip == nil {
+				http.Error(responseWriter, "ip invalid", http.StatusBadRequest)
+				return
+			}
+		}
+		if len(user) == 0 && ip == nil {
+			http.Error(responseWriter, "user or ip missing", http.StatusBadRequest)
+			return
+		}
+		infof(request.Context(), 1, "clear login lockout of user %v and ip %v", user, ip)
+		h.loginThrottle.Clear(user, ip)
+		responseWriter.WriteHeader(http.StatusNoContent)
+	default:
+		responseWriter.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"container/list"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// LoginMaxFailures is the number of failed logins before a lockout starts. Zero disables it.
+type LoginMaxFailures int
+
+func (l LoginMaxFailures) Int() int {
+	return int(l)
+}
+
+// LoginLockout is the duration of the first lockout. It doubles with every further failure.
+type LoginLockout time.Duration
+
+func (l LoginLockout) Duration() time.Duration {
+	return time.Duration(l)
+}
+
+// LoginLockoutMax caps the lockout duration. Failures older than this are forgotten.
+type LoginLockoutMax time.Duration
+
+func (l LoginLockoutMax) Duration() time.Duration {
+	return time.Duration(l)
+}
+
+// LoginMaxEntries limits the number of tracked users and ips each. Unlocked entries with
+// the oldest failure are dropped first.
+type LoginMaxEntries int
+
+func (l LoginMaxEntries) Int() int {
+	return int(l)
+}
+
+// LoginLockoutEntry describes a locked user or client ip.
+type LoginLockoutEntry struct {
+	User        UserName  `json:"user,omitempty"`
+	IP          string    `json:"ip,omitempty"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked-until"`
+}
+
+//counterfeiter:generate -o ../mocks/login-throttle.go --fake-name LoginThrottle . LoginThrottle
+type LoginThrottle interface {
+	// RetryAfter returns how long user or ip are locked. Zero if a login is allowed.
+	RetryAfter(user UserName, ip net.IP) time.Duration
+	// Failure records a failed login of user from ip.
+	Failure(user UserName, ip net.IP)
+	// Success resets the failures of user.
+	Success(user UserName, ip net.IP)
+	// Clear removes failures and lockouts of user and ip. Empty values are ignored.
+	Clear(user UserName, ip net.IP)
+	// Lockouts returns all currently locked users and ips.
+	Lockouts() []LoginLockoutEntry
+}
+
+// LoginThrottleDisabled allows unlimited login attempts.
+var LoginThrottleDisabled LoginThrottle = loginThrottleDisabled{}
+
+type loginThrottleDisabled struct{}
+
+func (loginThrottleDisabled) RetryAfter(user UserName, ip net.IP) time.Duration { return 0 }
+func (loginThrottleDisabled) Failure(user UserName, ip net.IP)                  {}
+func (loginThrottleDisabled) Success(user UserName, ip net.IP)                  {}
+func (loginThrottleDisabled) Clear(user UserName, ip net.IP)                    {}
+func (loginThrottleDisabled) Lockouts() []LoginLockoutEntry                     { return nil }
+
+// NewLoginThrottle counts failed logins per username and per client ip in memory.
+// After maxFailuresPerUser or maxFailuresPerIP failures the user or ip is locked
+// for lockout, doubled with every further failure up to lockoutMax.
+// At most maxEntries users and ips are tracked.
+func NewLoginThrottle(
+	maxFailuresPerUser LoginMaxFailures,
+	maxFailuresPerIP LoginMaxFailures,
+	lockout LoginLockout,
+	lockoutMax LoginLockoutMax,
+	maxEntries LoginMaxEntries,
+	now func() time.Time,
+) LoginThrottle {
+	return &loginThrottle{
+		maxFailuresPerUser: maxFailuresPerUser,
+		maxFailuresPerIP:   maxFailuresPerIP,
+		lockout:            lockout,
+		lockoutMax:         lockoutMax,
+		now:                now,
+		users:              newLoginFailuresLRU(maxEntries),
+		ips:                newLoginFailuresLRU(maxEntries),
+	}
+}
+
+type loginFailures struct {
+	key         string
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+type loginThrottle struct {
+	maxFailuresPerUser LoginMaxFailures
+	maxFailuresPerIP   LoginMaxFailures
+	lockout            LoginLockout
+	lockoutMax         LoginLockoutMax
+	now                func() time.Time
+
+	mux       sync.Mutex
+	users     *loginFailuresLRU
+	ips       *loginFailuresLRU
+	lastPurge time.Time
+}
+
+func (l *loginThrottle) RetryAfter(user UserName, ip net.IP) time.Duration {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	now := l.now()
+	var result time.Duration
+	if failures := l.users.get(user.String()); failures != nil && failures.lockedUntil.After(now) {
+		result = failures.lockedUntil.Sub(now)
+	}
+	if failures := l.ips.get(ipKey(ip)); failures != nil && failures.lockedUntil.After(now) {
+		if retryAfter := failures.lockedUntil.Sub(now); retryAfter > result {
+			result = retryAfter
+		}
+	}
+	return result
+}
+
+func (l *loginThrottle) Failure(user UserName, ip net.IP) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	now := l.now()
+	l.purge(now)
+	if len(user) > 0 && l.maxFailuresPerUser > 0 {
+		failures := l.users.add(user.String(), now)
+		if l.record(failures, l.maxFailuresPerUser, now) {
+			glog.V(1).Infof("user %v locked until %v", user, failures.lockedUntil)
+		}
+	}
+	if key := ipKey(ip); len(key) > 0 && l.maxFailuresPerIP > 0 {
+		failures := l.ips.add(key, now)
+		if l.record(failures, l.maxFailuresPerIP, now) {
+			glog.V(1).Infof("client %v locked until %v", key, failures.lockedUntil)
+		}
+	}
+}
+
+// record counts the failure and returns true if it starts a lockout.
+func (l *loginThrottle) record(
+	failures *loginFailures,
+	maxFailures LoginMaxFailures,
+	now time.Time,
+) bool {
+	failures.count++
+	failures.lastFailure = now
+	if failures.count < maxFailures.Int() {
+		return false
+	}
+	lockout := l.lockout.Duration()
+	for i := maxFailures.Int(); i < failures.count && lockout < l.lockoutMax.Duration(); i++ {
+		lockout *= 2
+	}
+	if lockout > l.lockoutMax.Duration() {
+		lockout = l.lockoutMax.Duration()
+	}
+	failures.lockedUntil = now.Add(lockout)
+	return true
+}
+
+// purge forgets failures that are neither recent nor locked once per minute.
+func (l *loginThrottle) purge(now time.Time) {
+	if now.Sub(l.lastPurge) < time.Minute {
+		return
+	}
+	l.lastPurge = now
+	expired := func(failures *loginFailures) bool {
+		return !failures.lockedUntil.After(now) &&
+			now.Sub(failures.lastFailure) > l.lockoutMax.Duration()
+	}
+	l.users.purge(expired)
+	l.ips.purge(expired)
+}
+
+func (l *loginThrottle) Success(user UserName, ip net.IP) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.users.remove(user.String())
+}
+
+func (l *loginThrottle) Clear(user UserName, ip net.IP) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if len(user) > 0 {
+		l.users.remove(user.String())
+	}
+	if key := ipKey(ip); len(key) > 0 {
+		l.ips.remove(key)
+	}
+}
+
+func (l *loginThrottle) Lockouts() []LoginLockoutEntry {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	now := l.now()
+	result := []LoginLockoutEntry{}
+	for element := l.users.lru.Front(); element != nil; element = element.Next() {
+		if failures := element.Value.(*loginFailures); failures.lockedUntil.After(now) {
+			result = append(result, LoginLockoutEntry{
+				User:        UserName(failures.key),
+				Failures:    failures.count,
+				LockedUntil: failures.lockedUntil,
+			})
+		}
+	}
+	for element := l.ips.lru.Front(); element != nil; element = element.Next() {
+		if failures := element.Value.(*loginFailures); failures.lockedUntil.After(now) {
+			result = append(result, LoginLockoutEntry{
+				IP:          failures.key,
+				Failures:    failures.count,
+				LockedUntil: failures.lockedUntil,
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LockedUntil.Before(result[j].LockedUntil)
+	})
+	return result
+}
+
+// loginFailuresLRU keeps the failures of at most maxEntries keys, ordered by the
+// last failure.
+type loginFailuresLRU struct {
+	maxEntries LoginMaxEntries
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+func newLoginFailuresLRU(maxEntries LoginMaxEntries) *loginFailuresLRU {
+	return &loginFailuresLRU{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+// get returns the failures of key or nil.
+func (l *loginFailuresLRU) get(key string) *loginFailures {
+	element, ok := l.entries[key]
+	if !ok {
+		return nil
+	}
+	return element.Value.(*loginFailures)
+}
+
+// add returns the failures of key, created if missing, and marks them as most recent.
+// If maxEntries is exceeded, the least recent unlocked failures are dropped, so flooding
+// with new keys does not release locked ones. Only if all are locked the least recent
+// locked failures are dropped.
+func (l *loginFailuresLRU) add(key string, now time.Time) *loginFailures {
+	if element, ok := l.entries[key]; ok {
+		l.lru.MoveToFront(element)
+		return element.Value.(*loginFailures)
+	}
+	failures := &loginFailures{key: key}
+	l.entries[key] = l.lru.PushFront(failures)
+	for l.maxEntries > 0 && l.lru.Len() > l.maxEntries.Int() {
+		l.removeElement(l.evictable(now))
+	}
+	return failures
+}
+
+// evictable returns the least recent unlocked element except the most recent one, or
+// the least recent element if all others are locked.
+func (l *loginFailuresLRU) evictable(now time.Time) *list.Element {
+	for element := l.lru.Back(); element != l.lru.Front(); element = element.Prev() {
+		if !element.Value.(*loginFailures).lockedUntil.After(now) {
+			return element
+		}
+	}
+	return l.lru.Back()
+}
+
+func (l *loginFailuresLRU) remove(key string) {
+	if element, ok := l.entries[key]; ok {
+		l.removeElement(element)
+	}
+}
+
+func (l *loginFailuresLRU) removeElement(element *list.Element) {
+	l.lru.Remove(element)
+	delete(l.entries, element.Value.(*loginFailures).key)
+}
+
+// purge removes expired failures starting with the least recent and stops at the
+// first failure not expired.
+func (l *loginFailuresLRU) purge(expired func(failures *loginFailures) bool) {
+	for element := l.lru.Back(); element != nil && expired(element.Value.(*loginFailures)); {
+		previous := element.Prev()
+		l.removeElement(element)
+		element = previous
+	}
+}
+
+func ipKey(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// serveTooManyRequests tells the client to retry the login after the lockout.
+func serveTooManyRequests(responseWriter http.ResponseWriter, retryAfter time.Duration) {
+	responseWriter.Header().Set("Retry-After", formatSeconds(retryAfter))
+	http.Error(responseWriter, "too many failed logins", http.StatusTooManyRequests)
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"encoding/json"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("LoginThrottle", func() {
+	var now time.Time
+	var loginThrottle pkg.LoginThrottle
+	var ip net.IP
+	var maxEntries pkg.LoginMaxEntries
+	BeforeEach(func() {
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		ip = net.ParseIP("1.2.3.4")
+		maxEntries = 100
+	})
+	JustBeforeEach(func() {
+		loginThrottle = pkg.NewLoginThrottle(
+			3,
+			5,
+			pkg.LoginLockout(time.Minute),
+			pkg.LoginLockoutMax(10*time.Minute),
+			maxEntries,
+			func() time.Time { return now },
+		)
+	})
+	failures := func(user pkg.UserName, ip net.IP, count int) {
+		for i := 0; i < count; i++ {
+			loginThrottle.Failure(user, ip)
+		}
+	}
+	It("allows login below max failures", func() {
+		failures("alice", ip, 2)
+		Expect(loginThrottle.RetryAfter("alice", ip)).To(Equal(time.Duration(0)))
+	})
+	It("locks user after max failures", func() {
+		failures("alice", ip, 3)
+		Expect(loginThrottle.RetryAfter("alice", net.ParseIP("5.6.7.8"))).To(Equal(time.Minute))
+		Expect(loginThrottle.RetryAfter("bob", net.ParseIP("5.6.7.8"))).To(Equal(time.Duration(0)))
+	})
+	It("doubles lockout up to max", func() {
+		failures("alice", nil, 4)
+		Expect(loginThrottle.RetryAfter("alice", nil)).To(Equal(2 * time.Minute))
+		failures("alice", nil, 10)
+		Expect(loginThrottle.RetryAfter("alice", nil)).To(Equal(10 * time.Minute))
+	})
+	It("unlocks after lockout", func() {
+		failures("alice", ip, 3)
+		now = now.Add(time.Minute)
+		Expect(loginThrottle.RetryAfter("alice", ip)).To(Equal(time.Duration(0)))
+	})
+	It("locks ip after max failures of different users", func() {
+		for _, user := range []pkg.UserName{"a", "b", "c", "d", "e"} {
+			loginThrottle.Failure(user, ip)
+		}
+		Expect(loginThrottle.RetryAfter("f", ip)).To(Equal(time.Minute))
+	})
+	It("resets user on success", func() {
+		failures("alice", ip, 2)
+		loginThrottle.Success("alice", ip)
+		failures("alice", ip, 2)
+		Expect(loginThrottle.RetryAfter("alice", ip)).To(Equal(time.Duration(0)))
+	})
+	It("clears lockout", func() {
+		failures("alice", ip, 5)
+		Expect(loginThrottle.Lockouts()).To(HaveLen(2))
+		loginThrottle.Clear("alice", ip)
+		Expect(loginThrottle.RetryAfter("alice", ip)).To(Equal(time.Duration(0)))
+		Expect(loginThrottle.Lockouts()).To(BeEmpty())
+	})
+	It("forgets failures after lockout max", func() {
+		failures("alice", ip, 2)
+		now = now.Add(11 * time.Minute)
+		failures("bob", nil, 1)
+		failures("alice", ip, 1)
+		Expect(loginThrottle.RetryAfter("alice", ip)).To(Equal(time.Duration(0)))
+	})
+	Context("max entries", func() {
+		BeforeEach(func() {
+			maxEntries = 2
+		})
+		It("drops user with oldest failure", func() {
+			failures("alice", nil, 3)
+			failures("bob", nil, 3)
+			failures("alice", nil, 1)
+			failures("carol", nil, 3)
+			Expect(loginThrottle.RetryAfter("alice", nil)).To(BeNumerically(">", 0))
+			Expect(loginThrottle.RetryAfter("bob", nil)).To(Equal(time.Duration(0)))
+			Expect(loginThrottle.RetryAfter("carol", nil)).To(Equal(time.Minute))
+			Expect(loginThrottle.Lockouts()).To(HaveLen(2))
+		})
+		It("drops unlocked user before locked user", func() {
+			failures("alice", nil, 3)
+			failures("bob", nil, 1)
+			failures("carol", nil, 1)
+			failures("dave", nil, 1)
+			Expect(loginThrottle.RetryAfter("alice", nil)).To(Equal(time.Minute))
+			failures("bob", nil, 2)
+			Expect(loginThrottle.RetryAfter("bob", nil)).To(Equal(time.Duration(0)))
+		})
+	})
+	It("lists lockouts as json", func() {
+		failures("alice", nil, 3)
+		content, err := json.Marshal(loginThrottle.Lockouts())
+		Expect(err).To(BeNil())
+		Expect(string(content)).To(Equal(
+			`[{"user":"alice","failures":3,"locked-until":"2026-01-01T12:01:00Z"}]`,
+		))
+	})
+})
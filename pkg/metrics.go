@@ -0,0 +1,126 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "auth_http_proxy"
+
+// Outcomes of authentication attempts and logins.
+const (
+	OutcomeSuccess = "success"
+	OutcomeInvalid = "invalid"
+	OutcomeError   = "error"
+	OutcomeLocked  = "locked"
+)
+
+// Results of the credential cache.
+const (
+	cacheResultHit   = "hit"
+	cacheResultMiss  = "miss"
+	cacheResultStale = "stale"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "requests_total",
+		Help:      "Requests by route and status code.",
+	}, []string{"route", "code"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_duration_seconds",
+		Help:      "Request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route"})
+	authAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "auth_attempts_total",
+		Help:      "Password verifications by verifier and outcome.",
+	}, []string{"verifier", "outcome"})
+	cacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "cache_requests_total",
+		Help:      "Lookups of the credential cache by result (hit, miss, stale).",
+	}, []string{"result"})
+	ldapConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "ldap_connections",
+		Help:      "Ldap connections by server and state (in_use, idle).",
+	}, []string{"server", "state"})
+	ldapErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "ldap_errors_total",
+		Help:      "Connection errors by ldap server.",
+	}, []string{"server"})
+	upstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "upstream_duration_seconds",
+		Help:      "Latency of the target by status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"code"})
+	upstreamErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "upstream_errors_total",
+		Help:      "Requests to the target failed without response.",
+	})
+	loginsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "logins_total",
+		Help:      "Logins with the html form by outcome.",
+	}, []string{"outcome"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		requestsTotal,
+		requestDuration,
+		authAttemptsTotal,
+		cacheRequestsTotal,
+		ldapConnections,
+		ldapErrorsTotal,
+		upstreamDuration,
+		upstreamErrorsTotal,
+		loginsTotal,
+	)
+}
+
+// NewMetricsHandler counts requests and their latency for the route.
+func NewMetricsHandler(route string, subhandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+		statusResponseWriter := NewStatusResponseWriter(responseWriter)
+		subhandler.ServeHTTP(statusResponseWriter, request)
+		requestsTotal.WithLabelValues(route, strconv.Itoa(statusResponseWriter.Status())).Inc()
+		requestDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// NewMetricsVerifier counts the verifications of the verifier by outcome.
+func NewMetricsVerifier(name string, verifier Verifier) Verifier {
+	return VerifierFunc(func(
+		ctx context.Context,
+		username UserName,
+		password Password,
+	) (bool, error) {
+		ok, err := verifier.Verify(ctx, username, password)
+		switch {
+		case err != nil:
+			authAttemptsTotal.WithLabelValues(name, OutcomeError).Inc()
+		case ok:
+			authAttemptsTotal.WithLabelValues(name, OutcomeSuccess).Inc()
+		default:
+			authAttemptsTotal.WithLabelValues(name, OutcomeInvalid).Inc()
+		}
+		return ok, err
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+// metricValue returns the value of the counter or gauge with the given labels
+// from the default registry, or 0 if it was not recorded yet.
+func metricValue(name string, labels map[string]string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	Expect(err).To(BeNil())
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+			if metric.GetCounter() != nil {
+				return metric.GetCounter().GetValue()
+			}
+			return metric.GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+var _ = Describe("Metrics", func() {
+	Context("MetricsHandler", func() {
+		var labels map[string]string
+		var before float64
+		BeforeEach(func() {
+			labels = map[string]string{"route": "test", "code": "418"}
+			before = metricValue("auth_http_proxy_requests_total", labels)
+		})
+		It("counts requests by route and status code", func() {
+			handler := pkg.NewMetricsHandler("test", http.HandlerFunc(
+				func(responseWriter http.ResponseWriter, request *http.Request) {
+					responseWriter.WriteHeader(http.StatusTeapot)
+				},
+			))
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+			Expect(recorder.Code).To(Equal(http.StatusTeapot))
+			Expect(metricValue("auth_http_proxy_requests_total", labels)).To(Equal(before + 1))
+		})
+	})
+	Context("MetricsVerifier", func() {
+		var valid bool
+		var verifyErr error
+		var verifier pkg.Verifier
+		BeforeEach(func() {
+			verifier = pkg.NewMetricsVerifier("test", pkg.VerifierFunc(
+				func(ctx context.Context, username pkg.UserName, password pkg.Password) (bool, error) {
+					return valid, verifyErr
+				},
+			))
+		})
+		DescribeTable("counts attempts by outcome",
+			func(result bool, err error, outcome string) {
+				valid, verifyErr = result, err
+				labels := map[string]string{"verifier": "test", "outcome": outcome}
+				before := metricValue("auth_http_proxy_auth_attempts_total", labels)
+				ok, verifyErr := verifier.Verify(context.Background(), "user", "pass")
+				Expect(ok).To(Equal(result))
+				Expect(verifyErr != nil).To(Equal(err != nil))
+				Expect(metricValue("auth_http_proxy_auth_attempts_total", labels)).
+					To(Equal(before + 1))
+			},
+			Entry("success", true, nil, pkg.OutcomeSuccess),
+			Entry("invalid", false, nil, pkg.OutcomeInvalid),
+			Entry("error", false, errors.New("banana"), pkg.OutcomeError),
+		)
+	})
+	Context("CacheAuth", func() {
+		cacheRequests := func(result string) float64 {
+			return metricValue(
+				"auth_http_proxy_cache_requests_total",
+				map[string]string{"result": result},
+			)
+		}
+		It("counts hits and misses", func() {
+			hits := cacheRequests("hit")
+			misses := cacheRequests("miss")
+			cacheAuth := pkg.NewCacheAuth(
+				pkg.VerifierFunc(func(
+					ctx context.Context,
+					username pkg.UserName,
+					password pkg.Password,
+				) (bool, error) {
+					return true, nil
+				}),
+				pkg.CacheTTL(time.Minute),
+				pkg.CacheNegativeTTL(time.Minute),
+				0,
+				10,
+				pkg.AuditLogDisabled,
+				time.Now,
+			)
+			for i := 0; i < 3; i++ {
+				_, err := cacheAuth.Verify(context.Background(), "user", "pass")
+				Expect(err).To(BeNil())
+			}
+			Expect(cacheRequests("hit")).To(Equal(hits + 2))
+			Expect(cacheRequests("miss")).To(Equal(misses + 1))
+		})
+	})
+})
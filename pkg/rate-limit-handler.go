@@ -0,0 +1,51 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// NewRateLimitHandler forwards requests to the subhandler as long as the rate limiter
+// has tokens left and adds the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers. Requests exceeding the limit get 429 with Retry-After.
+func NewRateLimitHandler(subhandler http.Handler, rateLimiter RateLimiter) http.Handler {
+	h := new(rateLimitHandler)
+	h.subhandler = subhandler
+	h.rateLimiter = rateLimiter
+	return h
+}
+
+type rateLimitHandler struct {
+	subhandler  http.Handler
+	rateLimiter RateLimiter
+}
+
+func (h *rateLimitHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	result := h.rateLimiter.Take(request)
+	if !result.Limited {
+		h.subhandler.ServeHTTP(responseWriter, request)
+		return
+	}
+	header := responseWriter.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", formatSeconds(result.Reset))
+	if !result.Allowed {
+		infof(request.Context(), 2, "rate limit exceeded for %s %s", request.Method, request.URL.Path)
+		header.Set("Retry-After", formatSeconds(result.RetryAfter))
+		http.Error(responseWriter, "rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+	h.subhandler.ServeHTTP(responseWriter, request)
+}
+
+// formatSeconds rounds the duration up to full seconds.
+func formatSeconds(duration time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(duration.Seconds())), 10)
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+)
+
+type RateLimitFile string
+
+func (r RateLimitFile) String() string {
+	return string(r)
+}
+
+// RateLimitRule limits matching requests to Rate requests per second with bursts of Burst.
+// The request is matched by host, path and method, the user by name or by any of the groups.
+// Requests without authenticated user are matched by rules without users and groups.
+type RateLimitRule struct {
+	Host       string     `json:"host,omitempty"`
+	PathPrefix string     `json:"path-prefix,omitempty"`
+	PathRegex  string     `json:"path-regex,omitempty"`
+	Methods    []string   `json:"methods,omitempty"`
+	Groups     GroupNames `json:"groups,omitempty"`
+	Users      []UserName `json:"users,omitempty"`
+	Rate       float64    `json:"rate"`
+	Burst      int        `json:"burst,omitempty"`
+
+	request AuthorizationRule
+}
+
+// Unlimited returns true for rules exempting requests from rate limiting.
+func (r *RateLimitRule) Unlimited() bool {
+	return r.Rate <= 0
+}
+
+// Matches returns true if the rule applies to the request of the identity.
+func (r *RateLimitRule) Matches(request *http.Request, identity *Identity) bool {
+	if !r.request.Matches(request) {
+		return false
+	}
+	if len(r.Users) == 0 && len(r.Groups) == 0 {
+		return true
+	}
+	if identity == nil {
+		return false
+	}
+	for _, user := range r.Users {
+		if user == identity.Name {
+			return true
+		}
+	}
+	for _, group := range r.Groups {
+		if identity.Groups.Contains(group) {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimitRules are evaluated in order, the first matching rule applies.
+type RateLimitRules []RateLimitRule
+
+// ParseRateLimitRules parses a json list of rules.
+func ParseRateLimitRules(ctx context.Context, content []byte) (RateLimitRules, error) {
+	var rules RateLimitRules
+	if err := json.Unmarshal(content, &rules); err != nil {
+		return nil, errors.Wrapf(ctx, err, "unmarshal rate limit rules failed")
+	}
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Burst <= 0 {
+			rule.Burst = int(math.Max(1, math.Ceil(rule.Rate)))
+		}
+		rule.request = AuthorizationRule{
+			Host:       rule.Host,
+			PathPrefix: rule.PathPrefix,
+			Methods:    rule.Methods,
+		}
+		if len(rule.PathRegex) > 0 {
+			pathRegex, err := regexp.Compile(rule.PathRegex)
+			if err != nil {
+				return nil, errors.Wrapf(ctx, err, "compile path-regex of rule %d failed", i)
+			}
+			rule.request.pathRegex = pathRegex
+		}
+	}
+	return rules, nil
+}
+
+// ReadRateLimitFile reads the rules from a json file.
+func ReadRateLimitFile(ctx context.Context, rateLimitFile RateLimitFile) (RateLimitRules, error) {
+	content, err := os.ReadFile(rateLimitFile.String())
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "read rate limit file %s failed", rateLimitFile)
+	}
+	return ParseRateLimitRules(ctx, content)
+}
+
+// RateLimitResult describes the bucket used for a request.
+type RateLimitResult struct {
+	// Limited is false if no rule limits the request.
+	Limited bool
+	// Allowed is true if a token was available.
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+//counterfeiter:generate -o ../mocks/rate-limiter.go --fake-name RateLimiter . RateLimiter
+type RateLimiter interface {
+	// Take takes a token from the bucket of the request.
+	Take(request *http.Request) RateLimitResult
+}
+
+// NewRateLimiter keeps one token bucket per rule and user in memory.
+// Requests without authenticated user share a bucket per client ip.
+func NewRateLimiter(
+	rules RateLimitRules,
+	groupResolver GroupResolver,
+	now func() time.Time,
+) RateLimiter {
+	return &rateLimiter{
+		rules:         rules,
+		groupResolver: groupResolver,
+		now:           now,
+		buckets:       map[rateLimitKey]*rateLimitBucket{},
+	}
+}
+
+type rateLimitKey struct {
+	rule   int
+	client string
+}
+
+type rateLimitBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type rateLimiter struct {
+	rules         RateLimitRules
+	groupResolver GroupResolver
+	now           func() time.Time
+
+	mux       sync.Mutex
+	buckets   map[rateLimitKey]*rateLimitBucket
+	lastPurge time.Time
+}
+
+func (r *rateLimiter) Take(request *http.Request) RateLimitResult {
+	identity, _ := IdentityFromContext(request.Context())
+	r.resolveGroups(request.Context(), identity)
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if !rule.Matches(request, identity) {
+			continue
+		}
+		if rule.Unlimited() {
+			return RateLimitResult{}
+		}
+		key := rateLimitKey{rule: i}
+		if identity != nil {
+			key.client = "user:" + identity.Name.String()
+		} else {
+			key.client = "ip:" + ipKey(ClientIP(request))
+		}
+		return r.take(rule, key)
+	}
+	return RateLimitResult{}
+}
+
+// resolveGroups loads the groups of password users once if any rule depends on groups.
+func (r *rateLimiter) resolveGroups(ctx context.Context, identity *Identity) {
+	if identity == nil || identity.Groups != nil || !identity.GroupsFromDirectory() {
+		return
+	}
+	for _, rule := range r.rules {
+		if len(rule.Groups) == 0 {
+			continue
+		}
+		groups, err := r.groupResolver.GroupsOfUser(ctx, identity.Name)
+		if err != nil {
+			warningf(ctx, "resolve groups of user %v failed: %v", identity.Name, err)
+			return
+		}
+		identity.Groups = groups
+		return
+	}
+}
+
+func (r *rateLimiter) take(rule *RateLimitRule, key rateLimitKey) RateLimitResult {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	now := r.now()
+	r.purge(now)
+	burst := float64(rule.Burst)
+	bucket, ok := r.buckets[key]
+	if !ok {
+		bucket = &rateLimitBucket{tokens: burst, updated: now}
+		r.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*rule.Rate)
+	bucket.updated = now
+	result := RateLimitResult{
+		Limited: true,
+		Limit:   rule.Burst,
+	}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = rateLimitDuration((1 - bucket.tokens) / rule.Rate)
+	}
+	result.Remaining = int(bucket.tokens)
+	result.Reset = rateLimitDuration((burst - bucket.tokens) / rule.Rate)
+	return result
+}
+
+// purge removes buckets refilled completely once per minute.
+func (r *rateLimiter) purge(now time.Time) {
+	if now.Sub(r.lastPurge) < time.Minute {
+		return
+	}
+	r.lastPurge = now
+	for key, bucket := range r.buckets {
+		rule := r.rules[key.rule]
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*rule.Rate >= float64(rule.Burst) {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+func rateLimitDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
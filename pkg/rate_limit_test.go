@@ -0,0 +1,145 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("RateLimiter", func() {
+	var ctx context.Context
+	var now time.Time
+	var groupResolver *mocks.GroupResolver
+	var rateLimiter pkg.RateLimiter
+	var request func(path string, identity *pkg.Identity) *http.Request
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		groupResolver = &mocks.GroupResolver{}
+		rules, err := pkg.ParseRateLimitRules(ctx, []byte(`[
+			{"path-prefix": "/health", "rate": 0},
+			{"groups": ["service"], "rate": 50, "burst": 100},
+			{"rate": 1, "burst": 2}
+		]`))
+		Expect(err).To(BeNil())
+		rateLimiter = pkg.NewRateLimiter(rules, groupResolver, func() time.Time { return now })
+		request = func(path string, identity *pkg.Identity) *http.Request {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.RemoteAddr = "1.2.3.4:1234"
+			if identity != nil {
+				req = req.WithContext(pkg.WithIdentity(req.Context(), identity))
+			}
+			return req
+		}
+	})
+	It("returns error for invalid regex", func() {
+		_, err := pkg.ParseRateLimitRules(ctx, []byte(`[{"path-regex": "("}]`))
+		Expect(err).NotTo(BeNil())
+	})
+	It("does not limit unlimited rule", func() {
+		result := rateLimiter.Take(request("/healthz", nil))
+		Expect(result.Limited).To(BeFalse())
+	})
+	It("limits after burst", func() {
+		identity := &pkg.Identity{Name: "alice", Groups: pkg.GroupNames{}}
+		first := rateLimiter.Take(request("/", identity))
+		Expect(first.Allowed).To(BeTrue())
+		Expect(first.Limit).To(Equal(2))
+		Expect(first.Remaining).To(Equal(1))
+		Expect(rateLimiter.Take(request("/", identity)).Allowed).To(BeTrue())
+		third := rateLimiter.Take(request("/", identity))
+		Expect(third.Allowed).To(BeFalse())
+		Expect(third.RetryAfter).To(Equal(time.Second))
+		Expect(third.Reset).To(Equal(2 * time.Second))
+	})
+	It("refills tokens", func() {
+		identity := &pkg.Identity{Name: "alice", Groups: pkg.GroupNames{}}
+		rateLimiter.Take(request("/", identity))
+		rateLimiter.Take(request("/", identity))
+		now = now.Add(time.Second)
+		Expect(rateLimiter.Take(request("/", identity)).Allowed).To(BeTrue())
+	})
+	It("uses separate buckets per user and client", func() {
+		alice := &pkg.Identity{Name: "alice", Groups: pkg.GroupNames{}}
+		rateLimiter.Take(request("/", alice))
+		rateLimiter.Take(request("/", alice))
+		Expect(rateLimiter.Take(request("/", alice)).Allowed).To(BeFalse())
+		bob := &pkg.Identity{Name: "bob", Groups: pkg.GroupNames{}}
+		Expect(rateLimiter.Take(request("/", bob)).Allowed).To(BeTrue())
+		Expect(rateLimiter.Take(request("/", nil)).Allowed).To(BeTrue())
+	})
+	It("uses group rule", func() {
+		identity := &pkg.Identity{Name: "ci", Groups: pkg.GroupNames{"service"}}
+		Expect(rateLimiter.Take(request("/", identity)).Limit).To(Equal(100))
+	})
+	It("resolves groups of password users", func() {
+		groupResolver.GroupsOfUserReturns(pkg.GroupNames{"service"}, nil)
+		identity := &pkg.Identity{Name: "ci", Source: pkg.IdentitySourcePassword}
+		Expect(rateLimiter.Take(request("/", identity)).Limit).To(Equal(100))
+		Expect(groupResolver.GroupsOfUserCallCount()).To(Equal(1))
+	})
+})
+
+var _ = Describe("RateLimitHandler", func() {
+	var rateLimiter *mocks.RateLimiter
+	var subhandler *mocks.HttpHandler
+	var recorder *httptest.ResponseRecorder
+	BeforeEach(func() {
+		rateLimiter = &mocks.RateLimiter{}
+		subhandler = &mocks.HttpHandler{}
+		recorder = httptest.NewRecorder()
+	})
+	JustBeforeEach(func() {
+		pkg.NewRateLimitHandler(subhandler, rateLimiter).
+			ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	Context("not limited", func() {
+		It("calls subhandler without headers", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+			Expect(recorder.Header().Get("RateLimit-Limit")).To(BeEmpty())
+		})
+	})
+	Context("allowed", func() {
+		BeforeEach(func() {
+			rateLimiter.TakeReturns(pkg.RateLimitResult{
+				Limited:   true,
+				Allowed:   true,
+				Limit:     10,
+				Remaining: 9,
+				Reset:     100 * time.Millisecond,
+			})
+		})
+		It("calls subhandler with headers", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(1))
+			Expect(recorder.Header().Get("RateLimit-Limit")).To(Equal("10"))
+			Expect(recorder.Header().Get("RateLimit-Remaining")).To(Equal("9"))
+			Expect(recorder.Header().Get("RateLimit-Reset")).To(Equal("1"))
+		})
+	})
+	Context("exceeded", func() {
+		BeforeEach(func() {
+			rateLimiter.TakeReturns(pkg.RateLimitResult{
+				Limited:    true,
+				Limit:      10,
+				Reset:      10 * time.Second,
+				RetryAfter: 2 * time.Second,
+			})
+		})
+		It("returns too many requests", func() {
+			Expect(subhandler.ServeHTTPCallCount()).To(Equal(0))
+			Expect(recorder.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(recorder.Header().Get("Retry-After")).To(Equal("2"))
+		})
+	})
+})
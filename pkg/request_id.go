@@ -0,0 +1,112 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+)
+
+// HeaderRequestID is the header the request id is returned to the client and
+// forwarded to the target with.
+const HeaderRequestID = "X-Request-ID"
+
+// requestIDMaxLength limits request ids accepted from the trusted header.
+const requestIDMaxLength = 128
+
+// TrustedRequestIDHeader is the header a request id is accepted from, like X-Request-ID.
+type TrustedRequestIDHeader string
+
+func (t TrustedRequestIDHeader) String() string {
+	return string(t)
+}
+
+type requestIDContextKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request id.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request id set by the request id handler or "".
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// NewRequestIDHandler assigns a request id to every request and returns it in the
+// X-Request-ID response header. The id is taken from the trusted header if it is set
+// and valid and the request comes from a trusted proxy or no proxies are configured.
+// Otherwise a random id is generated.
+func NewRequestIDHandler(
+	subhandler http.Handler,
+	trustedHeader TrustedRequestIDHeader,
+	trustedProxies Networks,
+) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		requestID := request.Header.Get(trustedHeader.String())
+		if len(trustedHeader) == 0 || !validRequestID(requestID) ||
+			len(trustedProxies) > 0 && !trustedProxies.Contains(remoteAddrIP(request)) {
+			requestID = newRequestID()
+		}
+		responseWriter.Header().Set(HeaderRequestID, requestID)
+		subhandler.ServeHTTP(
+			responseWriter,
+			request.WithContext(WithRequestID(request.Context(), requestID)),
+		)
+	})
+}
+
+// validRequestID allows ids of letters, digits and -_.:/+=@ so they can not break
+// log lines or headers.
+func validRequestID(requestID string) bool {
+	if len(requestID) == 0 || len(requestID) > requestIDMaxLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == ':' || r == '/' || r == '+' ||
+			r == '=' || r == '@':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		glog.Warningf("read random request id failed: %v", err)
+	}
+	return hex.EncodeToString(id)
+}
+
+// infof writes the glog info line prefixed with the request id of ctx if the
+// verbosity level is enabled for the calling file.
+func infof(ctx context.Context, level glog.Level, format string, args ...interface{}) {
+	if glog.VDepth(1, level) {
+		glog.InfoDepth(1, requestLogPrefix(ctx)+fmt.Sprintf(format, args...))
+	}
+}
+
+// warningf writes the glog warning line prefixed with the request id of ctx.
+func warningf(ctx context.Context, format string, args ...interface{}) {
+	glog.WarningDepth(1, requestLogPrefix(ctx)+fmt.Sprintf(format, args...))
+}
+
+func requestLogPrefix(ctx context.Context) string {
+	requestID := RequestIDFromContext(ctx)
+	if len(requestID) == 0 {
+		return ""
+	}
+	return "[" + requestID + "] "
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("RequestIDHandler", func() {
+	var trustedHeader pkg.TrustedRequestIDHeader
+	var trustedProxies pkg.Networks
+	var request *http.Request
+	var recorder *httptest.ResponseRecorder
+	var requestID string
+	BeforeEach(func() {
+		trustedHeader = ""
+		trustedProxies = nil
+		request = httptest.NewRequest(http.MethodGet, "/path", nil)
+		request.RemoteAddr = "10.0.0.1:1234"
+		request.Header.Set("X-Request-ID", "req-1")
+		recorder = httptest.NewRecorder()
+		requestID = ""
+	})
+	JustBeforeEach(func() {
+		pkg.NewRequestIDHandler(
+			http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+				requestID = pkg.RequestIDFromContext(request.Context())
+				responseWriter.WriteHeader(http.StatusForbidden)
+			}),
+			trustedHeader,
+			trustedProxies,
+		).ServeHTTP(recorder, request)
+	})
+	It("generates request id", func() {
+		Expect(requestID).To(MatchRegexp("^[0-9a-f]{32}$"))
+		Expect(recorder.Header().Get("X-Request-ID")).To(Equal(requestID))
+		Expect(recorder.Code).To(Equal(http.StatusForbidden))
+	})
+	Context("trusted header", func() {
+		BeforeEach(func() {
+			trustedHeader = "X-Request-ID"
+		})
+		It("accepts request id", func() {
+			Expect(requestID).To(Equal("req-1"))
+			Expect(recorder.Header().Get("X-Request-ID")).To(Equal("req-1"))
+		})
+		Context("invalid value", func() {
+			BeforeEach(func() {
+				request.Header.Set("X-Request-ID", "req 1\r\nX-Evil: 1")
+			})
+			It("generates request id", func() {
+				Expect(requestID).To(MatchRegexp("^[0-9a-f]{32}$"))
+			})
+		})
+		Context("trusted proxy", func() {
+			BeforeEach(func() {
+				_, network, err := net.ParseCIDR("10.0.0.0/8")
+				Expect(err).To(BeNil())
+				trustedProxies = pkg.Networks{network}
+			})
+			It("accepts request id", func() {
+				Expect(requestID).To(Equal("req-1"))
+			})
+		})
+		Context("untrusted proxy", func() {
+			BeforeEach(func() {
+				_, network, err := net.ParseCIDR("192.168.0.0/16")
+				Expect(err).To(BeNil())
+				trustedProxies = pkg.Networks{network}
+			})
+			It("generates request id", func() {
+				Expect(requestID).To(MatchRegexp("^[0-9a-f]{32}$"))
+			})
+		})
+	})
+})
+
+var _ = Describe("ForwardHandler request id", func() {
+	It("forwards request id to target", func() {
+		var upstreamHeader http.Header
+		handler := pkg.NewForwardHandler(
+			"target",
+			func(address string, req *http.Request) (*http.Response, error) {
+				upstreamHeader = req.Header
+				recorder := httptest.NewRecorder()
+				recorder.Header().Set("X-Request-ID", "upstream")
+				recorder.WriteHeader(http.StatusOK)
+				return recorder.Result(), nil
+			},
+		)
+		request := httptest.NewRequest(http.MethodGet, "/path", nil)
+		request.Header.Set("X-Request-ID", "spoofed")
+		request = request.WithContext(pkg.WithRequestID(context.Background(), "req-1"))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		Expect(upstreamHeader.Get("X-Request-ID")).To(Equal("req-1"))
+		Expect(recorder.Header().Get("X-Request-ID")).To(Equal("req-1"))
+	})
+})
@@ -0,0 +1,179 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// Session is a login with the html form.
+type Session struct {
+	ID       string    `json:"id"`
+	User     UserName  `json:"user"`
+	ClientIP string    `json:"client-ip,omitempty"`
+	Created  time.Time `json:"created"`
+	LastSeen time.Time `json:"last-seen"`
+	Expires  time.Time `json:"expires"`
+}
+
+//counterfeiter:generate -o ../mocks/session-store.go --fake-name SessionStore . SessionStore
+type SessionStore interface {
+	// Create returns a new session of the user valid until expires.
+	Create(user UserName, ip net.IP, expires time.Time) (*Session, error)
+	// Valid records the use of the session and returns false if it was revoked or expired.
+	// Sessions unknown to the store, for example after a restart, are registered again.
+	// Sessions without id are not valid.
+	Valid(session Session, ip net.IP) bool
+	// Sessions returns all known sessions that are not expired or revoked.
+	Sessions() []Session
+	// Revoke revokes the session and returns false if it is unknown.
+	Revoke(id string) bool
+	// RevokeUser revokes all sessions of the user created until now, including sessions
+	// unknown to the store, and returns the number of known sessions revoked.
+	RevokeUser(user UserName) int
+}
+
+// NewSessionStore keeps sessions and revocations in memory.
+// Revocations are lost on restart.
+func NewSessionStore(now func() time.Time) SessionStore {
+	return &sessionStore{
+		now:          now,
+		sessions:     map[string]*Session{},
+		revoked:      map[string]time.Time{},
+		revokedUsers: map[UserName]time.Time{},
+	}
+}
+
+type sessionStore struct {
+	now func() time.Time
+
+	mux      sync.Mutex
+	sessions map[string]*Session
+	// revoked contains the expiry of revoked session ids.
+	revoked map[string]time.Time
+	// revokedUsers contains the time all sessions of the user were revoked.
+	revokedUsers map[UserName]time.Time
+}
+
+func (s *sessionStore) Create(user UserName, ip net.IP, expires time.Time) (*Session, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	session := &Session{
+		ID:       hex.EncodeToString(id),
+		User:     user,
+		ClientIP: ipKey(ip),
+		Created:  now,
+		LastSeen: now,
+		Expires:  expires,
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.purge(now)
+	s.sessions[session.ID] = session
+	result := *session
+	return &result, nil
+}
+
+func (s *sessionStore) Valid(session Session, ip net.IP) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	now := s.now()
+	if revokedAt, ok := s.revokedUsers[session.User]; ok && !session.Created.After(revokedAt) {
+		glog.V(2).Infof("session of user %v created %v is revoked", session.User, session.Created)
+		return false
+	}
+	if len(session.ID) == 0 {
+		glog.V(2).Infof("session of user %v has no id", session.User)
+		return false
+	}
+	if _, ok := s.revoked[session.ID]; ok {
+		glog.V(2).Infof("session %v of user %v is revoked", session.ID, session.User)
+		return false
+	}
+	if !session.Expires.After(now) {
+		return false
+	}
+	known, ok := s.sessions[session.ID]
+	if !ok {
+		known = &session
+		s.sessions[session.ID] = known
+	}
+	known.LastSeen = now
+	known.ClientIP = ipKey(ip)
+	return true
+}
+
+func (s *sessionStore) Sessions() []Session {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.purge(s.now())
+	result := make([]Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		result = append(result, *session)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created.Before(result[j].Created)
+	})
+	return result
+}
+
+func (s *sessionStore) Revoke(id string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return false
+	}
+	glog.V(1).Infof("revoke session %v of user %v", id, session.User)
+	s.revoked[id] = session.Expires
+	delete(s.sessions, id)
+	return true
+}
+
+func (s *sessionStore) RevokeUser(user UserName) int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	now := s.now()
+	s.revokedUsers[user] = now
+	var count int
+	for id, session := range s.sessions {
+		if session.User == user {
+			s.revoked[id] = session.Expires
+			delete(s.sessions, id)
+			count++
+		}
+	}
+	glog.V(1).Infof("revoke %d sessions of user %v", count, user)
+	return count
+}
+
+// purge removes expired sessions and revocations no session can be affected by anymore.
+func (s *sessionStore) purge(now time.Time) {
+	for id, session := range s.sessions {
+		if !session.Expires.After(now) {
+			delete(s.sessions, id)
+		}
+	}
+	for id, expires := range s.revoked {
+		if !expires.After(now) {
+			delete(s.revoked, id)
+		}
+	}
+	for user, revokedAt := range s.revokedUsers {
+		if now.Sub(revokedAt) > loginDuration {
+			delete(s.revokedUsers, user)
+		}
+	}
+}
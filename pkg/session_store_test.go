@@ -0,0 +1,94 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("SessionStore", func() {
+	var now time.Time
+	var ip net.IP
+	var sessionStore pkg.SessionStore
+	BeforeEach(func() {
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		ip = net.ParseIP("10.0.0.1")
+		sessionStore = pkg.NewSessionStore(func() time.Time { return now })
+	})
+	It("creates valid session", func() {
+		session, err := sessionStore.Create("alice", ip, now.Add(time.Hour))
+		Expect(err).To(BeNil())
+		Expect(session.ID).To(HaveLen(32))
+		Expect(session.User).To(Equal(pkg.UserName("alice")))
+		Expect(session.ClientIP).To(Equal("10.0.0.1"))
+		Expect(sessionStore.Valid(*session, ip)).To(BeTrue())
+		Expect(sessionStore.Sessions()).To(HaveLen(1))
+	})
+	It("rejects expired session", func() {
+		session, err := sessionStore.Create("alice", ip, now.Add(time.Hour))
+		Expect(err).To(BeNil())
+		now = now.Add(time.Hour)
+		Expect(sessionStore.Valid(*session, ip)).To(BeFalse())
+		Expect(sessionStore.Sessions()).To(BeEmpty())
+	})
+	It("revokes session", func() {
+		session, err := sessionStore.Create("alice", ip, now.Add(time.Hour))
+		Expect(err).To(BeNil())
+		other, err := sessionStore.Create("alice", ip, now.Add(time.Hour))
+		Expect(err).To(BeNil())
+		Expect(sessionStore.Revoke(session.ID)).To(BeTrue())
+		Expect(sessionStore.Valid(*session, ip)).To(BeFalse())
+		Expect(sessionStore.Valid(*other, ip)).To(BeTrue())
+		Expect(sessionStore.Revoke(session.ID)).To(BeFalse())
+	})
+	It("registers unknown session", func() {
+		session := pkg.Session{ID: "abc", User: "alice", Created: now, Expires: now.Add(time.Hour)}
+		Expect(sessionStore.Valid(session, ip)).To(BeTrue())
+		Expect(sessionStore.Sessions()).To(HaveLen(1))
+		Expect(sessionStore.Revoke("abc")).To(BeTrue())
+		Expect(sessionStore.Valid(session, ip)).To(BeFalse())
+	})
+	Context("revoke user", func() {
+		var session *pkg.Session
+		BeforeEach(func() {
+			var err error
+			session, err = sessionStore.Create("alice", ip, now.Add(time.Hour))
+			Expect(err).To(BeNil())
+			_, err = sessionStore.Create("bob", ip, now.Add(time.Hour))
+			Expect(err).To(BeNil())
+			now = now.Add(time.Minute)
+			Expect(sessionStore.RevokeUser("alice")).To(Equal(1))
+		})
+		It("rejects sessions of user", func() {
+			Expect(sessionStore.Valid(*session, ip)).To(BeFalse())
+			Expect(sessionStore.Sessions()).To(HaveLen(1))
+		})
+		It("rejects unknown sessions created before", func() {
+			Expect(sessionStore.Valid(pkg.Session{User: "alice"}, ip)).To(BeFalse())
+			Expect(sessionStore.Valid(pkg.Session{
+				ID:      "abc",
+				User:    "alice",
+				Created: now.Add(-time.Second),
+				Expires: now.Add(time.Hour),
+			}, ip)).To(BeFalse())
+		})
+		It("accepts new sessions", func() {
+			now = now.Add(time.Second)
+			newSession, err := sessionStore.Create("alice", ip, now.Add(time.Hour))
+			Expect(err).To(BeNil())
+			Expect(sessionStore.Valid(*newSession, ip)).To(BeTrue())
+		})
+	})
+	It("rejects sessions without id", func() {
+		Expect(sessionStore.Valid(pkg.Session{User: "alice"}, ip)).To(BeFalse())
+		Expect(sessionStore.Sessions()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,66 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"net/http"
+)
+
+// StatusResponseWriter records the status code and the number of bytes written.
+type StatusResponseWriter interface {
+	http.ResponseWriter
+	Status() int
+	Bytes() int64
+}
+
+// NewStatusResponseWriter wraps the response writer. The status is 200 if
+// WriteHeader is not called.
+func NewStatusResponseWriter(responseWriter http.ResponseWriter) StatusResponseWriter {
+	return &statusResponseWriter{ResponseWriter: responseWriter}
+}
+
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (s *statusResponseWriter) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusResponseWriter) Write(data []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(data)
+	s.bytes += int64(n)
+	return n, err
+}
+
+func (s *statusResponseWriter) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to access the wrapped writer.
+func (s *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+func (s *statusResponseWriter) Status() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
+
+func (s *statusResponseWriter) Bytes() int64 {
+	return s.bytes
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("StatusResponseWriter", func() {
+	var recorder *httptest.ResponseRecorder
+	var statusResponseWriter pkg.StatusResponseWriter
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+		statusResponseWriter = pkg.NewStatusResponseWriter(recorder)
+	})
+	It("returns ok without write", func() {
+		Expect(statusResponseWriter.Status()).To(Equal(http.StatusOK))
+		Expect(statusResponseWriter.Bytes()).To(Equal(int64(0)))
+	})
+	It("returns first status written", func() {
+		statusResponseWriter.WriteHeader(http.StatusNotFound)
+		statusResponseWriter.WriteHeader(http.StatusOK)
+		Expect(statusResponseWriter.Status()).To(Equal(http.StatusNotFound))
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+	It("counts bytes written", func() {
+		_, err := statusResponseWriter.Write([]byte("hello"))
+		Expect(err).To(BeNil())
+		_, err = statusResponseWriter.Write([]byte(" world"))
+		Expect(err).To(BeNil())
+		Expect(statusResponseWriter.Status()).To(Equal(http.StatusOK))
+		Expect(statusResponseWriter.Bytes()).To(Equal(int64(11)))
+		Expect(recorder.Body.String()).To(Equal("hello world"))
+	})
+})
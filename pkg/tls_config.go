@@ -0,0 +1,78 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/bborbe/errors"
+)
+
+type TlsCertFile string
+
+func (t TlsCertFile) String() string {
+	return string(t)
+}
+
+type TlsKeyFile string
+
+func (t TlsKeyFile) String() string {
+	return string(t)
+}
+
+type TlsClientCaFile string
+
+func (t TlsClientCaFile) String() string {
+	return string(t)
+}
+
+// NewServerTlsConfig returns the tls config for the proxy listener.
+// If clientCaFile is set client certificates are verified against it and,
+// depending on the mode, required for every connection.
+func NewServerTlsConfig(
+	ctx context.Context,
+	certFile TlsCertFile,
+	keyFile TlsKeyFile,
+	clientCaFile TlsClientCaFile,
+	clientCertMode ClientCertMode,
+) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile.String(), keyFile.String())
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "load certificate %s failed", certFile)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+	}
+	if len(clientCaFile) == 0 {
+		return tlsConfig, nil
+	}
+	clientCAs, err := LoadCertPool(ctx, clientCaFile.String())
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "load client ca failed")
+	}
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	if clientCertMode == ClientCertModeAdditional {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// LoadCertPool reads all PEM certificates of the given file.
+func LoadCertPool(ctx context.Context, caFile string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "read ca file %s failed", caFile)
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(content) {
+		return nil, errors.Errorf(ctx, "no certificate found in %s", caFile)
+	}
+	return certPool, nil
+}
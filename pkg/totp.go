@@ -0,0 +1,91 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" // #nosec G505 -- RFC 6238 default algorithm supported by all authenticator apps
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpPeriod     = 30 * time.Second
+	totpDigits     = 6
+	totpSecretSize = 20
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TotpSecret is the base32 encoded shared secret of a user.
+type TotpSecret string
+
+func (t TotpSecret) String() string {
+	return string(t)
+}
+
+// Bytes returns the decoded secret. Padding, spaces and lower case are accepted.
+func (t TotpSecret) Bytes() ([]byte, error) {
+	value := strings.ToUpper(strings.ReplaceAll(t.String(), " ", ""))
+	return totpEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// TotpSkew is the number of time steps before and after now a code is accepted.
+type TotpSkew int
+
+func (t TotpSkew) Int() int {
+	return int(t)
+}
+
+// GenerateTotpSecret returns a new random secret.
+func GenerateTotpSecret() (TotpSecret, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return TotpSecret(totpEncoding.EncodeToString(secret)), nil
+}
+
+// TotpStep returns the RFC 6238 time step of the given time.
+func TotpStep(t time.Time) int64 {
+	return t.Unix() / int64(totpPeriod/time.Second)
+}
+
+// TotpCode returns the code of the given secret and time step (HMAC-SHA1, 6 digits).
+func TotpCode(secret TotpSecret, step int64) (string, error) {
+	key, err := secret.Bytes()
+	if err != nil {
+		return "", fmt.Errorf("decode totp secret failed: %v", err)
+	}
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step)) // #nosec G115 -- steps are positive
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// TotpURI returns the otpauth:// uri to enroll the secret in an authenticator app.
+func TotpURI(issuer string, username UserName, secret TotpSecret) string {
+	values := url.Values{}
+	values.Set("secret", secret.String())
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprintf("%d", totpDigits))
+	values.Set("period", fmt.Sprintf("%d", int(totpPeriod/time.Second)))
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + username.String(),
+		RawQuery: values.Encode(),
+	}).String()
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+)
+
+type TotpSecretFile string
+
+func (t TotpSecretFile) String() string {
+	return string(t)
+}
+
+type TotpLdapAttribute string
+
+func (t TotpLdapAttribute) String() string {
+	return string(t)
+}
+
+//counterfeiter:generate -o ../mocks/totp-secret-store.go --fake-name TotpSecretStore . TotpSecretStore
+type TotpSecretStore interface {
+	// TotpSecret returns the secret of the user. found is false if the user has no second factor.
+	TotpSecret(ctx context.Context, username UserName) (secret TotpSecret, found bool, err error)
+}
+
+// NewTotpFileSecretStore reads secrets from a file with lines of "username:base32secret".
+// The file is reloaded if its modification time changes.
+func NewTotpFileSecretStore(secretFile TotpSecretFile) TotpSecretStore {
+	return &totpFileSecretStore{
+		secretFile: secretFile,
+	}
+}
+
+type totpFileSecretStore struct {
+	secretFile TotpSecretFile
+
+	mux     sync.Mutex
+	modTime time.Time
+	secrets map[UserName]TotpSecret
+}
+
+func (t *totpFileSecretStore) TotpSecret(
+	ctx context.Context,
+	username UserName,
+) (TotpSecret, bool, error) {
+	secrets, err := t.load(ctx)
+	if err != nil {
+		return "", false, errors.Wrapf(ctx, err, "load totp secrets failed")
+	}
+	secret, found := secrets[username]
+	return secret, found, nil
+}
+
+func (t *totpFileSecretStore) load(ctx context.Context) (map[UserName]TotpSecret, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	fileInfo, err := os.Stat(t.secretFile.String())
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "stat totp secret file %s failed", t.secretFile)
+	}
+	if t.secrets != nil && fileInfo.ModTime().Equal(t.modTime) {
+		return t.secrets, nil
+	}
+	secrets, err := ReadTotpSecretFile(ctx, t.secretFile)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "read totp secret file %s failed", t.secretFile)
+	}
+	infof(ctx, 2, "loaded %d totp secrets from %s", len(secrets), t.secretFile)
+	t.secrets = secrets
+	t.modTime = fileInfo.ModTime()
+	return t.secrets, nil
+}
+
+// ReadTotpSecretFile returns all secrets of the given file.
+func ReadTotpSecretFile(
+	ctx context.Context,
+	secretFile TotpSecretFile,
+) (map[UserName]TotpSecret, error) {
+	content, err := os.ReadFile(secretFile.String())
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "read file %s failed", secretFile)
+	}
+	result := map[UserName]TotpSecret{}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf(ctx, "invalid line in %s", secretFile)
+		}
+		result[UserName(parts[0])] = TotpSecret(parts[1])
+	}
+	return result, nil
+}
+
+// WriteTotpSecret adds or replaces the secret of the user in the given file. Other lines
+// and comments keep their order. The file is replaced atomically by renaming a temp file.
+func WriteTotpSecret(
+	ctx context.Context,
+	secretFile TotpSecretFile,
+	username UserName,
+	secret TotpSecret,
+) error {
+	if strings.Contains(username.String(), ":") {
+		return errors.Errorf(ctx, "username must not contain ':'")
+	}
+	content, err := os.ReadFile(secretFile.String())
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(ctx, err, "read file %s failed", secretFile)
+	}
+	entry := fmt.Sprintf("%s:%s", username, secret)
+	var result strings.Builder
+	replaced := false
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) > 0 && !strings.HasPrefix(trimmed, "#") {
+			parts := strings.SplitN(trimmed, ":", 2)
+			if len(parts) != 2 {
+				return errors.Errorf(ctx, "invalid line in %s", secretFile)
+			}
+			if UserName(parts[0]) == username {
+				if replaced {
+					continue
+				}
+				line = entry
+				replaced = true
+			}
+		} else if len(content) == 0 {
+			continue
+		}
+		fmt.Fprintln(&result, line)
+	}
+	if !replaced {
+		fmt.Fprintln(&result, entry)
+	}
+	if err := writeFileAtomic(secretFile.String(), []byte(result.String())); err != nil {
+		return errors.Wrapf(ctx, err, "write file %s failed", secretFile)
+	}
+	return nil
+}
+
+// writeFileAtomic writes the content to a temp file in the same directory with mode 0600
+// and renames it, so readers never see a partially written file.
+func writeFileAtomic(filename string, content []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(content); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
+
+// NewTotpLdapSecretStore reads the secret from an attribute of the ldap user.
+func NewTotpLdapSecretStore(
+	ldapAuthenticator LdapAuthenticator,
+	attribute TotpLdapAttribute,
+) TotpSecretStore {
+	return &totpLdapSecretStore{
+		ldapAuthenticator: ldapAuthenticator,
+		attribute:         attribute,
+	}
+}
+
+type totpLdapSecretStore struct {
+	ldapAuthenticator LdapAuthenticator
+	attribute         TotpLdapAttribute
+}
+
+func (t *totpLdapSecretStore) TotpSecret(
+	ctx context.Context,
+	username UserName,
+) (TotpSecret, bool, error) {
+	attributes, err := t.ldapAuthenticator.GetUserAttributes(
+		username,
+		[]string{t.attribute.String()},
+	)
+	if err != nil {
+		return "", false, errors.Wrapf(
+			ctx,
+			err,
+			"get attribute %s of user %s failed",
+			t.attribute,
+			username,
+		)
+	}
+	secret := attributes[t.attribute.String()]
+	if len(secret) == 0 {
+		infof(ctx, 4, "user %s has no totp secret", username)
+		return "", false, nil
+	}
+	return TotpSecret(secret), true, nil
+}
+
+// NewCacheTotpSecretStore caches the secret of the totpSecretStore per user for ttl, so
+// basic auth does not search the directory on every request. Errors are not cached.
+func NewCacheTotpSecretStore(
+	totpSecretStore TotpSecretStore,
+	ttl CacheTTL,
+	maxEntries CacheMaxEntries,
+	now func() time.Time,
+) TotpSecretStore {
+	return &cacheTotpSecretStore{
+		totpSecretStore: totpSecretStore,
+		ttl:             ttl,
+		maxEntries:      maxEntries,
+		now:             now,
+		entries:         map[UserName]*list.Element{},
+		lru:             list.New(),
+	}
+}
+
+type cacheTotpSecretEntry struct {
+	username UserName
+	secret   TotpSecret
+	found    bool
+	expires  time.Time
+}
+
+type cacheTotpSecretStore struct {
+	totpSecretStore TotpSecretStore
+	ttl             CacheTTL
+	maxEntries      CacheMaxEntries
+	now             func() time.Time
+
+	mux     sync.Mutex
+	entries map[UserName]*list.Element
+	lru     *list.List
+}
+
+func (c *cacheTotpSecretStore) TotpSecret(
+	ctx context.Context,
+	username UserName,
+) (TotpSecret, bool, error) {
+	if entry, ok := c.get(username); ok {
+		infof(ctx, 4, "cache hit for totp secret of user %v", username)
+		return entry.secret, entry.found, nil
+	}
+	secret, found, err := c.totpSecretStore.TotpSecret(ctx, username)
+	if err != nil {
+		return "", false, err
+	}
+	c.set(username, secret, found)
+	return secret, found, nil
+}
+
+func (c *cacheTotpSecretStore) get(username UserName) (*cacheTotpSecretEntry, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	element, ok := c.entries[username]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheTotpSecretEntry)
+	if !entry.expires.After(c.now()) {
+		c.remove(element)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry, true
+}
+
+func (c *cacheTotpSecretStore) set(username UserName, secret TotpSecret, found bool) {
+	if c.ttl.Duration() <= 0 {
+		return
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if element, ok := c.entries[username]; ok {
+		c.remove(element)
+	}
+	c.entries[username] = c.lru.PushFront(&cacheTotpSecretEntry{
+		username: username,
+		secret:   secret,
+		found:    found,
+		expires:  c.now().Add(c.ttl.Duration()),
+	})
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries.Int() {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *cacheTotpSecretStore) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*cacheTotpSecretEntry).username)
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+var _ = Describe("TotpFileSecretStore", func() {
+	var ctx context.Context
+	var secretFile pkg.TotpSecretFile
+	var totpSecretStore pkg.TotpSecretStore
+	BeforeEach(func() {
+		ctx = context.Background()
+		secretFile = pkg.TotpSecretFile(filepath.Join(GinkgoT().TempDir(), "totp"))
+		Expect(pkg.WriteTotpSecret(ctx, secretFile, "myuser", "ABCDEFGH")).To(Succeed())
+		totpSecretStore = pkg.NewTotpFileSecretStore(secretFile)
+	})
+	It("returns secret of user", func() {
+		secret, found, err := totpSecretStore.TotpSecret(ctx, "myuser")
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(secret).To(Equal(pkg.TotpSecret("ABCDEFGH")))
+	})
+	It("reloads changed file", func() {
+		_, found, err := totpSecretStore.TotpSecret(ctx, "other")
+		Expect(err).To(BeNil())
+		Expect(found).To(BeFalse())
+
+		Expect(pkg.WriteTotpSecret(ctx, secretFile, "other", "IJKLMNOP")).To(Succeed())
+		modTime := time.Now().Add(time.Minute)
+		Expect(os.Chtimes(secretFile.String(), modTime, modTime)).To(Succeed())
+
+		secret, found, err := totpSecretStore.TotpSecret(ctx, "other")
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(secret).To(Equal(pkg.TotpSecret("IJKLMNOP")))
+	})
+})
+
+var _ = Describe("WriteTotpSecret", func() {
+	var ctx context.Context
+	var secretFile pkg.TotpSecretFile
+	BeforeEach(func() {
+		ctx = context.Background()
+		secretFile = pkg.TotpSecretFile(filepath.Join(GinkgoT().TempDir(), "totp"))
+		Expect(os.WriteFile(
+			secretFile.String(),
+			[]byte("# admins\nalice:AAAA\n\nmyuser:BBBB\nbob:CCCC\n"),
+			0600,
+		)).To(Succeed())
+	})
+	It("replaces secret and keeps order and comments", func() {
+		Expect(pkg.WriteTotpSecret(ctx, secretFile, "myuser", "DDDD")).To(Succeed())
+		content, err := os.ReadFile(secretFile.String())
+		Expect(err).To(BeNil())
+		Expect(string(content)).To(Equal("# admins\nalice:AAAA\n\nmyuser:DDDD\nbob:CCCC\n"))
+	})
+	It("appends new user", func() {
+		Expect(pkg.WriteTotpSecret(ctx, secretFile, "carol", "DDDD")).To(Succeed())
+		content, err := os.ReadFile(secretFile.String())
+		Expect(err).To(BeNil())
+		Expect(string(content)).To(Equal(
+			"# admins\nalice:AAAA\n\nmyuser:BBBB\nbob:CCCC\ncarol:DDDD\n",
+		))
+	})
+	It("creates missing file readable only by owner", func() {
+		secretFile = pkg.TotpSecretFile(filepath.Join(GinkgoT().TempDir(), "new"))
+		Expect(pkg.WriteTotpSecret(ctx, secretFile, "myuser", "DDDD")).To(Succeed())
+		content, err := os.ReadFile(secretFile.String())
+		Expect(err).To(BeNil())
+		Expect(string(content)).To(Equal("myuser:DDDD\n"))
+		fileInfo, err := os.Stat(secretFile.String())
+		Expect(err).To(BeNil())
+		Expect(fileInfo.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+	It("leaves no temp file", func() {
+		Expect(pkg.WriteTotpSecret(ctx, secretFile, "myuser", "DDDD")).To(Succeed())
+		entries, err := os.ReadDir(filepath.Dir(secretFile.String()))
+		Expect(err).To(BeNil())
+		Expect(entries).To(HaveLen(1))
+	})
+	It("rejects invalid file", func() {
+		Expect(os.WriteFile(secretFile.String(), []byte("invalid\n"), 0600)).To(Succeed())
+		Expect(pkg.WriteTotpSecret(ctx, secretFile, "myuser", "DDDD")).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("CacheTotpSecretStore", func() {
+	var ctx context.Context
+	var now time.Time
+	var totpSecretStore *mocks.TotpSecretStore
+	var cacheTotpSecretStore pkg.TotpSecretStore
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Unix(1700000000, 0)
+		totpSecretStore = &mocks.TotpSecretStore{}
+		totpSecretStore.TotpSecretReturns("ABCDEFGH", true, nil)
+		cacheTotpSecretStore = pkg.NewCacheTotpSecretStore(
+			totpSecretStore,
+			pkg.CacheTTL(time.Minute),
+			10,
+			func() time.Time { return now },
+		)
+	})
+	It("returns secret of store", func() {
+		secret, found, err := cacheTotpSecretStore.TotpSecret(ctx, "myuser")
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(secret).To(Equal(pkg.TotpSecret("ABCDEFGH")))
+	})
+	It("caches secret", func() {
+		_, _, err := cacheTotpSecretStore.TotpSecret(ctx, "myuser")
+		Expect(err).To(BeNil())
+		_, _, err = cacheTotpSecretStore.TotpSecret(ctx, "myuser")
+		Expect(err).To(BeNil())
+		Expect(totpSecretStore.TotpSecretCallCount()).To(Equal(1))
+	})
+	It("caches user without secret", func() {
+		totpSecretStore.TotpSecretReturns("", false, nil)
+		_, _, err := cacheTotpSecretStore.TotpSecret(ctx, "myuser")
+		Expect(err).To(BeNil())
+		_, found, err := cacheTotpSecretStore.TotpSecret(ctx, "myuser")
+		Expect(err).To(BeNil())
+		Expect(found).To(BeFalse())
+		Expect(totpSecretStore.TotpSecretCallCount()).To(Equal(1))
+	})
+	It("reloads expired secret", func() {
+		_, _, err := cacheTotpSecretStore.TotpSecret(ctx, "myuser")
+		Expect(err).To(BeNil())
+		now = now.Add(2 * time.Minute)
+		_, _, err = cacheTotpSecretStore.TotpSecret(ctx, "myuser")
+		Expect(err).To(BeNil())
+		Expect(totpSecretStore.TotpSecretCallCount()).To(Equal(2))
+	})
+	It("does not cache errors", func() {
+		totpSecretStore.TotpSecretReturns("", false, errors.New("banana"))
+		_, _, err := cacheTotpSecretStore.TotpSecret(ctx, "myuser")
+		Expect(err).NotTo(BeNil())
+		_, _, err = cacheTotpSecretStore.TotpSecret(ctx, "myuser")
+		Expect(err).NotTo(BeNil())
+		Expect(totpSecretStore.TotpSecretCallCount()).To(Equal(2))
+	})
+})
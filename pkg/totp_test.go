@@ -0,0 +1,118 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"encoding/base32"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+// rfc6238Secret is the SHA1 seed of the RFC 6238 test vectors.
+var rfc6238Secret = pkg.TotpSecret(
+	base32.StdEncoding.EncodeToString([]byte("12345678901234567890")),
+)
+
+var _ = Describe("Totp", func() {
+	DescribeTable("TotpCode matches RFC 6238 test vectors",
+		func(unix int64, expected string) {
+			code, err := pkg.TotpCode(rfc6238Secret, pkg.TotpStep(time.Unix(unix, 0)))
+			Expect(err).To(BeNil())
+			Expect(code).To(Equal(expected))
+		},
+		Entry("59", int64(59), "287082"),
+		Entry("1111111109", int64(1111111109), "081804"),
+		Entry("1111111111", int64(1111111111), "050471"),
+		Entry("1234567890", int64(1234567890), "005924"),
+		Entry("2000000000", int64(2000000000), "279037"),
+	)
+	It("GenerateTotpSecret returns decodable secret", func() {
+		secret, err := pkg.GenerateTotpSecret()
+		Expect(err).To(BeNil())
+		bytes, err := secret.Bytes()
+		Expect(err).To(BeNil())
+		Expect(bytes).To(HaveLen(20))
+	})
+	It("TotpURI contains secret and issuer", func() {
+		u, err := url.Parse(pkg.TotpURI("proxy", "myuser", "ABCDEF"))
+		Expect(err).To(BeNil())
+		Expect(u.Scheme).To(Equal("otpauth"))
+		Expect(u.Host).To(Equal("totp"))
+		Expect(u.Path).To(Equal("/proxy:myuser"))
+		Expect(u.Query().Get("secret")).To(Equal("ABCDEF"))
+		Expect(u.Query().Get("issuer")).To(Equal("proxy"))
+	})
+})
+
+var _ = Describe("TotpVerifier", func() {
+	var ctx context.Context
+	var now time.Time
+	var verifier pkg.TotpVerifier
+	var secretFile pkg.TotpSecretFile
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Unix(1111111111, 0)
+		secretFile = pkg.TotpSecretFile(filepath.Join(GinkgoT().TempDir(), "totp"))
+		Expect(pkg.WriteTotpSecret(ctx, secretFile, "myuser", rfc6238Secret)).To(Succeed())
+		verifier = pkg.NewTotpVerifier(
+			pkg.NewTotpFileSecretStore(secretFile),
+			1,
+			func() time.Time { return now },
+		)
+	})
+	It("is enabled for enrolled user", func() {
+		enabled, err := verifier.Enabled(ctx, "myuser")
+		Expect(err).To(BeNil())
+		Expect(enabled).To(BeTrue())
+	})
+	It("is disabled for other user", func() {
+		enabled, err := verifier.Enabled(ctx, "other")
+		Expect(err).To(BeNil())
+		Expect(enabled).To(BeFalse())
+	})
+	It("accepts current code", func() {
+		valid, err := verifier.Verify(ctx, "myuser", "050471")
+		Expect(err).To(BeNil())
+		Expect(valid).To(BeTrue())
+	})
+	It("accepts code within skew", func() {
+		now = now.Add(30 * time.Second)
+		valid, err := verifier.Verify(ctx, "myuser", "050471")
+		Expect(err).To(BeNil())
+		Expect(valid).To(BeTrue())
+	})
+	It("rejects code outside skew", func() {
+		now = now.Add(90 * time.Second)
+		valid, err := verifier.Verify(ctx, "myuser", "050471")
+		Expect(err).To(BeNil())
+		Expect(valid).To(BeFalse())
+	})
+	It("rejects wrong code", func() {
+		valid, err := verifier.Verify(ctx, "myuser", "123456")
+		Expect(err).To(BeNil())
+		Expect(valid).To(BeFalse())
+	})
+	It("rejects replayed code", func() {
+		valid, err := verifier.Verify(ctx, "myuser", "050471")
+		Expect(err).To(BeNil())
+		Expect(valid).To(BeTrue())
+		valid, err = verifier.Verify(ctx, "myuser", "050471")
+		Expect(err).To(BeNil())
+		Expect(valid).To(BeFalse())
+	})
+	It("replaces secret on enroll", func() {
+		Expect(pkg.WriteTotpSecret(ctx, secretFile, "myuser", "ABCDEFGH")).To(Succeed())
+		secrets, err := pkg.ReadTotpSecretFile(ctx, secretFile)
+		Expect(err).To(BeNil())
+		Expect(secrets).To(Equal(map[pkg.UserName]pkg.TotpSecret{"myuser": "ABCDEFGH"}))
+	})
+})
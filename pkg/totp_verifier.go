@@ -0,0 +1,104 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"crypto/subtle"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+)
+
+//counterfeiter:generate -o ../mocks/totp-verifier.go --fake-name TotpVerifier . TotpVerifier
+type TotpVerifier interface {
+	// Enabled returns true if the user has to enter a totp code.
+	Enabled(ctx context.Context, username UserName) (bool, error)
+	// Verify checks the code and rejects codes already used.
+	Verify(ctx context.Context, username UserName, code string) (bool, error)
+}
+
+// TotpVerifierDisabled is used if no totp secrets are configured.
+var TotpVerifierDisabled TotpVerifier = totpVerifierDisabled{}
+
+type totpVerifierDisabled struct{}
+
+func (t totpVerifierDisabled) Enabled(ctx context.Context, username UserName) (bool, error) {
+	return false, nil
+}
+
+func (t totpVerifierDisabled) Verify(
+	ctx context.Context,
+	username UserName,
+	code string,
+) (bool, error) {
+	return false, nil
+}
+
+func NewTotpVerifier(
+	totpSecretStore TotpSecretStore,
+	skew TotpSkew,
+	now func() time.Time,
+) TotpVerifier {
+	return &totpVerifier{
+		totpSecretStore: totpSecretStore,
+		skew:            skew,
+		now:             now,
+		usedSteps:       map[UserName]int64{},
+	}
+}
+
+type totpVerifier struct {
+	totpSecretStore TotpSecretStore
+	skew            TotpSkew
+	now             func() time.Time
+
+	mux       sync.Mutex
+	usedSteps map[UserName]int64
+}
+
+func (t *totpVerifier) Enabled(ctx context.Context, username UserName) (bool, error) {
+	_, found, err := t.totpSecretStore.TotpSecret(ctx, username)
+	if err != nil {
+		return false, errors.Wrapf(ctx, err, "get totp secret failed")
+	}
+	return found, nil
+}
+
+func (t *totpVerifier) Verify(ctx context.Context, username UserName, code string) (bool, error) {
+	secret, found, err := t.totpSecretStore.TotpSecret(ctx, username)
+	if err != nil {
+		return false, errors.Wrapf(ctx, err, "get totp secret failed")
+	}
+	if !found {
+		return false, nil
+	}
+	now := TotpStep(t.now())
+	var matchedStep int64 = -1
+	for step := now - int64(t.skew); step <= now+int64(t.skew); step++ {
+		expected, err := TotpCode(secret, step)
+		if err != nil {
+			return false, errors.Wrapf(ctx, err, "create totp code for user %s failed", username)
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			matchedStep = step
+		}
+	}
+	if matchedStep < 0 {
+		infof(ctx, 1, "totp code of user %s invalid", username)
+		return false, nil
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if matchedStep <= t.usedSteps[username] {
+		infof(ctx, 1, "totp code of user %s already used", username)
+		return false, nil
+	}
+	t.usedSteps[username] = matchedStep
+	infof(ctx, 2, "totp code of user %s valid", username)
+	return true, nil
+}
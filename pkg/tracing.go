@@ -0,0 +1,184 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/bborbe/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/bborbe/auth-http-proxy"
+
+// Attributes of the verification spans.
+const (
+	attributeAuthMethod   = attribute.Key("auth.method")
+	attributeAuthVerifier = attribute.Key("auth.verifier")
+	attributeAuthResult   = attribute.Key("auth.result")
+	attributeCacheResult  = attribute.Key("auth.cache.result")
+)
+
+// tracer returns the tracer of the global tracer provider, which does not record
+// spans until a provider is set with otel.SetTracerProvider.
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// OtlpEndpoint is the url of an OTLP/HTTP collector like http://localhost:4318.
+type OtlpEndpoint string
+
+func (o OtlpEndpoint) String() string {
+	return string(o)
+}
+
+// Validate returns an error if the endpoint is not a http or https url.
+func (o OtlpEndpoint) Validate(ctx context.Context) error {
+	endpoint, err := url.Parse(o.String())
+	if err != nil {
+		return errors.Wrapf(ctx, err, "parse otlp endpoint %s failed", o)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" || len(endpoint.Host) == 0 {
+		return errors.Errorf(ctx, "otlp endpoint %s is not a http or https url", o)
+	}
+	return nil
+}
+
+// TraceSampleRatio is the fraction of new traces recorded. Requests with a
+// traceparent header follow the sampling decision of the caller.
+type TraceSampleRatio float64
+
+func (t TraceSampleRatio) Float64() float64 {
+	return float64(t)
+}
+
+// NewTracerProvider exports spans in batches to the OTLP endpoint. The service name
+// auth-http-proxy can be overridden with OTEL_SERVICE_NAME.
+func NewTracerProvider(
+	ctx context.Context,
+	endpoint OtlpEndpoint,
+	sampleRatio TraceSampleRatio,
+) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint.String()))
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "create otlp exporter for %s failed", endpoint)
+	}
+	res, err := resource.New(
+		ctx,
+		resource.WithAttributes(semconv.ServiceName("auth-http-proxy")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "create trace resource failed")
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(
+			sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio.Float64())),
+		),
+	), nil
+}
+
+// NewTracingHandler starts a server span per request, continuing the trace of the
+// W3C traceparent header.
+func NewTracingHandler(subhandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(
+			request.Context(),
+			propagation.HeaderCarrier(request.Header),
+		)
+		ctx, span := tracer().Start(
+			ctx,
+			request.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(request.Method),
+				semconv.URLPath(request.URL.Path),
+				semconv.ClientAddress(ipKey(ClientIP(request))),
+			),
+		)
+		defer span.End()
+		if requestID := RequestIDFromContext(ctx); len(requestID) > 0 {
+			span.SetAttributes(semconv.HTTPRequestHeader("x-request-id", requestID))
+		}
+		statusResponseWriter := NewStatusResponseWriter(responseWriter)
+		subhandler.ServeHTTP(statusResponseWriter, request.WithContext(ctx))
+		span.SetAttributes(semconv.HTTPResponseStatusCode(statusResponseWriter.Status()))
+		if statusResponseWriter.Status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(statusResponseWriter.Status()))
+		}
+	})
+}
+
+// NewTracingVerifier records a span for each verification of the verifier. The
+// returned verifier is a ReasonVerifier passing on the reasons of the verifier.
+func NewTracingVerifier(name string, verifier Verifier) ReasonVerifier {
+	return &tracingVerifier{
+		name:     name,
+		verifier: verifier,
+	}
+}
+
+type tracingVerifier struct {
+	name     string
+	verifier Verifier
+}
+
+func (t *tracingVerifier) Verify(
+	ctx context.Context,
+	username UserName,
+	password Password,
+) (bool, error) {
+	ok, _, err := t.VerifyWithReason(ctx, username, password)
+	return ok, err
+}
+
+func (t *tracingVerifier) VerifyWithReason(
+	ctx context.Context,
+	username UserName,
+	password Password,
+) (bool, AuditReason, error) {
+	ctx, span := tracer().Start(
+		ctx,
+		"verify "+t.name,
+		trace.WithAttributes(
+			attributeAuthVerifier.String(t.name),
+			semconv.EnduserID(username.String()),
+		),
+	)
+	defer span.End()
+	ok, reason, err := verifyWithReason(ctx, t.verifier, username, password)
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "verify failed")
+		span.SetAttributes(attributeAuthResult.String(OutcomeError))
+	case ok:
+		span.SetAttributes(attributeAuthResult.String(OutcomeSuccess))
+	default:
+		span.SetAttributes(attributeAuthResult.String(OutcomeInvalid))
+	}
+	return ok, reason, err
+}
+
+// recordSpanAuth adds the authenticated user to the span of the request.
+func recordSpanAuth(ctx context.Context, user UserName, method AuthMethod) {
+	trace.SpanFromContext(ctx).SetAttributes(
+		semconv.EnduserID(user.String()),
+		attributeAuthMethod.String(method.String()),
+	)
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2026 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bborbe/auth-http-proxy/mocks"
+	"github.com/bborbe/auth-http-proxy/pkg"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// spanByName returns the ended span with the given name.
+func spanByName(exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	for _, span := range exporter.GetSpans() {
+		if span.Name == name {
+			return span
+		}
+	}
+	Fail("span " + name + " not found")
+	return tracetest.SpanStub{}
+}
+
+// spanByKind returns the ended span with the given kind.
+func spanByKind(exporter *tracetest.InMemoryExporter, kind trace.SpanKind) tracetest.SpanStub {
+	for _, span := range exporter.GetSpans() {
+		if span.SpanKind == kind {
+			return span
+		}
+	}
+	Fail("span of kind " + kind.String() + " not found")
+	return tracetest.SpanStub{}
+}
+
+// spanAttribute returns the value of the attribute of the span.
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) string {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+var _ = Describe("Tracing", func() {
+	var exporter *tracetest.InMemoryExporter
+	var verifier *mocks.Verifier
+	var upstreamErr error
+	var upstreamHeader http.Header
+	var handler http.Handler
+	BeforeEach(func() {
+		exporter = tracetest.NewInMemoryExporter()
+		tracerProvider := otel.GetTracerProvider()
+		propagator := otel.GetTextMapPropagator()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		DeferCleanup(func() {
+			otel.SetTracerProvider(tracerProvider)
+			otel.SetTextMapPropagator(propagator)
+		})
+
+		verifier = &mocks.Verifier{}
+		verifier.VerifyReturns(true, nil)
+		upstreamErr = nil
+		upstreamHeader = nil
+	})
+	JustBeforeEach(func() {
+		cacheAuth := pkg.NewCacheAuth(
+			pkg.NewTracingVerifier("file", verifier),
+			pkg.CacheTTL(time.Minute),
+			0,
+			0,
+			10,
+			pkg.AuditLogDisabled,
+			time.Now,
+		)
+		authorizer := &mocks.Authorizer{}
+		authorizer.AuthorizeReturns(true, nil)
+		handler = pkg.NewTracingHandler(pkg.NewAuthBasicHandler(
+			pkg.NewForwardHandler(
+				"target",
+				func(address string, req *http.Request) (*http.Response, error) {
+					upstreamHeader = req.Header
+					if upstreamErr != nil {
+						return nil, upstreamErr
+					}
+					recorder := httptest.NewRecorder()
+					recorder.WriteHeader(http.StatusOK)
+					return recorder.Result(), nil
+				},
+			),
+			pkg.CheckFunc(func(ctx context.Context, username string, password string) (bool, error) {
+				return cacheAuth.Verify(ctx, pkg.UserName(username), pkg.Password(password))
+			}),
+			&mocks.ApiKeyAuth{},
+			&mocks.ClientCertAuth{},
+			authorizer,
+			&mocks.LoginThrottle{},
+			"realm",
+			pkg.AuditLogDisabled,
+		))
+	})
+	serve := func() *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/path", nil)
+		request.SetBasicAuth("alice", "secret")
+		request.Header.Set("traceparent", traceparent)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+	It("continues trace of traceparent", func() {
+		Expect(serve().Code).To(Equal(http.StatusOK))
+		server := spanByKind(exporter, trace.SpanKindServer)
+		Expect(server.Name).To(Equal(http.MethodGet))
+		Expect(server.SpanContext.TraceID().String()).
+			To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+		Expect(server.Parent.SpanID().String()).To(Equal("00f067aa0ba902b7"))
+		Expect(spanAttribute(server, "http.response.status_code")).To(Equal("200"))
+		Expect(spanAttribute(server, "enduser.id")).To(Equal("alice"))
+		Expect(spanAttribute(server, "auth.method")).To(Equal("basic"))
+	})
+	It("records verification spans", func() {
+		serve()
+		server := spanByKind(exporter, trace.SpanKindServer)
+		cache := spanByName(exporter, "verify cache")
+		Expect(cache.Parent.SpanID()).To(Equal(server.SpanContext.SpanID()))
+		Expect(spanAttribute(cache, "auth.cache.result")).To(Equal("miss"))
+		backend := spanByName(exporter, "verify file")
+		Expect(backend.Parent.SpanID()).To(Equal(cache.SpanContext.SpanID()))
+		Expect(spanAttribute(backend, "auth.result")).To(Equal(pkg.OutcomeSuccess))
+	})
+	It("records cache hit without backend span", func() {
+		serve()
+		exporter.Reset()
+		serve()
+		Expect(spanAttribute(spanByName(exporter, "verify cache"), "auth.cache.result")).
+			To(Equal("hit"))
+		for _, span := range exporter.GetSpans() {
+			Expect(span.Name).NotTo(Equal("verify file"))
+		}
+	})
+	It("propagates upstream span to target", func() {
+		serve()
+		upstream := spanByKind(exporter, trace.SpanKindClient)
+		Expect(upstream.Parent.SpanID()).
+			To(Equal(spanByKind(exporter, trace.SpanKindServer).SpanContext.SpanID()))
+		Expect(upstream.SpanContext.TraceID().String()).
+			To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+		Expect(upstreamHeader.Get("traceparent")).To(Equal(
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-" + upstream.SpanContext.SpanID().String() + "-01",
+		))
+	})
+	Context("upstream fails", func() {
+		BeforeEach(func() {
+			upstreamErr = errors.New("banana")
+		})
+		It("marks spans as error", func() {
+			Expect(serve().Code).To(Equal(http.StatusInternalServerError))
+			Expect(spanByKind(exporter, trace.SpanKindClient).Status.Code).To(Equal(codes.Error))
+			Expect(spanByKind(exporter, trace.SpanKindServer).Status.Code).To(Equal(codes.Error))
+		})
+	})
+	Context("backend fails", func() {
+		BeforeEach(func() {
+			verifier.VerifyReturns(false, errors.New("banana"))
+		})
+		It("records error", func() {
+			serve()
+			backend := spanByName(exporter, "verify file")
+			Expect(backend.Status.Code).To(Equal(codes.Error))
+			Expect(spanAttribute(backend, "auth.result")).To(Equal(pkg.OutcomeError))
+		})
+	})
+})
+
+var _ = Describe("TracingVerifier", func() {
+	var verifier *mocks.ReasonVerifier
+	var auditLog *mocks.AuditLog
+	var cacheAuth pkg.CacheAuth
+	BeforeEach(func() {
+		verifier = &mocks.ReasonVerifier{}
+		verifier.VerifyWithReasonReturns(false, pkg.AuditReasonMissingGroup, nil)
+		auditLog = &mocks.AuditLog{}
+		cacheAuth = pkg.NewCacheAuth(
+			pkg.NewTracingVerifier("ldap", verifier),
+			pkg.CacheTTL(time.Minute),
+			0,
+			0,
+			10,
+			auditLog,
+			time.Now,
+		)
+	})
+	It("passes reason of verifier", func() {
+		Expect(cacheAuth.Verify(context.Background(), "alice", "secret")).To(BeFalse())
+		Expect(verifier.VerifyCallCount()).To(Equal(0))
+		Expect(auditLog.LogCallCount()).To(Equal(1))
+		_, event := auditLog.LogArgsForCall(0)
+		Expect(event.Type).To(Equal(pkg.AuditVerifyFailure))
+		Expect(event.Reason).To(Equal(pkg.AuditReasonMissingGroup))
+	})
+	It("returns invalid credentials for verifier without reason", func() {
+		plain := &mocks.Verifier{}
+		plain.VerifyReturns(false, nil)
+		ok, reason, err := pkg.NewTracingVerifier("file", plain).
+			VerifyWithReason(context.Background(), "alice", "wrong")
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeFalse())
+		Expect(reason).To(Equal(pkg.AuditReasonInvalidCredentials))
+	})
+})
+
+var _ = Describe("OtlpEndpoint", func() {
+	DescribeTable("Validate",
+		func(endpoint pkg.OtlpEndpoint, valid bool) {
+			err := endpoint.Validate(context.Background())
+			Expect(err == nil).To(Equal(valid))
+		},
+		Entry("http", pkg.OtlpEndpoint("http://localhost:4318"), true),
+		Entry("https", pkg.OtlpEndpoint("https://collector.example.com"), true),
+		Entry("without scheme", pkg.OtlpEndpoint("localhost:4318"), false),
+		Entry("grpc", pkg.OtlpEndpoint("grpc://localhost:4317"), false),
+	)
+})
@@ -4,6 +4,8 @@
 
 package pkg
 
+import "context"
+
 type UserName string
 
 func (u UserName) String() string {
@@ -16,6 +18,53 @@ func (p Password) String() string {
 	return string(p)
 }
 
+//counterfeiter:generate -o ../mocks/verifier.go --fake-name Verifier . Verifier
 type Verifier interface {
-	Verify(UserName, Password) (bool, error)
+	Verify(context.Context, UserName, Password) (bool, error)
+}
+
+// ReasonVerifier is implemented by verifiers telling why credentials were rejected.
+//
+//counterfeiter:generate -o ../mocks/reason-verifier.go --fake-name ReasonVerifier . ReasonVerifier
+type ReasonVerifier interface {
+	Verifier
+	VerifyWithReason(context.Context, UserName, Password) (bool, AuditReason, error)
+}
+
+// verifyWithReason uses ReasonVerifier if implemented by the verifier.
+func verifyWithReason(
+	ctx context.Context,
+	verifier Verifier,
+	username UserName,
+	password Password,
+) (bool, AuditReason, error) {
+	if reasonVerifier, ok := verifier.(ReasonVerifier); ok {
+		return reasonVerifier.VerifyWithReason(ctx, username, password)
+	}
+	ok, err := verifier.Verify(ctx, username, password)
+	if err != nil {
+		return false, AuditReasonBackendError, err
+	}
+	if !ok {
+		return false, AuditReasonInvalidCredentials, nil
+	}
+	return true, "", nil
+}
+
+// VerifierNone accepts every user. It is used for digest auth if the verifier has no
+// required groups or attributes.
+var VerifierNone Verifier = VerifierFunc(
+	func(ctx context.Context, username UserName, password Password) (bool, error) {
+		return true, nil
+	},
+)
+
+type VerifierFunc func(context.Context, UserName, Password) (bool, error)
+
+func (v VerifierFunc) Verify(
+	ctx context.Context,
+	username UserName,
+	password Password,
+) (bool, error) {
+	return v(ctx, username, password)
 }